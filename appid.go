@@ -0,0 +1,21 @@
+package pkg
+
+import "strings"
+
+// WithPreserveAppIDCase sends the application ID exactly as given instead of
+// uppercasing it. Eureka stores application names in upper case, so this is
+// only useful with servers or proxies that match names case-sensitively in
+// some other way.
+func WithPreserveAppIDCase() Option {
+	return func(c *Client) {
+		c.preserveAppIDCase = true
+	}
+}
+
+// normalizeAppID returns the form of an application name used in requests.
+func (c *Client) normalizeAppID(appID string) string {
+	if c.preserveAppIDCase {
+		return appID
+	}
+	return strings.ToUpper(appID)
+}
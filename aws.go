@@ -0,0 +1,232 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+const (
+	defaultIMDSEndpoint = "http://169.254.169.254"
+	imdsTokenTTL        = "21600"
+	// imdsProbeTimeout bounds the token request, which fails fast off EC2.
+	imdsProbeTimeout = 2 * time.Second
+)
+
+// Keys of the Amazon data center metadata, as published by the Java client.
+const (
+	AmazonInstanceID       = "instance-id"
+	AmazonAMIID            = "ami-id"
+	AmazonInstanceType     = "instance-type"
+	AmazonLocalIPv4        = "local-ipv4"
+	AmazonLocalHostname    = "local-hostname"
+	AmazonPublicIPv4       = "public-ipv4"
+	AmazonPublicHostname   = "public-hostname"
+	AmazonAvailabilityZone = "availability-zone"
+)
+
+// imdsPaths maps the Amazon metadata keys to their IMDS paths. Public
+// addresses are optional; instances in private subnets don't have them.
+var imdsPaths = []struct {
+	key, path string
+	optional  bool
+}{
+	{AmazonInstanceID, "instance-id", false},
+	{AmazonAMIID, "ami-id", false},
+	{AmazonInstanceType, "instance-type", false},
+	{AmazonLocalIPv4, "local-ipv4", false},
+	{AmazonLocalHostname, "local-hostname", false},
+	{AmazonAvailabilityZone, "placement/availability-zone", false},
+	{AmazonPublicIPv4, "public-ipv4", true},
+	{AmazonPublicHostname, "public-hostname", true},
+}
+
+// imdsClient reads the EC2 instance metadata service using IMDSv2.
+type imdsClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// AmazonDataCenterInfo reads Amazon data center info from the EC2 instance
+// metadata service (IMDSv2): the instance ID, AMI, instance type, addresses
+// and availability zone, as the Java client does. The availability zone is
+// used as the zone and the private address as the address to register. Off
+// EC2 the client falls back to MyOwn.
+func AmazonDataCenterInfo() DataCenterInfoProvider {
+	return newIMDSClient("")
+}
+
+func newIMDSClient(endpoint string) *imdsClient {
+	if endpoint == "" {
+		endpoint = defaultIMDSEndpoint
+	}
+	return &imdsClient{endpoint: strings.TrimRight(endpoint, "/"), client: &http.Client{}}
+}
+
+// DataCenterInfo returns the data center info, private address and
+// availability zone of the EC2 instance.
+func (m *imdsClient) DataCenterInfo(ctx context.Context) (DataCenterInfo, error) {
+	token, err := m.token(ctx)
+	if err != nil {
+		return DataCenterInfo{}, err
+	}
+
+	kv := make(map[string]string, len(imdsPaths))
+	for _, p := range imdsPaths {
+		value, err := m.get(ctx, token, p.path)
+		if errors.Is(err, errIMDSNotFound) && p.optional {
+			continue
+		}
+		if err != nil {
+			return DataCenterInfo{}, fmt.Errorf("failed to read %s from instance metadata: %w", p.key, err)
+		}
+		kv[p.key] = value
+	}
+	return DataCenterInfo{
+		Name:     DataCenterAmazon,
+		Class:    eurekaapi.AmazonInfoClass,
+		Metadata: kv,
+		IP:       net.ParseIP(kv[AmazonLocalIPv4]),
+		Zone:     kv[AmazonAvailabilityZone],
+	}, nil
+}
+
+func (m *imdsClient) token(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, imdsProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, m.endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", imdsTokenTTL)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: EC2 instance metadata service is unreachable: %w", ErrDataCenterInfoUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get instance metadata token: unexpected status %s", resp.Status)
+	}
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read instance metadata token: %w", err)
+	}
+	return string(token), nil
+}
+
+var errIMDSNotFound = errors.New("not found")
+
+func (m *imdsClient) get(ctx context.Context, token, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.endpoint+"/latest/meta-data/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", errIMDSNotFound
+	default:
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	value, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// ecsClient reads the ECS task metadata endpoint (version 4).
+type ecsClient struct {
+	getenv func(key string) string
+	client *http.Client
+}
+
+// ECSDataCenterInfo reads Amazon data center info from the ECS task metadata
+// endpoint: the task ID as instance ID, the availability zone and the address
+// of the container in the task network. Outside ECS the client falls back to
+// MyOwn.
+func ECSDataCenterInfo() DataCenterInfoProvider {
+	return &ecsClient{getenv: os.Getenv, client: &http.Client{Timeout: imdsProbeTimeout}}
+}
+
+// ecsContainer and ecsTask hold the fields read from the task metadata
+// endpoint.
+type ecsContainer struct {
+	Networks []struct {
+		IPv4Addresses []string
+	}
+}
+
+type ecsTask struct {
+	TaskARN          string
+	AvailabilityZone string
+}
+
+// DataCenterInfo returns Amazon data center info for the task, with its ID
+// in place of an EC2 instance ID, and the address of the container in the
+// task network.
+func (e *ecsClient) DataCenterInfo(ctx context.Context) (DataCenterInfo, error) {
+	endpoint := e.getenv("ECS_CONTAINER_METADATA_URI_V4")
+	if endpoint == "" {
+		return DataCenterInfo{}, fmt.Errorf("%w: not running in an ECS task", ErrDataCenterInfoUnavailable)
+	}
+
+	var container ecsContainer
+	if err := e.get(ctx, endpoint, &container); err != nil {
+		return DataCenterInfo{}, fmt.Errorf("failed to read ECS container metadata: %w", err)
+	}
+	var task ecsTask
+	if err := e.get(ctx, endpoint+"/task", &task); err != nil {
+		return DataCenterInfo{}, fmt.Errorf("failed to read ECS task metadata: %w", err)
+	}
+
+	kv := map[string]string{
+		AmazonInstanceID:       task.TaskARN[strings.LastIndex(task.TaskARN, "/")+1:],
+		AmazonAvailabilityZone: task.AvailabilityZone,
+	}
+	info := DataCenterInfo{
+		Name:     DataCenterAmazon,
+		Class:    eurekaapi.AmazonInfoClass,
+		Metadata: kv,
+		Zone:     task.AvailabilityZone,
+	}
+	for _, network := range container.Networks {
+		if len(network.IPv4Addresses) > 0 {
+			kv[AmazonLocalIPv4] = network.IPv4Addresses[0]
+			info.IP = net.ParseIP(network.IPv4Addresses[0])
+			break
+		}
+	}
+	return info, nil
+}
+
+func (e *ecsClient) get(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
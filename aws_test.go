@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func newFakeIMDS(t *testing.T) *httptest.Server {
+	t.Helper()
+	values := map[string]string{
+		"instance-id":                 "i-0abc",
+		"ami-id":                      "ami-0def",
+		"instance-type":               "m5.large",
+		"local-ipv4":                  "10.0.0.7",
+		"local-hostname":              "ip-10-0-0-7.ec2.internal",
+		"placement/availability-zone": "us-east-1a",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+			w.Write([]byte("token"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		value, ok := values[strings.TrimPrefix(r.URL.Path, "/latest/meta-data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(value))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAmazonDataCenter(t *testing.T) {
+	imds := newFakeIMDS(t)
+	f := newFakeEureka(t)
+	client := newTestClient(t, f, WithAmazonDataCenter())
+	client.dataCenterProvider = newIMDSClient(imds.URL)
+
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	info := client.registration.DataCenterInfo
+	if info.Name != eurekaapi.AmazonDataCenter || info.Class != eurekaapi.AmazonInfoClass {
+		t.Errorf("data center = %s (%s); want Amazon", info.Name, info.Class)
+	}
+	kv := info.Metadata.Map()
+	if kv[AmazonInstanceID] != "i-0abc" || kv[AmazonAvailabilityZone] != "us-east-1a" {
+		t.Errorf("Amazon metadata = %v; want the instance ID and availability zone", kv)
+	}
+	if _, ok := kv[AmazonPublicHostname]; ok {
+		t.Errorf("Amazon metadata has a public hostname the instance doesn't have")
+	}
+
+	out, err := xml.Marshal(info)
+	if err != nil {
+		t.Fatalf("xml.Marshal returned error: %v", err)
+	}
+	if !strings.Contains(string(out), `class="com.netflix.appinfo.AmazonInfo"`) || !strings.Contains(string(out), "<instance-id>i-0abc</instance-id>") {
+		t.Errorf("encoded data center = %s; want the AmazonInfo class and metadata", out)
+	}
+}
+
+func TestAmazonDataCenterOffEC2(t *testing.T) {
+	imds := newFakeIMDS(t)
+	imds.Close()
+	f := newFakeEureka(t)
+	client := newTestClient(t, f, WithAmazonDataCenter())
+	client.dataCenterProvider = newIMDSClient(imds.URL)
+
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if got := client.registration.DataCenterInfo.Name; got != eurekaapi.DefaultDataCenter {
+		t.Errorf("data center off EC2 = %s; want MyOwn", got)
+	}
+}
+
+func TestECSDataCenter(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v4/abc":
+			w.Write([]byte(`{"DockerId":"abc","Networks":[{"NetworkMode":"awsvpc","IPv4Addresses":["10.0.2.106"]}]}`))
+		case "/v4/abc/task":
+			w.Write([]byte(`{"Cluster":"shop","TaskARN":"arn:aws:ecs:us-west-2:111122223333:task/shop/158d1c8083dd49d6b527399fd6414f5c","AvailabilityZone":"us-west-2b"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer metadata.Close()
+
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	client.dataCenterProvider = &ecsClient{
+		getenv: func(string) string { return metadata.URL + "/v4/abc" },
+		client: metadata.Client(),
+	}
+
+	// Without an address the one of the task is registered.
+	if _, err := client.RegisterInstance(context.Background(), nil, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	reg := client.registration
+	if reg.IPAddr != "10.0.2.106" {
+		t.Errorf("registered address = %s; want the task's 10.0.2.106", reg.IPAddr)
+	}
+	if zone, _ := reg.Metadata.Get(MetadataZone); zone != "us-west-2b" {
+		t.Errorf("zone metadata = %q; want us-west-2b", zone)
+	}
+	kv := reg.DataCenterInfo.Metadata.Map()
+	if reg.DataCenterInfo.Name != eurekaapi.AmazonDataCenter || kv[AmazonInstanceID] != "158d1c8083dd49d6b527399fd6414f5c" {
+		t.Errorf("data center = %s %v; want Amazon with the task ID", reg.DataCenterInfo.Name, kv)
+	}
+}
@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Balancer picks one endpoint out of the healthy endpoints of an application.
+type Balancer interface {
+	Pick(ctx context.Context, app string, endpoints []Endpoint) (Endpoint, error)
+}
+
+// RoundRobin cycles through the endpoints of each application in turn.
+type RoundRobin struct {
+	counters sync.Map // app -> *atomic.Uint64
+}
+
+func NewRoundRobin() *RoundRobin {
+	return &RoundRobin{}
+}
+
+func (rr *RoundRobin) Pick(_ context.Context, app string, endpoints []Endpoint) (Endpoint, error) {
+	if len(endpoints) == 0 {
+		return Endpoint{}, ErrNoInstances
+	}
+	v, _ := rr.counters.LoadOrStore(app, new(atomic.Uint64))
+	n := v.(*atomic.Uint64).Add(1) - 1
+	return endpoints[n%uint64(len(endpoints))], nil
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+// CircuitBreaker configures per-instance circuit breaking in Transport.
+// Zero fields take their defaults.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failed calls that opens
+	// the circuit of an instance. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long a circuit stays open before a single probe
+	// request is let through. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+}
+
+// WithCircuitBreaker stops routing to an instance after repeated failures,
+// even before Eureka notices it is unhealthy. Once OpenTimeout has passed the
+// circuit is half-open: one probe request is routed to the instance, and its
+// outcome closes the circuit again or keeps it open for another timeout.
+func WithCircuitBreaker(cfg CircuitBreaker) TransportOption {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	return func(t *Transport) {
+		t.breakers = &breakerSet{cfg: cfg, circuits: make(map[string]*circuit)}
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+type breakerSet struct {
+	cfg   CircuitBreaker
+	clock clock.Clock
+
+	mu       sync.Mutex
+	circuits map[string]*circuit // by instance ID
+}
+
+// allowed returns the endpoints whose circuit lets a request through. An
+// endpoint whose circuit turns half-open is admitted as the probe, so at most
+// one probe is in flight per instance.
+func (b *breakerSet) allowed(endpoints []Endpoint) []Endpoint {
+	now := b.clock.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make([]Endpoint, 0, len(endpoints))
+	var probe *Endpoint
+	for _, ep := range endpoints {
+		c, ok := b.circuits[ep.InstanceID]
+		switch {
+		case !ok || c.state == circuitClosed:
+			out = append(out, ep)
+		case c.state == circuitOpen && now.Sub(c.openedAt) >= b.cfg.OpenTimeout && probe == nil:
+			probe = &ep
+			c.state = circuitHalfOpen
+		}
+	}
+	if probe != nil {
+		// Route the probe rather than a closed instance.
+		return []Endpoint{*probe}
+	}
+	return out
+}
+
+// release reopens the circuit of instanceID if it was half-open for a probe
+// whose outcome will never be known, keeping its original openedAt so that
+// the next request can probe the instance again.
+func (b *breakerSet) release(instanceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[instanceID]; ok && c.state == circuitHalfOpen {
+		c.state = circuitOpen
+	}
+}
+
+func (b *breakerSet) record(instanceID string, success bool) {
+	now := b.clock.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[instanceID]
+	if !ok {
+		if success {
+			return
+		}
+		c = &circuit{}
+		b.circuits[instanceID] = c
+	}
+	if success {
+		c.state, c.failures = circuitClosed, 0
+		return
+	}
+	c.failures++
+	if c.state == circuitHalfOpen || c.failures >= b.cfg.FailureThreshold {
+		c.state, c.openedAt = circuitOpen, now
+	}
+}
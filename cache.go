@@ -0,0 +1,455 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// ErrCacheNotPopulated is returned by cache reads before the first successful refresh.
+var ErrCacheNotPopulated = errors.New("registry cache has not been populated yet")
+
+// refreshTimeout bounds a registry fetch, which is detached from the context
+// of whoever started it.
+const refreshTimeout = 30 * time.Second
+
+// Cache holds a locally refreshed copy of the Eureka registry.
+type Cache struct {
+	fetch      func(ctx context.Context) (eurekaapi.Applications, error)
+	fetchDelta func(ctx context.Context) (eurekaapi.Applications, error)
+	fetchApp   func(ctx context.Context, name string) (eurekaapi.Application, error)
+	// appIntervals holds the applications refreshed on their own, by name.
+	appIntervals map[string]time.Duration
+	interval     time.Duration
+	maxInterval  time.Duration
+	clock        clock.Clock
+	filters      instanceFilters
+	gate         pauseGate
+	events       *eventBus
+	// deltaInterval, if set, merges the registry delta into the cache at
+	// this interval between full refreshes.
+	deltaInterval time.Duration
+	// leaseExpiry, if set, drops instances whose lease has run out.
+	leaseExpiry *LeaseExpiryPolicy
+	clockSkew   func() time.Duration
+	// onStore, if set, is called with every registry stored, before
+	// filtering.
+	onStore func(raw eurekaapi.Applications)
+	// onPanic, if set, cleans up after a panic in one of the goroutines
+	// started by Run, before it is re-raised.
+	onPanic func(r any)
+
+	// rescheduled wakes Run when SetInterval changed the interval.
+	rescheduled chan struct{}
+
+	watchMu   sync.Mutex
+	watchers  map[int]func(err error)
+	nextWatch int
+
+	mu          sync.RWMutex
+	apps        eurekaapi.Applications
+	index       map[string]int
+	populated   bool
+	lastRefresh time.Time
+	// hashCode is reconciled from the registry before filtering, so that it
+	// can be compared with the server's.
+	hashCode string
+	// raw is apps before filtering, which registry deltas are merged into.
+	raw eurekaapi.Applications
+
+	callMu   sync.Mutex
+	inflight *refreshCall
+
+	refreshes        atomic.Uint64
+	failures         atomic.Uint64
+	skippedRefreshes atomic.Uint64
+	currentInterval  atomic.Int64
+	lastDuration     atomic.Int64
+	inconsistencies  atomic.Uint64
+	deltas           atomic.Uint64
+	evicted          atomic.Uint64
+}
+
+// refreshCall is a registry fetch shared by every caller that asked for a
+// refresh while it was in flight.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// CacheStats is a point-in-time view of the cache's refresh behavior.
+type CacheStats struct {
+	Refreshes        uint64
+	Failures         uint64
+	SkippedRefreshes uint64
+	LastRefresh      time.Time
+	LastDuration     time.Duration
+	Interval         time.Duration
+	// Inconsistencies counts VerifyConsistency calls and merged registry
+	// deltas that found the cache diverging from the server.
+	Inconsistencies uint64
+	// Deltas counts registry deltas merged into the cache.
+	Deltas uint64
+	// Expired counts instances dropped between refreshes because their
+	// lease ran out, see WithLeaseExpiry.
+	Expired uint64
+}
+
+func newCache(fetch func(ctx context.Context) (eurekaapi.Applications, error), interval, maxInterval time.Duration) *Cache {
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	c := &Cache{
+		fetch:       fetch,
+		interval:    interval,
+		maxInterval: maxInterval,
+		clock:       clock.Real(),
+		rescheduled: make(chan struct{}, 1),
+	}
+	c.currentInterval.Store(int64(interval))
+	return c
+}
+
+// Run refreshes the cache until ctx is cancelled. A refresh is scheduled only
+// after the previous one has finished, and the interval is stretched while
+// fetches take longer than it, so slow registries don't accumulate overlapping
+// refreshes.
+func (c *Cache) Run(ctx context.Context) error {
+	for app, interval := range c.appIntervals {
+		go c.runAppRefresh(ctx, app, interval)
+	}
+	if c.deltaInterval > 0 {
+		go c.runDeltaPolling(ctx)
+	}
+	if c.leaseExpiry != nil {
+		go c.runLeaseExpiry(ctx)
+	}
+
+	timer := c.clock.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.rescheduled:
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+			timer.Reset(time.Duration(c.currentInterval.Load()))
+			continue
+		case <-timer.C():
+		}
+		if !c.gate.await(ctx) {
+			return ctx.Err()
+		}
+
+		call, leader := c.startRefresh()
+		if !leader {
+			// Someone else is already refreshing; don't queue up behind them.
+			c.skippedRefreshes.Add(1)
+			timer.Reset(time.Duration(c.currentInterval.Load()))
+			continue
+		}
+		c.lead(ctx, call, c.doRefresh)
+		if call.wait(ctx) != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		elapsed := time.Duration(c.lastDuration.Load())
+		next := c.nextInterval(time.Duration(c.currentInterval.Load()), elapsed)
+		c.currentInterval.Store(int64(next))
+		timer.Reset(next)
+	}
+}
+
+// recoverPanic is deferred at the top of the goroutines started by Run and
+// hands a panic to onPanic, if set, before re-raising it.
+func (c *Cache) recoverPanic() {
+	if c.onPanic == nil {
+		return
+	}
+	if r := recover(); r != nil {
+		c.onPanic(r)
+		panic(r)
+	}
+}
+
+// Refresh fetches the registry now. Concurrent callers share a single fetch,
+// which is detached from ctx and bounded by its own timeout instead, so that
+// the caller who started it giving up fails neither it nor the others. Each
+// caller waits no longer than its own ctx.
+func (c *Cache) Refresh(ctx context.Context) error {
+	call, leader := c.startRefresh()
+	if leader {
+		c.lead(ctx, call, c.doRefresh)
+	}
+	return call.wait(ctx)
+}
+
+// wait returns the result of call, or the error of ctx if it ends first.
+func (call *refreshCall) wait(ctx context.Context) error {
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lead runs refresh for call in the background, so that its leader can stop
+// waiting for it like any other caller.
+func (c *Cache) lead(ctx context.Context, call *refreshCall, refresh func(ctx context.Context, call *refreshCall)) {
+	go func() {
+		defer c.recoverPanic()
+		refresh(ctx, call)
+	}()
+}
+
+// fetchContext returns the context a shared fetch started on ctx runs on: it
+// keeps the values of ctx but not its cancellation or deadline, and times out
+// after refreshTimeout.
+func fetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+}
+
+func (c *Cache) startRefresh() (*refreshCall, bool) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+	if c.inflight != nil {
+		return c.inflight, false
+	}
+	c.inflight = &refreshCall{done: make(chan struct{})}
+	return c.inflight, true
+}
+
+func (c *Cache) doRefresh(ctx context.Context, call *refreshCall) {
+	ctx, cancel := fetchContext(ctx)
+	defer cancel()
+	start := c.clock.Now()
+	apps, err := c.fetch(ctx)
+	elapsed := c.clock.Since(start)
+
+	if err != nil {
+		c.failures.Add(1)
+		c.events.publish(Event{Type: EventRegistryRefreshFailed, Err: err})
+	} else {
+		if c.store(apps, start) {
+			c.events.publish(Event{Type: EventRegistryUpdated, Detail: apps.AppsHashCode})
+		}
+		c.refreshes.Add(1)
+	}
+	c.notify(err)
+	c.lastDuration.Store(int64(elapsed))
+	c.finishRefresh(call, err)
+}
+
+// finishRefresh hands the result of call to the callers waiting on it.
+func (c *Cache) finishRefresh(call *refreshCall, err error) {
+	c.callMu.Lock()
+	call.err = err
+	c.inflight = nil
+	c.callMu.Unlock()
+	close(call.done)
+}
+
+// nextInterval stretches the interval while fetches outlast it and shrinks it
+// back towards the configured value once they are fast again.
+func (c *Cache) nextInterval(current, elapsed time.Duration) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if elapsed > c.interval {
+		return min(max(current, 2*elapsed), c.maxInterval)
+	}
+	return max(current/2, c.interval)
+}
+
+// SetInterval changes the refresh interval. The next refresh of a running
+// cache is rescheduled to interval from now.
+func (c *Cache) SetInterval(interval time.Duration) {
+	c.mu.Lock()
+	c.interval = interval
+	c.maxInterval = max(c.maxInterval, interval)
+	c.mu.Unlock()
+	c.currentInterval.Store(int64(interval))
+	select {
+	case c.rescheduled <- struct{}{}:
+	default:
+	}
+}
+
+// watch calls fn after every refresh of the cache, with the error of the
+// refresh if it failed, until the returned function is called. fn is called
+// before callers waiting for the refresh are released, so it must not refresh
+// the cache itself.
+func (c *Cache) watch(fn func(err error)) (unwatch func()) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.watchers == nil {
+		c.watchers = make(map[int]func(error))
+	}
+	id := c.nextWatch
+	c.nextWatch++
+	c.watchers[id] = fn
+	return func() {
+		c.watchMu.Lock()
+		defer c.watchMu.Unlock()
+		delete(c.watchers, id)
+	}
+}
+
+func (c *Cache) notify(err error) {
+	c.watchMu.Lock()
+	watchers := make([]func(error), 0, len(c.watchers))
+	for _, fn := range c.watchers {
+		watchers = append(watchers, fn)
+	}
+	c.watchMu.Unlock()
+	for _, fn := range watchers {
+		fn(err)
+	}
+}
+
+// store replaces the cached registry and reports whether it changed.
+func (c *Cache) store(apps eurekaapi.Applications, fetchedAt time.Time) bool {
+	hashCode := apps.ReconcileHashCode()
+	raw := apps
+	apps = c.visible().applications(apps)
+	index := make(map[string]int, len(apps.Application))
+	for i, app := range apps.Application {
+		index[strings.ToUpper(app.Name)] = i
+	}
+
+	c.mu.Lock()
+	changed := !c.populated || !sameRegistry(c.apps, apps)
+	c.apps = apps
+	c.raw = raw
+	c.index = index
+	c.populated = true
+	c.lastRefresh = fetchedAt
+	c.hashCode = hashCode
+	c.mu.Unlock()
+
+	if c.onStore != nil {
+		c.onStore(raw)
+	}
+	return changed
+}
+
+// sameRegistry reports whether a and b hold the same instances, each in the
+// same status and at the same version.
+func sameRegistry(a, b eurekaapi.Applications) bool {
+	type version struct {
+		status string
+		dirty  int64
+	}
+	instances := func(apps eurekaapi.Applications) map[string]version {
+		m := make(map[string]version)
+		for _, app := range apps.Application {
+			for _, inst := range app.Instance {
+				m[strings.ToUpper(app.Name)+"/"+inst.InstanceID] = version{inst.Status, inst.LastDirtyTimestamp}
+			}
+		}
+		return m
+	}
+	return maps.Equal(instances(a), instances(b))
+}
+
+// Applications returns the most recently fetched registry, without the
+// instances hidden by the client's instance filters.
+func (c *Cache) Applications() (eurekaapi.Applications, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.populated {
+		return eurekaapi.Applications{}, ErrCacheNotPopulated
+	}
+	return c.apps, nil
+}
+
+// Application returns the cached application with the given name.
+func (c *Cache) Application(name string) (eurekaapi.Application, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i, ok := c.index[strings.ToUpper(name)]
+	if !ok {
+		return eurekaapi.Application{}, false
+	}
+	return c.apps.Application[i], true
+}
+
+// ApplicationNames returns the names of the cached applications, sorted.
+func (c *Cache) ApplicationNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.apps.Application))
+	for _, app := range c.apps.Application {
+		names = append(names, app.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// HashCode returns the apps__hashcode the server reported with the cached
+// registry.
+func (c *Cache) HashCode() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.apps.AppsHashCode
+}
+
+// VerifyConsistency recomputes the hashcode of the cached registry and
+// compares it with the server's current one, taken from the registry delta.
+// Divergence is counted in CacheStats.Inconsistencies and published as
+// EventRegistryInconsistent.
+func (c *Cache) VerifyConsistency(ctx context.Context) (bool, error) {
+	c.mu.RLock()
+	populated := c.populated
+	local := c.hashCode
+	c.mu.RUnlock()
+	if !populated {
+		return false, ErrCacheNotPopulated
+	}
+
+	delta, err := c.fetchDelta(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch server hashcode: %w", err)
+	}
+	if delta.AppsHashCode != local {
+		c.inconsistencies.Add(1)
+		c.events.publish(Event{
+			Type:   EventRegistryInconsistent,
+			Detail: fmt.Sprintf("cache=%s server=%s", local, delta.AppsHashCode),
+		})
+		return false, nil
+	}
+	return true, nil
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.mu.RLock()
+	lastRefresh := c.lastRefresh
+	c.mu.RUnlock()
+
+	return CacheStats{
+		Refreshes:        c.refreshes.Load(),
+		Failures:         c.failures.Load(),
+		SkippedRefreshes: c.skippedRefreshes.Load(),
+		LastRefresh:      lastRefresh,
+		LastDuration:     time.Duration(c.lastDuration.Load()),
+		Interval:         time.Duration(c.currentInterval.Load()),
+		Inconsistencies:  c.inconsistencies.Load(),
+		Deltas:           c.deltas.Load(),
+		Expired:          c.evicted.Load(),
+	}
+}
@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestCacheRefreshIsSingleFlight(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (eurekaapi.Applications, error) {
+		fetches.Add(1)
+		<-release
+		return eurekaapi.Applications{Application: []eurekaapi.Application{{Name: "FOO"}}}, nil
+	}
+	cache := newCache(fetch, time.Second, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cache.Refresh(context.Background()); err != nil {
+				t.Errorf("Refresh returned error: %v", err)
+			}
+		}()
+	}
+	// Give the goroutines a chance to pile up behind the first fetch.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetch called %d times; want 1", got)
+	}
+	if _, ok := cache.Application("foo"); !ok {
+		t.Errorf("expected application FOO to be cached")
+	}
+}
+
+func TestCacheRefreshOutlivesTheCallerThatStartedIt(t *testing.T) {
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (eurekaapi.Applications, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return eurekaapi.Applications{}, ctx.Err()
+		}
+		return eurekaapi.Applications{Application: []eurekaapi.Application{{Name: "FOO"}}}, nil
+	}
+	cache := newCache(fetch, time.Second, time.Minute)
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	leaderDone := make(chan error, 1)
+	go func() { leaderDone <- cache.Refresh(leaderCtx) }()
+	time.Sleep(5 * time.Millisecond)
+	followerDone := make(chan error, 1)
+	go func() { followerDone <- cache.Refresh(context.Background()) }()
+
+	if err := <-leaderDone; err != context.DeadlineExceeded {
+		t.Errorf("leader's Refresh returned %v; want its own deadline", err)
+	}
+	close(release)
+	if err := <-followerDone; err != nil {
+		t.Errorf("follower's Refresh returned %v; want the shared fetch to succeed", err)
+	}
+	if stats := cache.Stats(); stats.Failures != 0 || stats.Refreshes != 1 {
+		t.Errorf("stats = %+v; want one refresh and no failures", stats)
+	}
+}
+
+func TestCacheNextInterval(t *testing.T) {
+	cache := newCache(nil, 10*time.Second, time.Minute)
+
+	tests := []struct {
+		current, elapsed, expected time.Duration
+	}{
+		{10 * time.Second, time.Second, 10 * time.Second},
+		{10 * time.Second, 15 * time.Second, 30 * time.Second},
+		{30 * time.Second, 45 * time.Second, time.Minute},
+		{time.Minute, time.Second, 30 * time.Second},
+		{30 * time.Second, time.Second, 15 * time.Second},
+		{15 * time.Second, time.Second, 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		result := cache.nextInterval(test.current, test.elapsed)
+		if result != test.expected {
+			t.Errorf("nextInterval(%v, %v) = %v; want %v", test.current, test.elapsed, result, test.expected)
+		}
+	}
+}
+
+func TestCacheVerifyConsistency(t *testing.T) {
+	apps := eurekaapi.Applications{
+		AppsHashCode: "UP_2_",
+		Application: []eurekaapi.Application{
+			{Name: "FOO", Instance: []eurekaapi.Instance{{Status: StatusUp}, {Status: StatusUp}}},
+		},
+	}
+	serverHashCode := "UP_2_"
+	cache := newCache(func(ctx context.Context) (eurekaapi.Applications, error) {
+		return apps, nil
+	}, time.Second, time.Minute)
+	cache.fetchDelta = func(ctx context.Context) (eurekaapi.Applications, error) {
+		return eurekaapi.Applications{AppsHashCode: serverHashCode}, nil
+	}
+
+	if _, err := cache.VerifyConsistency(context.Background()); err != ErrCacheNotPopulated {
+		t.Errorf("VerifyConsistency before refresh returned %v; want ErrCacheNotPopulated", err)
+	}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if cache.HashCode() != "UP_2_" {
+		t.Errorf("HashCode() = %q; want UP_2_", cache.HashCode())
+	}
+
+	cache.events = newEventBus(10, cache.clock)
+	events := cache.events.subscribe()
+	if ok, err := cache.VerifyConsistency(context.Background()); err != nil || !ok {
+		t.Errorf("VerifyConsistency() = %t, %v; want true", ok, err)
+	}
+	serverHashCode = "DOWN_1_UP_1_"
+	if ok, err := cache.VerifyConsistency(context.Background()); err != nil || ok {
+		t.Errorf("VerifyConsistency() = %t, %v; want false", ok, err)
+	}
+	if got := cache.Stats().Inconsistencies; got != 1 {
+		t.Errorf("Inconsistencies = %d; want 1", got)
+	}
+	if len(events) != 1 {
+		t.Fatalf("%d events published; want one for the inconsistency", len(events))
+	}
+	if e := <-events; e.Type != EventRegistryInconsistent || e.Detail != "cache=UP_2_ server=DOWN_1_UP_1_" {
+		t.Errorf("event = %+v; want EventRegistryInconsistent with both hashcodes", e)
+	}
+}
+
+func TestCacheRunStretchesIntervalWithClock(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	refreshed := make(chan struct{})
+	fetch := func(ctx context.Context) (eurekaapi.Applications, error) {
+		// Simulate a registry fetch that takes longer than the interval.
+		clk.Advance(3 * time.Second)
+		refreshed <- struct{}{}
+		return eurekaapi.Applications{}, nil
+	}
+	cache := newCache(fetch, time.Second, time.Minute)
+	cache.clock = clk
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Run(ctx)
+
+	<-refreshed
+	clk.BlockUntil(1)
+	if got := cache.Stats().Interval; got != 6*time.Second {
+		t.Fatalf("Interval after slow refresh = %v; want 6s", got)
+	}
+	if got := cache.Stats().LastRefresh; !got.Equal(time.Unix(0, 0)) {
+		t.Errorf("LastRefresh = %v; want %v", got, time.Unix(0, 0))
+	}
+
+	clk.Advance(5 * time.Second)
+	select {
+	case <-refreshed:
+		t.Fatal("cache refreshed before the stretched interval elapsed")
+	default:
+	}
+	clk.Advance(time.Second)
+	<-refreshed
+}
@@ -0,0 +1,151 @@
+// Package chaos injects faults into the HTTP traffic between the Eureka
+// client and the server, so that failover and heartbeat error handling can be
+// exercised in integration tests:
+//
+//	client, err := eurekaClient.NewClient(urls, app, host, port,
+//		eurekaClient.WithTransportWrapper(chaos.Wrapper(chaos.Config{
+//			ResetProbability: 0.1,
+//		})))
+package chaos
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+// ErrConnectionReset is returned for requests whose connection was reset by
+// the transport. The request was sent, so the server may have processed it.
+var ErrConnectionReset = errors.New("chaos: connection reset by peer")
+
+// Config sets how often each fault is injected. Probabilities range from 0,
+// never, to 1, for every request; faults are drawn independently.
+type Config struct {
+	// LatencyProbability delays requests by a random duration up to
+	// MaxLatency before they are sent.
+	LatencyProbability float64
+	MaxLatency         time.Duration
+	// ResetProbability fails requests with ErrConnectionReset after sending
+	// them.
+	ResetProbability float64
+	// PartialResponseProbability cuts response bodies off halfway, with
+	// io.ErrUnexpectedEOF.
+	PartialResponseProbability float64
+	// Seed makes the injected faults reproducible. Zero seeds randomly.
+	Seed uint64
+	// Clock times the injected latency. Defaults to the real clock.
+	Clock clock.Clock
+}
+
+// Transport is a round tripper that injects faults into the requests it
+// forwards.
+type Transport struct {
+	next http.RoundTripper
+	cfg  Config
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewTransport returns a Transport forwarding to next, or to
+// http.DefaultTransport if next is nil.
+func NewTransport(next http.RoundTripper, cfg Config) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real()
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &Transport{
+		next: next,
+		cfg:  cfg,
+		rand: rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// Wrapper returns a function that wraps a round tripper with a Transport,
+// matching eurekaClient.WithTransportWrapper.
+func Wrapper(cfg Config) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return NewTransport(next, cfg)
+	}
+}
+
+// faults are the faults drawn for a single request.
+type faults struct {
+	latency time.Duration
+	reset   bool
+	partial bool
+}
+
+func (t *Transport) draw() faults {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var f faults
+	if t.rand.Float64() < t.cfg.LatencyProbability && t.cfg.MaxLatency > 0 {
+		f.latency = time.Duration(t.rand.Int64N(int64(t.cfg.MaxLatency)) + 1)
+	}
+	f.reset = t.rand.Float64() < t.cfg.ResetProbability
+	f.partial = t.rand.Float64() < t.cfg.PartialResponseProbability
+	return f
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := t.draw()
+	if f.latency > 0 {
+		timer := t.cfg.Clock.NewTimer(f.latency)
+		select {
+		case <-timer.C():
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if f.reset {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, ErrConnectionReset
+	}
+	if f.partial {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = &truncatedBody{data: body[:len(body)/2]}
+		resp.ContentLength = -1
+	}
+	return resp, nil
+}
+
+// truncatedBody yields data and then fails as if the connection dropped.
+type truncatedBody struct {
+	data []byte
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+func (b *truncatedBody) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package chaos
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "0123456789")
+	}))
+	defer server.Close()
+
+	get := func(cfg Config) (string, error) {
+		t.Helper()
+		client := &http.Client{Transport: NewTransport(nil, cfg)}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	if body, err := get(Config{}); err != nil || body != "0123456789" {
+		t.Errorf("without faults got %q, %v; want the whole body", body, err)
+	}
+	if _, err := get(Config{ResetProbability: 1}); !errors.Is(err, ErrConnectionReset) {
+		t.Errorf("with resets got %v; want ErrConnectionReset", err)
+	}
+	if body, err := get(Config{PartialResponseProbability: 1}); !errors.Is(err, io.ErrUnexpectedEOF) || body != "01234" {
+		t.Errorf("with partial responses got %q, %v; want half the body and io.ErrUnexpectedEOF", body, err)
+	}
+	if _, err := get(Config{LatencyProbability: 1, MaxLatency: 20 * time.Millisecond}); err != nil {
+		t.Errorf("with latency got %v", err)
+	}
+}
+
+func TestSeedIsReproducible(t *testing.T) {
+	cfg := Config{LatencyProbability: 0.5, MaxLatency: time.Second, ResetProbability: 0.5, Seed: 42}
+	a, b := NewTransport(nil, cfg), NewTransport(nil, cfg)
+	for range 20 {
+		fa, fb := a.draw(), b.draw()
+		if fa != fb {
+			t.Fatalf("transports with the same seed drew %+v and %+v", fa, fb)
+		}
+		if fa.latency < 0 || fa.latency > cfg.MaxLatency {
+			t.Fatalf("drew latency %s; want at most %s", fa.latency, cfg.MaxLatency)
+		}
+	}
+}
+
+func TestLatencyFollowsTheClock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	clk := clock.NewManual(time.Now())
+	client := &http.Client{Transport: NewTransport(nil, Config{LatencyProbability: 1, MaxLatency: time.Hour, Clock: clk})}
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	select {
+	case err := <-done:
+		t.Fatalf("request finished before the latency elapsed: %v", err)
+	default:
+	}
+	clk.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Errorf("request returned error: %v", err)
+	}
+}
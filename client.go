@@ -2,75 +2,309 @@ package pkg
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/cassis163/eureka-go-client/clock"
 	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
 )
 
 type Client struct {
 	appID      string
+	vipAddress string
 	host       string
+	ip         net.IP
 	port       int
+	securePort int
+	// portSource, if set, yields the port to register; see WithListener.
+	portSource func(ctx context.Context) (int, error)
 	instanceID string
+	// instanceIDFile, if set, persists instanceID across restarts.
+	instanceIDFile string
 
+	refreshInterval    time.Duration
+	maxRefreshInterval time.Duration
+	deltaInterval      time.Duration
+	leaseExpiry        *LeaseExpiryPolicy
+
+	heartbeatPolicy HeartbeatPolicy
+	crashSafetyNet  bool
+	crashAction     CrashAction
+	logger          *slog.Logger
+	shutdownDrain   time.Duration
+
+	homePagePath    string
+	statusPagePath  string
+	healthCheckPath string
+
+	secureHealthCheckPath string
+
+	dualStackPorts bool
+
+	metadata map[string]string
+	// containerMetadata is detected by WithContainerMetadata.
+	containerMetadata map[string]string
+	managementPort    int
+	jmxPort           int
+
+	historySize int
+	history     *history
+	eventBuffer int
+	events      *eventBus
+
+	clock    clock.Clock
+	filters  instanceFilters
+	profiles map[string]Profile
+
+	verifyTimeout     time.Duration
+	pendingMetadata   pendingMetadata
+	preserveAppIDCase bool
+
+	apiOptions      []eurekaapi.Option
 	eurekaAPIClient eurekaapi.EurekaAPI
+	cache           *Cache
+
+	remoteRegions     map[string][]string
+	regionParallelism int
+	// regionClients query the remote regions, by name.
+	regionClients map[string]eurekaapi.EurekaAPI
+
+	// serverDiscovery, if set, updates the Eureka servers from the
+	// registry, starting from the configured seedURLs.
+	serverDiscovery *ServerDiscovery
+	seedURLs        []string
+
+	heartbeats pauseGate
+	renewals   renewalTracker
+
+	dataCenterProvider DataCenterInfoProvider
+
+	startupRetry *StartupRetryPolicy
+	ready        chan struct{}
+	readyOnce    sync.Once
+	// registered is closed by the first successful heartbeat.
+	registered     chan struct{}
+	registeredOnce sync.Once
+
+	// reRegistering is the latest re-registration, in flight or not.
+	reRegisterMu  sync.Mutex
+	reRegistering *reRegisterCall
+
+	mu           sync.Mutex
+	registration *eurekaapi.Instance
+	// cachedDataCenterInfo is read from dataCenterProvider on first
+	// registration.
+	cachedDataCenterInfo *DataCenterInfo
+	state                atomic.Int32
+	// servers is the server list last set by server discovery.
+	servers []string
+
+	lastDirtyTimestamp atomic.Int64
+	// heartbeatOverride, if set by Reconfigure, overrides the interval
+	// RunHeartbeat was given; heartbeatRescheduled wakes it to apply it.
+	heartbeatOverride    atomic.Int64
+	heartbeatRescheduled chan struct{}
 }
 
 type ClientAPI interface {
-    WrapTransport(wrap func(http.RoundTripper) http.RoundTripper)
+	WrapTransport(wrap func(http.RoundTripper) http.RoundTripper)
 
 	RegisterInstance(ctx context.Context, ip net.IP, ttl uint, useSSL bool) (*Instance, error)
 	Heartbeat(ctx context.Context) error
+	Renew(ctx context.Context) (HeartbeatResult, error)
+	RunHeartbeat(ctx context.Context, interval time.Duration) error
+	Run(ctx context.Context, opts RunOptions) <-chan error
+	Ready() <-chan struct{}
+	Registered() <-chan struct{}
+	NodeStatus() []NodeStatus
+	RenewalStats() RenewalStats
 	GetAllApplications(ctx context.Context) (eurekaapi.Applications, error)
+	VerifyRegistration(ctx context.Context) error
 	UnregisterInstance(ctx context.Context) error
+	UnregisterWithReason(ctx context.Context, reason ShutdownReason) error
 	GetApplication(ctx context.Context) (eurekaapi.Application, error)
+	ListApplications(ctx context.Context, names ...string) iter.Seq2[eurekaapi.Application, error]
+	Instances(ctx context.Context, app string) iter.Seq2[eurekaapi.Instance, error]
+	GetInstances(ctx context.Context, app string, opts ...ReadOption) ([]eurekaapi.Instance, error)
 	GetInstance(ctx context.Context) (eurekaapi.Instance, error)
 	GetByVIP(ctx context.Context, vip string) (eurekaapi.Applications, error)
 	GetBySecureVIP(ctx context.Context, svip string) (eurekaapi.Applications, error)
+	GetAllApplicationsByRegion(ctx context.Context) (map[string]eurekaapi.Applications, error)
+	FindInstances(ctx context.Context, app string, metadata map[string]string) ([]eurekaapi.Instance, error)
 	SetStatus(ctx context.Context, status string) error
 	ClearStatusOverride(ctx context.Context, suggestedFallback string) error
 	UpdateMetadata(ctx context.Context, kv map[string]string) error
+	DeleteMetadata(ctx context.Context, keys ...string) error
+	SetStatusWithMetadata(ctx context.Context, status string, kv map[string]string) error
+	Reconfigure(cfg Reconfiguration) error
+	RunMetadataSync(ctx context.Context, interval time.Duration, source func() map[string]string) error
+	Do(ctx context.Context, method, path string, body []byte) (*http.Response, error)
+	LameDuck(ctx context.Context, duration time.Duration) error
+	HandleSignals(signals ...os.Signal) <-chan error
+	Pause()
+	Events() <-chan Event
+	Resume()
+	DeregisterOnPanic()
+	Exit(code int)
 
-    // Getters
-    InstanceID() string
+	// Getters
+	InstanceID() string
+	Cache() *Cache
+	History() []HistoryEntry
+	DebugHandler() http.Handler
+	Resolver(opts ...ResolverOption) *Resolver
+	HTTPClientFor(app string, opts ...HTTPClientOption) *http.Client
+	State() State
 }
 
 func (c *Client) InstanceID() string {
 	return c.instanceID
 }
 
-func NewClient(eurekaServiceURLs []string, appID string, host string, port int) (ClientAPI, error) {
-	eurekaAPIClient, err := eurekaapi.NewEurekaAPIClient(eurekaServiceURLs...)
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) Cache() *Cache {
+	return c.cache
+}
 
-	return &Client{
+func NewClient(eurekaServiceURLs []string, appID string, host string, port int, opts ...Option) (ClientAPI, error) {
+	c := &Client{
 		appID:      appID,
+		vipAddress: appID,
 		host:       host,
 		port:       port,
+		securePort: port,
 		instanceID: fmt.Sprintf("%s:%s:%d", host, appID, port),
 
-		eurekaAPIClient: eurekaAPIClient,
-	}, nil
+		refreshInterval:    defaultRefreshInterval,
+		maxRefreshInterval: defaultMaxRefreshInterval,
+		heartbeatPolicy:    DefaultHeartbeatPolicy,
+
+		homePagePath:    defaultHomePagePath,
+		statusPagePath:  defaultStatusPagePath,
+		healthCheckPath: defaultHealthCheckPath,
+
+		metadata: make(map[string]string),
+
+		regionParallelism: defaultRegionParallelism,
+
+		historySize: defaultHistorySize,
+		eventBuffer: defaultEventBuffer,
+
+		clock: clock.Real(),
+		ready: make(chan struct{}),
+
+		registered: make(chan struct{}),
+
+		heartbeatRescheduled: make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := validateAddress(c.host, c.ip); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	c.appID = c.normalizeAppID(c.appID)
+	if c.instanceIDFile != "" {
+		id, err := persistInstanceID(c.instanceIDFile, c.instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to persist instance ID: %w", err)
+		}
+		c.instanceID = id
+	}
+
+	eurekaAPIClient, err := eurekaapi.NewEurekaAPIClient(eurekaServiceURLs, c.apiOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c.eurekaAPIClient = eurekaAPIClient
+	if err := c.newRegionClients(); err != nil {
+		return nil, err
+	}
+	c.events = newEventBus(c.eventBuffer, c.clock)
+	c.history = newHistory(c.historySize, c.clock)
+	c.history.events = c.events
+	c.cache = newCache(c.fetchApplications, c.refreshInterval, c.maxRefreshInterval)
+	c.cache.clock = c.clock
+	c.cache.events = c.events
+	c.cache.filters = c.filters
+	if c.crashSafetyNet {
+		c.cache.onPanic = c.onCrash
+	}
+	c.cache.fetchApp = c.eurekaAPIClient.GetApplication
+	for app, p := range c.profiles {
+		if p.RefreshInterval > 0 {
+			if c.cache.appIntervals == nil {
+				c.cache.appIntervals = make(map[string]time.Duration)
+			}
+			c.cache.appIntervals[app] = p.RefreshInterval
+		}
+	}
+	c.cache.fetchDelta = c.fetchDelta
+	c.cache.deltaInterval = c.deltaInterval
+	c.cache.leaseExpiry = c.leaseExpiry
+	c.cache.clockSkew = c.eurekaAPIClient.ClockSkew
+	if c.serverDiscovery != nil {
+		for _, u := range eurekaServiceURLs {
+			// Already validated by NewEurekaAPIClient.
+			norm, _ := eurekaapi.NormalizeBaseURL(u)
+			c.seedURLs = append(c.seedURLs, norm)
+		}
+		c.cache.onStore = c.updateServers
+	}
+	return c, nil
 }
 
+// WrapTransport wraps the transports of a client that may already be in use.
+// Prefer WithTransportWrapper, which wraps them before the first request.
 func (c *Client) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
-    if wrap == nil {
-        return
-    }
-    c.eurekaAPIClient.WrapTransport(wrap)
+	if wrap == nil {
+		return
+	}
+	c.eurekaAPIClient.WrapTransport(wrap)
+	for _, client := range c.regionClients {
+		client.WrapTransport(wrap)
+	}
 }
 
 type Instance struct {
 	ID string
 }
 
+// RegisterInstance registers the instance with Eureka. With WithStartupRetry,
+// a failed registration is retried in the background until ctx is cancelled
+// and RegisterInstance doesn't fail; Ready reports when it succeeded.
 func (c *Client) RegisterInstance(ctx context.Context, ip net.IP, ttl uint, useSSL bool) (*Instance, error) {
-	dataCenterInfo := &eurekaapi.DataCenter{
-		Name: eurekaapi.DefaultDataCenter,
+	return c.registerInstance(ctx, ip, ttl, useSSL, true)
+}
+
+// registerInstance registers the instance, retrying according to the startup
+// retry policy either in the background or before returning.
+func (c *Client) registerInstance(ctx context.Context, ip net.IP, ttl uint, useSSL bool, retryInBackground bool) (*Instance, error) {
+	if err := c.resolvePort(ctx); err != nil {
+		return nil, err
+	}
+	dataCenter, err := c.dataCenterInfo(ctx)
+	if err != nil {
+		c.history.record(ActionRegisterFailed, "", err)
+		return nil, fmt.Errorf("failed to register instance: %w", err)
+	}
+	if ip == nil || ip.IsUnspecified() {
+		ip = c.ip
+	}
+	if err := validateAddress(c.host, ip); err != nil {
+		return nil, fmt.Errorf("failed to register instance: %w: %w", ErrInvalidConfig, err)
+	}
+	if ip == nil && dataCenter.IP != nil {
+		ip = dataCenter.IP
 	}
 	leaseInfo := &eurekaapi.LeaseInfo{
 		EvictionDurationInSecs: ttl,
@@ -79,40 +313,155 @@ func (c *Client) RegisterInstance(ctx context.Context, ip net.IP, ttl uint, useS
 		HostName:         c.host,
 		InstanceID:       c.instanceID,
 		App:              c.appID,
-		IPAddr:           ip.To4().String(),
+		IPAddr:           ipAddr(ip),
 		Status:           eurekaapi.UP,
-		DataCenterInfo:   *dataCenterInfo,
+		DataCenterInfo:   dataCenter.dataCenter(),
 		LeaseInfo:        leaseInfo,
-		SecureVipAddress: c.appID,
-		VipAddress:       c.appID,
+		SecureVipAddress: c.vipAddress,
+		VipAddress:       c.vipAddress,
 		SecurePort: &eurekaapi.Port{
-			Value:   c.port,
-			Enabled: useSSL,
+			Value:   c.securePort,
+			Enabled: useSSL || c.dualStackPorts,
 		},
 		Port: &eurekaapi.Port{
 			Value:   c.port,
-			Enabled: !useSSL,
+			Enabled: !useSSL || c.dualStackPorts,
 		},
+		HomePageURL:    c.instanceURL(useSSL, c.homePagePath),
+		StatusPageURL:  c.instanceURL(useSSL, c.statusPagePath),
+		HealthCheckURL: c.instanceURL(useSSL, c.healthCheckPath),
 	}
-
-	err := c.eurekaAPIClient.RegisterInstance(ctx, c.appID, instance)
-	if err != nil {
+	// The secure health check URL is only advertised with an enabled secure
+	// port to serve it.
+	if instance.SecurePort.Enabled {
+		secureHealthCheckPath := c.secureHealthCheckPath
+		if secureHealthCheckPath == "" && useSSL {
+			secureHealthCheckPath = c.healthCheckPath
+		}
+		instance.SecureHealthCheckURL = c.instanceURL(true, secureHealthCheckPath)
+	}
+	kv := c.registrationMetadata()
+	if _, ok := kv[MetadataZone]; !ok && dataCenter.Zone != "" {
+		kv[MetadataZone] = dataCenter.Zone
+	}
+	instance.Metadata = eurekaapi.NewMetadata(kv)
+	if err := instance.Validate(c.appID); err != nil {
+		c.history.record(ActionRegisterFailed, "", err)
 		return nil, fmt.Errorf("failed to register instance: %w", err)
 	}
+
+	err = c.register(ctx, instance)
+	switch {
+	case err != nil && c.startupRetry == nil:
+		return nil, err
+	case err != nil && retryInBackground:
+		go func() {
+			defer c.safetyNet()
+			_ = c.retryRegistration(ctx, instance, err)
+		}()
+		return &Instance{ID: c.instanceID}, nil
+	case err != nil:
+		if err := c.retryRegistration(ctx, instance, err); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.verifyTimeout > 0 {
+		verifyCtx, cancel := context.WithTimeout(ctx, c.verifyTimeout)
+		defer cancel()
+		if err := c.VerifyRegistration(verifyCtx); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Instance{
 		ID: c.instanceID,
 	}, nil
 }
 
+// register sends instance to Eureka and, once accepted, makes it the
+// registration that heartbeats renew.
+func (c *Client) register(ctx context.Context, instance *eurekaapi.Instance) error {
+	c.stamp(instance)
+	if err := c.eurekaAPIClient.RegisterInstance(ctx, c.appID, instance); err != nil {
+		c.history.record(ActionRegisterFailed, "", err)
+		return fmt.Errorf("failed to register instance: %w", err)
+	}
+	c.history.record(ActionRegistered, c.instanceID, nil)
+
+	c.mu.Lock()
+	c.registration = instance
+	c.mu.Unlock()
+	c.setState(StateRegistered)
+	c.readyOnce.Do(func() { close(c.ready) })
+	return nil
+}
+
+// ipAddr formats ip for the registration. A missing IP is left empty, for
+// validation to report.
+func ipAddr(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String()
+	}
+	return ""
+}
+
+// instanceURL builds an absolute URL for path on this instance. An empty path
+// yields an empty URL so the field is omitted from the registration.
+func (c *Client) instanceURL(useSSL bool, path string) string {
+	if path == "" {
+		return ""
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	scheme, port := "http", c.port
+	if useSSL {
+		scheme, port = "https", c.securePort
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(c.host, strconv.Itoa(port)), path)
+}
+
 func (c *Client) Heartbeat(ctx context.Context) error {
-	exists, err := c.eurekaAPIClient.Heartbeat(ctx, c.appID, c.instanceID)
+	_, err := c.Renew(ctx)
+	return err
+}
+
+// Renew sends a heartbeat like Heartbeat and returns the details of the
+// server's answer, such as its time and the renewed lease, for precise lease
+// tracking.
+func (c *Client) Renew(ctx context.Context) (HeartbeatResult, error) {
+	result, err := c.heartbeat(ctx)
+	c.renewals.record(c.clock.Now(), err)
 	if err != nil {
-		return fmt.Errorf("failed to send heartbeat: %w", err)
+		c.history.record(ActionHeartbeatFailed, "", err)
+		return result, err
 	}
-	if !exists {
-		return fmt.Errorf("instance %s does not exist", c.instanceID)
+	c.history.record(ActionHeartbeat, "", nil)
+	c.registeredOnce.Do(func() { close(c.registered) })
+	return result, nil
+}
+
+func (c *Client) heartbeat(ctx context.Context) (HeartbeatResult, error) {
+	result, err := c.eurekaAPIClient.Heartbeat(ctx, c.appID, c.instanceID, c.lastDirtyTimestamp.Load())
+	if errors.Is(err, eurekaapi.ErrDirtyTimestampConflict) {
+		// Our timestamp was most likely taken before the skew estimate settled.
+		// Re-stamp so the next heartbeat carries a value in server time; this
+		// one didn't renew the lease.
+		c.mu.Lock()
+		if c.registration != nil {
+			c.stamp(c.registration)
+		}
+		c.mu.Unlock()
+		return result, fmt.Errorf("failed to send heartbeat: %w", err)
 	}
-	return nil
+	if err != nil {
+		return result, fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	if !result.Found {
+		return result, fmt.Errorf("%w: %s", ErrInstanceNotFound, c.instanceID)
+	}
+	return result, nil
 }
 
 func (c *Client) GetAllApplications(ctx context.Context) (eurekaapi.Applications, error) {
@@ -120,14 +469,30 @@ func (c *Client) GetAllApplications(ctx context.Context) (eurekaapi.Applications
 	if err != nil {
 		return eurekaapi.Applications{}, fmt.Errorf("failed to get all applications: %w", err)
 	}
-	return applications, nil
+	return c.filters.applications(c.ownApplications(applications)), nil
 }
 
 func (c *Client) UnregisterInstance(ctx context.Context) error {
+	// Keep heartbeat loops that are still running from re-registering the
+	// instance behind our back, and let a re-registration already under way
+	// land before the instance is removed.
+	c.mu.Lock()
+	registration := c.registration
+	c.registration = nil
+	c.mu.Unlock()
+	c.awaitReRegistration(ctx)
+
 	err := c.eurekaAPIClient.UnregisterInstance(ctx, c.appID, c.instanceID)
+	c.history.record(ActionUnregistered, "", err)
 	if err != nil {
+		c.mu.Lock()
+		if c.registration == nil {
+			c.registration = registration
+		}
+		c.mu.Unlock()
 		return fmt.Errorf("failed to unregister instance: %w", err)
 	}
+	c.setState(StateUnregistered)
 	return nil
 }
 
@@ -136,7 +501,8 @@ func (c *Client) GetApplication(ctx context.Context) (eurekaapi.Application, err
 	if err != nil {
 		return eurekaapi.Application{}, fmt.Errorf("failed to get application %s: %w", c.appID, err)
 	}
-	return application, nil
+	application, _ = c.ownApplication(application)
+	return c.filters.application(application), nil
 }
 
 func (c *Client) GetInstance(ctx context.Context) (eurekaapi.Instance, error) {
@@ -144,6 +510,7 @@ func (c *Client) GetInstance(ctx context.Context) (eurekaapi.Instance, error) {
 	if err != nil {
 		return eurekaapi.Instance{}, fmt.Errorf("failed to get instance %s of application %s: %w", c.instanceID, c.appID, err)
 	}
+	instance, _ = c.ownInstance(instance)
 	return instance, nil
 }
 
@@ -152,7 +519,7 @@ func (c *Client) GetByVIP(ctx context.Context, vip string) (eurekaapi.Applicatio
 	if err != nil {
 		return eurekaapi.Applications{}, fmt.Errorf("failed to get applications by VIP %s: %w", vip, err)
 	}
-	return applications, nil
+	return c.filters.applications(c.ownApplications(applications)), nil
 }
 
 func (c *Client) GetBySecureVIP(ctx context.Context, svip string) (eurekaapi.Applications, error) {
@@ -160,11 +527,24 @@ func (c *Client) GetBySecureVIP(ctx context.Context, svip string) (eurekaapi.App
 	if err != nil {
 		return eurekaapi.Applications{}, fmt.Errorf("failed to get applications by secure VIP %s: %w", svip, err)
 	}
-	return applications, nil
+	return c.filters.applications(c.ownApplications(applications)), nil
+}
+
+// FindInstances queries the server for the instances of app whose metadata
+// has all the given entries. Servers that can filter by metadata do so;
+// for the others the instances are filtered on the client.
+func (c *Client) FindInstances(ctx context.Context, app string, metadata map[string]string) ([]eurekaapi.Instance, error) {
+	application, err := c.eurekaAPIClient.GetApplicationByMetadata(ctx, app, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instances of application %s: %w", app, err)
+	}
+	application, _ = c.ownApplication(application)
+	return c.filters.application(application).Instance, nil
 }
 
 func (c *Client) SetStatus(ctx context.Context, status string) error {
 	err := c.eurekaAPIClient.SetStatus(ctx, c.appID, c.instanceID, status)
+	c.history.record(ActionStatusChanged, status, err)
 	if err != nil {
 		return fmt.Errorf("failed to set status %s for instance %s: %w", status, c.instanceID, err)
 	}
@@ -173,6 +553,7 @@ func (c *Client) SetStatus(ctx context.Context, status string) error {
 
 func (c *Client) ClearStatusOverride(ctx context.Context, suggestedFallback string) error {
 	err := c.eurekaAPIClient.ClearStatusOverride(ctx, c.appID, c.instanceID, suggestedFallback)
+	c.history.record(ActionStatusOverrideCleared, suggestedFallback, err)
 	if err != nil {
 		return fmt.Errorf("failed to clear status override for instance %s: %w", c.instanceID, err)
 	}
@@ -181,8 +562,39 @@ func (c *Client) ClearStatusOverride(ctx context.Context, suggestedFallback stri
 
 func (c *Client) UpdateMetadata(ctx context.Context, kv map[string]string) error {
 	err := c.eurekaAPIClient.UpdateMetadata(ctx, c.appID, c.instanceID, kv)
+	c.history.record(ActionMetadataUpdated, "", err)
 	if err != nil {
 		return fmt.Errorf("failed to update metadata for instance %s: %w", c.instanceID, err)
 	}
+	c.pendingMetadata.record(kv, c.clock.Now())
+	return nil
+}
+
+// DeleteMetadata clears the given metadata keys of the instance. Eureka can't
+// remove keys, so they are kept with an empty value.
+func (c *Client) DeleteMetadata(ctx context.Context, keys ...string) error {
+	err := c.eurekaAPIClient.DeleteMetadata(ctx, c.appID, c.instanceID, keys...)
+	c.history.record(ActionMetadataUpdated, strings.Join(keys, ","), err)
+	if err != nil {
+		return fmt.Errorf("failed to delete metadata for instance %s: %w", c.instanceID, err)
+	}
+	cleared := make(map[string]string, len(keys))
+	for _, k := range keys {
+		cleared[k] = ""
+	}
+	c.pendingMetadata.record(cleared, c.clock.Now())
 	return nil
 }
+
+// Do sends a request to an arbitrary path below the Eureka base URL, with the
+// same failover as the typed operations. It is meant for server extensions
+// the typed API doesn't cover. The response format is negotiated per node like
+// for the typed reads, see WithJSON, and a body is sent as JSON if it looks
+// like JSON, as XML otherwise. The caller must close the response body.
+func (c *Client) Do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	resp, err := c.eurekaAPIClient.Do(ctx, method, path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send raw request: %w", err)
+	}
+	return resp, nil
+}
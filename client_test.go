@@ -0,0 +1,233 @@
+package pkg
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// fakeEureka is a minimal in-memory stand-in for a Eureka server. Handlers can
+// be overridden per method to script failures.
+type fakeEureka struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+	handlers map[string]http.HandlerFunc
+}
+
+func newFakeEureka(t *testing.T) *fakeEureka {
+	t.Helper()
+	f := &fakeEureka{
+		requests: make(map[string]int),
+		handlers: make(map[string]http.HandlerFunc),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests[r.Method]++
+		h := f.handlers[r.Method]
+		f.mu.Unlock()
+
+		if h != nil {
+			h(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeEureka) handle(method string, h http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method] = h
+}
+
+func (f *fakeEureka) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[method]
+}
+
+func newTestClient(t *testing.T, f *fakeEureka, opts ...Option) *Client {
+	t.Helper()
+	api, err := NewClient([]string{f.URL}, "test-app", "127.0.0.1", 8080, opts...)
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return api.(*Client)
+}
+
+var testIP = net.ParseIP("127.0.0.1")
+
+func TestInstanceURL(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+
+	tests := []struct {
+		useSSL   bool
+		path     string
+		expected string
+	}{
+		{false, "/health", "http://127.0.0.1:8080/health"},
+		{true, "/info", "https://127.0.0.1:8080/info"},
+		{false, "actuator/health", "http://127.0.0.1:8080/actuator/health"},
+		{false, "", ""},
+	}
+
+	for _, test := range tests {
+		result := client.instanceURL(test.useSSL, test.path)
+		if result != test.expected {
+			t.Errorf("instanceURL(%t, %q) = %q; want %q", test.useSSL, test.path, result, test.expected)
+		}
+	}
+
+	client = newTestClient(t, f, WithSecurePort(8443))
+	tests = []struct {
+		useSSL   bool
+		path     string
+		expected string
+	}{
+		{false, "/health", "http://127.0.0.1:8080/health"},
+		{true, "/health", "https://127.0.0.1:8443/health"},
+	}
+	for _, test := range tests {
+		result := client.instanceURL(test.useSSL, test.path)
+		if result != test.expected {
+			t.Errorf("instanceURL(%t, %q) = %q; want %q", test.useSSL, test.path, result, test.expected)
+		}
+	}
+}
+
+func TestRegisterInstancePayload(t *testing.T) {
+	f := newFakeEureka(t)
+	var registered eurekaapi.Instance
+	f.handle(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		if err := xml.NewDecoder(r.Body).Decode(&registered); err != nil {
+			t.Errorf("failed to decode registration: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client := newTestClient(t, f, WithJMXPort(9010), WithMetadata(map[string]string{"zone": "a"}))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	if registered.HealthCheckURL != "http://127.0.0.1:8080/health" {
+		t.Errorf("healthCheckUrl = %q; want %q", registered.HealthCheckURL, "http://127.0.0.1:8080/health")
+	}
+	expected := map[string]string{
+		MetadataManagementPort: "8080",
+		MetadataJMXPort:        "9010",
+		MetadataInstanceID:     client.InstanceID(),
+		"zone":                 "a",
+	}
+	got := registered.Metadata.Map()
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("metadata[%q] = %q; want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRegisterInstanceSecureHealthCheckURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		useSSL bool
+		opts   []Option
+		want   string
+	}{
+		{"ssl", true, []Option{WithSecureHealthCheckPath("/secure-health")}, "https://127.0.0.1:8443/secure-health"},
+		{"ssl reusing the health check path", true, nil, "https://127.0.0.1:8443/health"},
+		{"plain with the secure port disabled", false, []Option{WithSecureHealthCheckPath("/secure-health")}, ""},
+		{"plain with dual-stack ports", false, []Option{WithSecureHealthCheckPath("/secure-health"), WithDualStackPorts()}, "https://127.0.0.1:8443/secure-health"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFakeEureka(t)
+			registered := captureRegistration(t, f)
+			client := newTestClient(t, f, append([]Option{WithSecurePort(8443)}, test.opts...)...)
+			if _, err := client.RegisterInstance(context.Background(), testIP, 30, test.useSSL); err != nil {
+				t.Fatalf("RegisterInstance returned error: %v", err)
+			}
+			if registered.SecureHealthCheckURL != test.want {
+				t.Errorf("secureHealthCheckUrl = %q; want %q", registered.SecureHealthCheckURL, test.want)
+			}
+		})
+	}
+}
+
+func TestHostNameAndIPAddress(t *testing.T) {
+	f := newFakeEureka(t)
+	var registered eurekaapi.Instance
+	f.handle(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		if err := xml.NewDecoder(r.Body).Decode(&registered); err != nil {
+			t.Errorf("failed to decode registration: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	api, err := NewClient([]string{f.URL}, "test-app", "orders-1.internal", 8080, WithIPAddress(net.ParseIP("10.0.0.5")))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if _, err := api.RegisterInstance(context.Background(), nil, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if registered.HostName != "orders-1.internal" || registered.IPAddr != "10.0.0.5" {
+		t.Errorf("registered hostName %q and ipAddr %q; want orders-1.internal and 10.0.0.5", registered.HostName, registered.IPAddr)
+	}
+
+	if _, err := NewClient([]string{f.URL}, "test-app", "10.0.0.1", 8080, WithIPAddress(net.ParseIP("10.0.0.5"))); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewClient with mismatching host and IP returned %v; want ErrInvalidConfig", err)
+	}
+	if _, err := api.RegisterInstance(context.Background(), net.ParseIP("fd00::1"), 30, false); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("RegisterInstance with an IPv6 address returned %v; want ErrInvalidConfig", err)
+	}
+}
+
+func TestAppIDNormalization(t *testing.T) {
+	f := newFakeEureka(t)
+	var paths []string
+	f.handle(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		var registered eurekaapi.Instance
+		if err := xml.NewDecoder(r.Body).Decode(&registered); err != nil {
+			t.Errorf("failed to decode registration: %v", err)
+		}
+		paths = append(paths, r.URL.Path+" app="+registered.App+" vip="+registered.VipAddress)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "/eureka/v2/apps/TEST-APP app=TEST-APP vip=test-app"},
+		{[]Option{WithPreserveAppIDCase()}, "/eureka/v2/apps/test-app app=test-app vip=test-app"},
+	}
+
+	for _, test := range tests {
+		paths = nil
+		client := newTestClient(t, f, test.opts...)
+		if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+			t.Fatalf("RegisterInstance returned error: %v", err)
+		}
+		if len(paths) != 1 || paths[0] != test.expected {
+			t.Errorf("registration = %v; want %q", paths, test.expected)
+		}
+	}
+}
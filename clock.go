@@ -0,0 +1,22 @@
+package pkg
+
+import (
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// serverNow estimates the current time on the Eureka server's clock. The
+// server compares lastDirtyTimestamp values against its own clock, so
+// timestamps we send must be corrected for the observed skew.
+func (c *Client) serverNow() time.Time {
+	return c.clock.Now().Add(c.eurekaAPIClient.ClockSkew())
+}
+
+// stamp marks instance as dirty as of now in server time.
+func (c *Client) stamp(instance *eurekaapi.Instance) {
+	ts := c.serverNow().UnixMilli()
+	instance.LastDirtyTimestamp = ts
+	instance.LastUpdatedTimestamp = ts
+	c.lastDirtyTimestamp.Store(ts)
+}
@@ -0,0 +1,151 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Manual is a Clock that only moves when Advance is called. Timers and
+// tickers fire synchronously from Advance once their deadline is reached.
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	changed chan struct{}
+}
+
+type waiter struct {
+	clock    *Manual
+	deadline time.Time
+	period   time.Duration // non-zero for tickers
+	c        chan time.Time
+	active   bool
+}
+
+// NewManual returns a Manual clock set to start.
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start, changed: make(chan struct{})}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Manual) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+func (m *Manual) NewTimer(d time.Duration) Timer {
+	return m.addWaiter(d, 0)
+}
+
+func (m *Manual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return manualTicker{m.addWaiter(d, d)}
+}
+
+func (m *Manual) addWaiter(d, period time.Duration) *waiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := &waiter{clock: m, deadline: m.now.Add(d), period: period, c: make(chan time.Time, 1), active: true}
+	m.waiters = append(m.waiters, w)
+	m.fireLocked()
+	return w
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker whose
+// deadline has passed. Timers created with a non-positive duration fire
+// immediately, as with the time package.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	m.fireLocked()
+}
+
+// fireLocked delivers every timer and ticker whose deadline has passed.
+func (m *Manual) fireLocked() {
+	for _, w := range m.waiters {
+		for w.active && !w.deadline.After(m.now) {
+			select {
+			case w.c <- w.deadline:
+			default:
+			}
+			if w.period == 0 {
+				w.active = false
+				break
+			}
+			w.deadline = w.deadline.Add(w.period)
+		}
+	}
+	m.pruneLocked()
+}
+
+// BlockUntil blocks until at least n timers or tickers are active, which lets
+// a test wait for the code under test to start waiting on the clock.
+func (m *Manual) BlockUntil(n int) {
+	for {
+		m.mu.Lock()
+		active := len(m.waiters)
+		changed := m.changed
+		m.mu.Unlock()
+		if active >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (m *Manual) pruneLocked() {
+	kept := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.active {
+			kept = append(kept, w)
+		}
+	}
+	clear(m.waiters[len(kept):])
+	m.waiters = kept
+	m.notifyLocked()
+}
+
+func (m *Manual) notifyLocked() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (w *waiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *waiter) Stop() bool {
+	m := w.clock
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasActive := w.active
+	w.active = false
+	m.pruneLocked()
+	return wasActive
+}
+
+func (w *waiter) Reset(d time.Duration) bool {
+	m := w.clock
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasActive := w.active
+	w.deadline = m.now.Add(d)
+	if !w.active {
+		w.active = true
+		m.waiters = append(m.waiters, w)
+	}
+	m.fireLocked()
+	return wasActive
+}
+
+type manualTicker struct{ w *waiter }
+
+func (t manualTicker) C() <-chan time.Time { return t.w.c }
+func (t manualTicker) Stop()               { t.w.Stop() }
@@ -0,0 +1,41 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualTimerAndTicker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewManual(start)
+	timer := m.NewTimer(time.Second)
+	ticker := m.NewTicker(time.Second)
+
+	m.Advance(500 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	m.Advance(500 * time.Millisecond)
+	if got := <-timer.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("timer fired at %v; want %v", got, start.Add(time.Second))
+	}
+	<-ticker.C()
+
+	m.Advance(time.Second)
+	<-ticker.C()
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired again")
+	default:
+	}
+
+	timer.Reset(time.Second)
+	m.Advance(time.Second)
+	<-timer.C()
+	if m.Since(start) != 3*time.Second {
+		t.Errorf("Since(start) = %v; want 3s", m.Since(start))
+	}
+}
@@ -0,0 +1,88 @@
+// Command eureka-sidecar registers a local process with Eureka and mirrors
+// its health endpoint into the instance status.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/health"
+	"github.com/cassis163/eureka-go-client/sidecar"
+)
+
+func main() {
+	var (
+		eurekaURLs         = flag.String("eureka-urls", "http://localhost:8761/eureka/", "comma-separated Eureka server URLs")
+		appID              = flag.String("app", "", "application ID to register")
+		host               = flag.String("host", "", "host name of the process (defaults to -ip)")
+		ip                 = flag.String("ip", "", "IP address of the process")
+		port               = flag.Int("port", 8080, "port the process listens on")
+		useSSL             = flag.Bool("ssl", false, "register the port as secure")
+		healthURL          = flag.String("health-url", "", "health endpoint of the process")
+		healthTCP          = flag.String("health-tcp", "", "address to check with a TCP connect instead of -health-url")
+		healthCmd          = flag.String("health-cmd", "", "command to run as health check instead of -health-url")
+		healthyThreshold   = flag.Int("healthy-threshold", 1, "consecutive successful checks before reporting UP")
+		unhealthyThreshold = flag.Int("unhealthy-threshold", 1, "consecutive failed checks before reporting DOWN")
+		ttl                = flag.Uint("ttl", 30, "lease eviction duration in seconds")
+		heartbeatInterval  = flag.Duration("heartbeat-interval", 10*time.Second, "interval between heartbeats")
+		healthInterval     = flag.Duration("health-interval", 10*time.Second, "interval between health checks")
+	)
+	flag.Parse()
+
+	var checker health.Checker
+	switch {
+	case *healthCmd != "":
+		fields := strings.Fields(*healthCmd)
+		checker = &health.ExecChecker{Command: fields[0], Args: fields[1:]}
+	case *healthTCP != "":
+		checker = &health.TCPChecker{Address: *healthTCP}
+	}
+
+	if *appID == "" || *ip == "" || (*healthURL == "" && checker == nil) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *host == "" {
+		*host = *ip
+	}
+	parsedIP := net.ParseIP(*ip)
+	if parsedIP == nil {
+		log.Fatalf("Invalid IP address: %s", *ip)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s, err := sidecar.New(sidecar.Config{
+		EurekaURLs:         strings.Split(*eurekaURLs, ","),
+		AppID:              *appID,
+		Host:               *host,
+		IP:                 parsedIP,
+		Port:               *port,
+		UseSSL:             *useSSL,
+		HealthURL:          *healthURL,
+		Checker:            checker,
+		HealthyThreshold:   *healthyThreshold,
+		UnhealthyThreshold: *unhealthyThreshold,
+		TTL:                *ttl,
+		HeartbeatInterval:  *heartbeatInterval,
+		HealthInterval:     *healthInterval,
+		Logger:             slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create sidecar: %v", err)
+	}
+
+	if err := s.Run(ctx); err != nil {
+		log.Fatalf("Sidecar stopped with error: %v", err)
+	}
+	log.Println("Sidecar shut down.")
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"cmp"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultRingReplicas is the number of points each instance gets on the ring,
+// as in ketama.
+const defaultRingReplicas = 160
+
+// ConsistentHash maps affinity keys (see WithAffinityKey) onto a ketama-style
+// hash ring built from instance IDs. When an instance joins or leaves only
+// the keys adjacent to its points are remapped, which suits cache-sharded
+// backends. Requests without an affinity key are handed to the fallback
+// balancer.
+type ConsistentHash struct {
+	replicas int
+	fallback Balancer
+	rings    sync.Map // app -> *hashRing
+}
+
+type hashRing struct {
+	signature string
+	points    []ringPoint
+}
+
+type ringPoint struct {
+	hash  uint32
+	index int
+}
+
+// NewConsistentHash returns a consistent-hash balancer with replicas points
+// per instance. Non-positive replicas default to 160 and a nil fallback to
+// round-robin.
+func NewConsistentHash(replicas int, fallback Balancer) *ConsistentHash {
+	if replicas <= 0 {
+		replicas = defaultRingReplicas
+	}
+	if fallback == nil {
+		fallback = NewRoundRobin()
+	}
+	return &ConsistentHash{replicas: replicas, fallback: fallback}
+}
+
+func (ch *ConsistentHash) Pick(ctx context.Context, app string, endpoints []Endpoint) (Endpoint, error) {
+	if len(endpoints) == 0 {
+		return Endpoint{}, ErrNoInstances
+	}
+	key, ok := AffinityKeyFrom(ctx)
+	if !ok {
+		return ch.fallback.Pick(ctx, app, endpoints)
+	}
+
+	ring := ch.ring(app, endpoints)
+	if len(ring.points) == 0 {
+		return Endpoint{}, ErrNoInstances
+	}
+	h := ketamaHash(md5.Sum([]byte(key)), 0)
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i].hash >= h })
+	if i == len(ring.points) {
+		i = 0
+	}
+	return endpoints[ring.points[i].index], nil
+}
+
+// ring returns the ring for the given endpoints, rebuilding it when the set
+// of instances changed since the last pick.
+func (ch *ConsistentHash) ring(app string, endpoints []Endpoint) *hashRing {
+	ids := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		ids[i] = ep.InstanceID
+	}
+	signature := strings.Join(ids, "\x00")
+	if v, ok := ch.rings.Load(app); ok && v.(*hashRing).signature == signature {
+		return v.(*hashRing)
+	}
+
+	ring := &hashRing{signature: signature, points: make([]ringPoint, 0, len(ids)*ch.replicas)}
+	for index, id := range ids {
+		// Every digest yields four points; round up so that fewer than four
+		// replicas still put the instance on the ring.
+		for r := 0; r < (ch.replicas+3)/4; r++ {
+			digest := md5.Sum([]byte(id + "-" + strconv.Itoa(r)))
+			for part := 0; part < 4; part++ {
+				ring.points = append(ring.points, ringPoint{hash: ketamaHash(digest, part), index: index})
+			}
+		}
+	}
+	slices.SortFunc(ring.points, func(a, b ringPoint) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.index, b.index))
+	})
+	ch.rings.Store(app, ring)
+	return ring
+}
+
+func ketamaHash(digest [md5.Size]byte, part int) uint32 {
+	return binary.LittleEndian.Uint32(digest[part*4:])
+}
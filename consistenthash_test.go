@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestConsistentHashRemapsFewKeys(t *testing.T) {
+	var endpoints []Endpoint
+	for i := 0; i < 10; i++ {
+		endpoints = append(endpoints, Endpoint{InstanceID: fmt.Sprintf("cache-%d", i)})
+	}
+	balancer := NewConsistentHash(0, nil)
+
+	const keys = 1000
+	before := make([]string, keys)
+	for i := range before {
+		ep, err := balancer.Pick(WithAffinityKey(context.Background(), fmt.Sprint(i)), "CACHE", endpoints)
+		if err != nil {
+			t.Fatalf("Pick returned error: %v", err)
+		}
+		before[i] = ep.InstanceID
+	}
+
+	// Add an instance; only the keys it takes over may move.
+	endpoints = append(endpoints, Endpoint{InstanceID: "cache-10"})
+	moved := 0
+	for i := range before {
+		ep, _ := balancer.Pick(WithAffinityKey(context.Background(), fmt.Sprint(i)), "CACHE", endpoints)
+		if ep.InstanceID == before[i] {
+			continue
+		}
+		if ep.InstanceID != "cache-10" {
+			t.Errorf("key %d moved from %s to %s; want it to stay or move to the new instance", i, before[i], ep.InstanceID)
+		}
+		moved++
+	}
+	// Ideally keys/11 keys move; allow for the imbalance of a finite ring.
+	if moved == 0 || moved > keys/5 {
+		t.Errorf("%d of %d keys moved; want roughly %d", moved, keys, keys/11)
+	}
+}
+
+func TestConsistentHashWithFewReplicas(t *testing.T) {
+	endpoints := []Endpoint{{InstanceID: "cache-0"}, {InstanceID: "cache-1"}}
+	for replicas := 1; replicas <= 5; replicas++ {
+		balancer := NewConsistentHash(replicas, nil)
+		ep, err := balancer.Pick(WithAffinityKey(context.Background(), "k"), "CACHE", endpoints)
+		if err != nil || ep.InstanceID == "" {
+			t.Errorf("Pick with %d replicas = %+v, %v; want an instance", replicas, ep, err)
+		}
+		if got := len(balancer.ring("CACHE", endpoints).points); got < replicas*len(endpoints) {
+			t.Errorf("ring with %d replicas has %d points; want at least %d", replicas, got, replicas*len(endpoints))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"regexp"
+)
+
+// Metadata keys published by WithContainerMetadata.
+const (
+	MetadataContainerID           = "container.id"
+	MetadataContainerHostname     = "container.hostname"
+	MetadataContainerOrchestrator = "container.orchestrator"
+	MetadataKubernetesNamespace   = "k8s.namespace"
+	MetadataKubernetesPod         = "k8s.pod"
+	MetadataKubernetesNode        = "k8s.node"
+)
+
+// containerIDPattern matches the 64 hex digit IDs Docker, containerd and
+// CRI-O name containers by.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerEnv is what container detection reads, replaceable in tests.
+type containerEnv struct {
+	readFile func(name string) ([]byte, error)
+	getenv   func(key string) string
+	hostname func() (string, error)
+}
+
+var hostContainerEnv = containerEnv{
+	readFile: os.ReadFile,
+	getenv:   os.Getenv,
+	hostname: os.Hostname,
+}
+
+// detect returns the container metadata of the current process, or nil when
+// it doesn't appear to run in a container.
+func (env containerEnv) detect() map[string]string {
+	kv := make(map[string]string)
+	if id := env.containerID(); id != "" {
+		kv[MetadataContainerID] = id
+	}
+
+	switch {
+	case env.getenv("KUBERNETES_SERVICE_HOST") != "":
+		kv[MetadataContainerOrchestrator] = "kubernetes"
+		// Set through the downward API; the pod name is the hostname otherwise.
+		pod := env.getenv("POD_NAME")
+		if pod == "" {
+			pod = env.getenv("HOSTNAME")
+		}
+		setIfNotEmpty(kv, MetadataKubernetesPod, pod)
+		setIfNotEmpty(kv, MetadataKubernetesNamespace, env.getenv("POD_NAMESPACE"))
+		setIfNotEmpty(kv, MetadataKubernetesNode, env.getenv("NODE_NAME"))
+	case env.getenv("ECS_CONTAINER_METADATA_URI_V4") != "" || env.getenv("ECS_CONTAINER_METADATA_URI") != "":
+		kv[MetadataContainerOrchestrator] = "ecs"
+	case env.getenv("NOMAD_ALLOC_ID") != "":
+		kv[MetadataContainerOrchestrator] = "nomad"
+	case kv[MetadataContainerID] != "":
+		kv[MetadataContainerOrchestrator] = "docker"
+	}
+
+	if len(kv) == 0 {
+		return nil
+	}
+	if host, err := env.hostname(); err == nil {
+		kv[MetadataContainerHostname] = host
+	}
+	return kv
+}
+
+// containerID reads the ID from the cgroup of the process, or from its mounts
+// under cgroup v2, where the cgroup path is hidden by the namespace.
+func (env containerEnv) containerID() string {
+	if data, err := env.readFile("/proc/self/cgroup"); err == nil {
+		if id := lastMatch(data, nil); id != "" {
+			return id
+		}
+	}
+	if data, err := env.readFile("/proc/self/mountinfo"); err == nil {
+		return lastMatch(data, []byte("/containers/"))
+	}
+	return ""
+}
+
+// lastMatch returns the last container ID found on the lines containing
+// marker, or on any line if marker is nil.
+func lastMatch(data, marker []byte) string {
+	var id string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if marker != nil && !bytes.Contains(line, marker) {
+			continue
+		}
+		if matches := containerIDPattern.FindAll(line, -1); len(matches) > 0 {
+			id = string(matches[len(matches)-1])
+		}
+	}
+	return id
+}
+
+func setIfNotEmpty(kv map[string]string, key, value string) {
+	if value != "" {
+		kv[key] = value
+	}
+}
@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"maps"
+	"os"
+	"testing"
+)
+
+const testContainerID = "3f4c1b2a9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b"
+
+func fakeContainerEnv(files, env map[string]string) containerEnv {
+	return containerEnv{
+		readFile: func(name string) ([]byte, error) {
+			if data, ok := files[name]; ok {
+				return []byte(data), nil
+			}
+			return nil, os.ErrNotExist
+		},
+		getenv:   func(key string) string { return env[key] },
+		hostname: func() (string, error) { return "web-7d9f", nil },
+	}
+}
+
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		want  map[string]string
+	}{
+		{
+			name: "bare host",
+			files: map[string]string{
+				"/proc/self/cgroup": "0::/user.slice/user-1000.slice/session-2.scope\n",
+			},
+			want: nil,
+		},
+		{
+			name: "docker cgroup v1",
+			files: map[string]string{
+				"/proc/self/cgroup": "12:memory:/docker/" + testContainerID + "\n",
+			},
+			want: map[string]string{
+				MetadataContainerID:           testContainerID,
+				MetadataContainerOrchestrator: "docker",
+				MetadataContainerHostname:     "web-7d9f",
+			},
+		},
+		{
+			name: "cgroup v2 mounts",
+			files: map[string]string{
+				"/proc/self/cgroup":    "0::/\n",
+				"/proc/self/mountinfo": "1 0 0:1 /var/lib/docker/containers/" + testContainerID + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n",
+			},
+			want: map[string]string{
+				MetadataContainerID:           testContainerID,
+				MetadataContainerOrchestrator: "docker",
+				MetadataContainerHostname:     "web-7d9f",
+			},
+		},
+		{
+			name: "kubernetes",
+			files: map[string]string{
+				"/proc/self/cgroup": "0::/kubepods.slice/cri-containerd-" + testContainerID + ".scope\n",
+			},
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.96.0.1",
+				"HOSTNAME":                "web-7d9f",
+				"POD_NAMESPACE":           "shop",
+				"NODE_NAME":               "node-1",
+			},
+			want: map[string]string{
+				MetadataContainerID:           testContainerID,
+				MetadataContainerOrchestrator: "kubernetes",
+				MetadataContainerHostname:     "web-7d9f",
+				MetadataKubernetesPod:         "web-7d9f",
+				MetadataKubernetesNamespace:   "shop",
+				MetadataKubernetesNode:        "node-1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fakeContainerEnv(tt.files, tt.env).detect()
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("detect() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerMetadataYieldsToExplicitMetadata(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f, WithMetadata(map[string]string{MetadataContainerOrchestrator: "custom"}))
+	client.containerMetadata = map[string]string{
+		MetadataContainerID:           testContainerID,
+		MetadataContainerOrchestrator: "docker",
+	}
+
+	kv := client.registrationMetadata()
+	if kv[MetadataContainerID] != testContainerID || kv[MetadataContainerOrchestrator] != "custom" {
+		t.Errorf("registrationMetadata() = %v; want the container ID and the explicit orchestrator", kv)
+	}
+}
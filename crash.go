@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+const crashDeregisterTimeout = 2 * time.Second
+
+// CrashAction is what the safety net does to the registration when the
+// process crashes.
+type CrashAction int
+
+const (
+	// CrashActionUnregister removes the instance from Eureka.
+	CrashActionUnregister CrashAction = iota
+	// CrashActionMarkDown keeps the instance registered but sets it DOWN.
+	CrashActionMarkDown
+)
+
+// DeregisterOnPanic is meant to be deferred at the top of a goroutine. If the
+// goroutine panics, it logs the panic, makes a best-effort attempt to
+// unregister the instance (or mark it DOWN, see WithCrashSafetyNet) and then
+// re-panics, so stale registrations don't linger until lease eviction.
+func (c *Client) DeregisterOnPanic() {
+	if r := recover(); r != nil {
+		c.onCrash(r)
+		panic(r)
+	}
+}
+
+// safetyNet is deferred at the top of the goroutines the client starts. It
+// behaves like DeregisterOnPanic when WithCrashSafetyNet is set and lets
+// panics through untouched otherwise.
+func (c *Client) safetyNet() {
+	if !c.crashSafetyNet {
+		return
+	}
+	if r := recover(); r != nil {
+		c.onCrash(r)
+		panic(r)
+	}
+}
+
+// Exit makes a best-effort attempt to unregister the instance (or mark it
+// DOWN) and then terminates the process with os.Exit. Deferred functions are
+// not run.
+func (c *Client) Exit(code int) {
+	c.onCrash(nil)
+	os.Exit(code)
+}
+
+// onCrash cleans up the registration after a crash; panicValue is what was
+// recovered, or nil when the process exits without panicking.
+func (c *Client) onCrash(panicValue any) {
+	logger := c.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), crashDeregisterTimeout)
+	defer cancel()
+
+	if panicValue != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "recovered panic, cleaning up eureka registration",
+			slog.String("instance", c.instanceID),
+			slog.Any("panic", panicValue),
+			slog.String("stack", string(debug.Stack())),
+		)
+	}
+	if c.State() == StateUnregistered {
+		return
+	}
+
+	var err error
+	switch c.crashAction {
+	case CrashActionMarkDown:
+		err = c.SetStatus(ctx, StatusDown)
+	default:
+		err = c.UnregisterWithReason(ctx, ShutdownReasonCrash)
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "failed to clean up eureka registration on crash",
+			slog.String("instance", c.instanceID),
+			slog.Any("error", err),
+		)
+	}
+}
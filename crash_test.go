@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDeregisterOnPanic(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v; want the original panic value", r)
+			}
+		}()
+		defer client.DeregisterOnPanic()
+		panic("boom")
+	}()
+
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+	if client.State() != StateUnregistered {
+		t.Errorf("state = %s; want UNREGISTERED", client.State())
+	}
+}
+
+func TestDeregisterOnPanicLogsThePanic(t *testing.T) {
+	f := newFakeEureka(t)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := newTestClient(t, f, WithLogger(logger), WithCrashSafetyNet(CrashActionUnregister))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	// The cache's goroutines hand their panics to the same cleanup.
+	func() {
+		defer func() { _ = recover() }()
+		defer client.Cache().recoverPanic()
+		panic("boom")
+	}()
+
+	var record struct {
+		Level    string
+		Instance string
+		Panic    string
+		Stack    string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to decode log record %q: %v", buf.String(), err)
+	}
+	if record.Level != "ERROR" || record.Panic != "boom" || record.Instance == "" {
+		t.Errorf("log record = %+v; want the panic at error level with the instance", record)
+	}
+	if !strings.Contains(record.Stack, "TestDeregisterOnPanicLogsThePanic") {
+		t.Errorf("stack = %q; want the panicking goroutine's stack", record.Stack)
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// Data center names understood by Eureka.
+const (
+	DataCenterMyOwn  = eurekaapi.DefaultDataCenter
+	DataCenterAmazon = eurekaapi.AmazonDataCenter
+)
+
+// ErrDataCenterInfoUnavailable is returned by a DataCenterInfoProvider that
+// doesn't apply to the environment the process runs in, e.g. an Amazon
+// provider off EC2. The client then registers with MyOwn.
+var ErrDataCenterInfoUnavailable = errors.New("data center info unavailable")
+
+// DataCenterInfo describes where the instance runs.
+type DataCenterInfo struct {
+	// Name is the data center name, e.g. DataCenterMyOwn or DataCenterAmazon.
+	Name string
+	// Class is the Java class Eureka decodes the data center info with. It
+	// may be left empty for MyOwn.
+	Class string
+	// Metadata is published with the data center, e.g. the EC2 instance ID.
+	Metadata map[string]string
+	// IP is registered when RegisterInstance isn't given an address.
+	IP net.IP
+	// Zone is published as the zone metadata unless set explicitly.
+	Zone string
+}
+
+func (info DataCenterInfo) dataCenter() eurekaapi.DataCenter {
+	return eurekaapi.DataCenter{
+		Class:    info.Class,
+		Name:     info.Name,
+		Metadata: eurekaapi.NewMetadata(info.Metadata),
+	}
+}
+
+// DataCenterInfoProvider produces the data center info an instance registers
+// with. Site-specific providers can be plugged in with
+// WithDataCenterInfoProvider.
+type DataCenterInfoProvider interface {
+	DataCenterInfo(ctx context.Context) (DataCenterInfo, error)
+}
+
+// DataCenterInfoFunc adapts a function to a DataCenterInfoProvider.
+type DataCenterInfoFunc func(ctx context.Context) (DataCenterInfo, error)
+
+func (f DataCenterInfoFunc) DataCenterInfo(ctx context.Context) (DataCenterInfo, error) {
+	return f(ctx)
+}
+
+// dataCenterInfo returns where the instance runs. It is read from the
+// configured provider once and reused; if the provider doesn't apply, the
+// client falls back to MyOwn.
+func (c *Client) dataCenterInfo(ctx context.Context) (DataCenterInfo, error) {
+	fallback := DataCenterInfo{Name: DataCenterMyOwn}
+	if c.dataCenterProvider == nil {
+		return fallback, nil
+	}
+
+	c.mu.Lock()
+	cached := c.cachedDataCenterInfo
+	c.mu.Unlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	info, err := c.dataCenterProvider.DataCenterInfo(ctx)
+	if errors.Is(err, ErrDataCenterInfoUnavailable) {
+		return fallback, nil
+	}
+	if err != nil {
+		return DataCenterInfo{}, err
+	}
+	if info.Name == "" {
+		info.Name = DataCenterMyOwn
+	}
+	c.mu.Lock()
+	c.cachedDataCenterInfo = &info
+	c.mu.Unlock()
+	return info, nil
+}
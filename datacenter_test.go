@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCustomDataCenterInfoProvider(t *testing.T) {
+	f := newFakeEureka(t)
+	calls := 0
+	provider := DataCenterInfoFunc(func(context.Context) (DataCenterInfo, error) {
+		calls++
+		return DataCenterInfo{
+			Name:     "MyOwn",
+			Metadata: map[string]string{"rack": "r12"},
+			Zone:     "dc1-a",
+		}, nil
+	})
+	client := newTestClient(t, f, WithDataCenterInfoProvider(provider))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+			t.Fatalf("RegisterInstance returned error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times; want 1", calls)
+	}
+	reg := client.registration
+	if rack, _ := reg.DataCenterInfo.Metadata.Get("rack"); rack != "r12" {
+		t.Errorf("data center metadata rack = %q; want r12", rack)
+	}
+	if zone, _ := reg.Metadata.Get(MetadataZone); zone != "dc1-a" {
+		t.Errorf("zone metadata = %q; want dc1-a", zone)
+	}
+}
+
+func TestDataCenterInfoProviderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "unavailable falls back", err: ErrDataCenterInfoUnavailable},
+		{name: "failure fails registration", err: errors.New("boom"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeEureka(t)
+			client := newTestClient(t, f, WithDataCenterInfoProvider(DataCenterInfoFunc(func(context.Context) (DataCenterInfo, error) {
+				return DataCenterInfo{}, tt.err
+			})))
+			_, err := client.RegisterInstance(context.Background(), testIP, 30, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterInstance error = %v; want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if got := f.count(http.MethodPost); got != 0 {
+					t.Errorf("server received %d registrations; want none", got)
+				}
+				return
+			}
+			if got := client.registration.DataCenterInfo.Name; got != DataCenterMyOwn {
+				t.Errorf("data center = %s; want MyOwn", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+const debugErrorLimit = 20
+
+// NodeStatus describes the recent health of a single Eureka server URL.
+type NodeStatus = eurekaapi.NodeStatus
+
+// NodeStatus reports, for every configured Eureka service URL in failover
+// order, when it last answered and failed, how many requests in a row failed
+// and whether it is quarantined, e.g. for a connectivity dashboard.
+func (c *Client) NodeStatus() []NodeStatus {
+	return c.eurekaAPIClient.Nodes()
+}
+
+type debugRegistration struct {
+	InstanceID string              `json:"instanceId"`
+	State      string              `json:"state"`
+	ClockSkew  string              `json:"clockSkew"`
+	Renewals   RenewalStats        `json:"renewals"`
+	Payload    *eurekaapi.Instance `json:"payload,omitempty"`
+}
+
+type debugCache struct {
+	Applications int                       `json:"applications"`
+	Instances    int                       `json:"instances"`
+	HashCode     string                    `json:"hashCode,omitempty"`
+	LastRefresh  time.Time                 `json:"lastRefresh,omitzero"`
+	Stats        CacheStats                `json:"stats"`
+	Apps         map[string]map[string]int `json:"apps"`
+}
+
+// DebugHandler returns an http.Handler exposing the client's internals as
+// JSON, similar to the Java client's discovery endpoints. Mount it under a
+// prefix with http.StripPrefix; it serves:
+//
+//	/              everything below in one document
+//	/registration  the registered payload and client state
+//	/cache         a summary of the registry cache
+//	/nodes         health of each Eureka server URL
+//	/history       recent client actions
+//	/errors        recent failed client actions
+func (c *Client) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /registration", func(w http.ResponseWriter, _ *http.Request) {
+		writeDebugJSON(w, c.debugRegistration())
+	})
+	mux.HandleFunc("GET /cache", func(w http.ResponseWriter, _ *http.Request) {
+		writeDebugJSON(w, c.debugCache())
+	})
+	mux.HandleFunc("GET /nodes", func(w http.ResponseWriter, _ *http.Request) {
+		writeDebugJSON(w, c.NodeStatus())
+	})
+	mux.HandleFunc("GET /history", func(w http.ResponseWriter, _ *http.Request) {
+		writeDebugJSON(w, c.History())
+	})
+	mux.HandleFunc("GET /errors", func(w http.ResponseWriter, _ *http.Request) {
+		writeDebugJSON(w, c.recentErrors())
+	})
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, _ *http.Request) {
+		writeDebugJSON(w, map[string]any{
+			"registration": c.debugRegistration(),
+			"cache":        c.debugCache(),
+			"nodes":        c.NodeStatus(),
+			"history":      c.History(),
+			"errors":       c.recentErrors(),
+		})
+	})
+	return mux
+}
+
+func (c *Client) debugRegistration() debugRegistration {
+	c.mu.Lock()
+	var payload *eurekaapi.Instance
+	if c.registration != nil {
+		copied := *c.registration
+		payload = &copied
+	}
+	c.mu.Unlock()
+
+	return debugRegistration{
+		InstanceID: c.instanceID,
+		State:      c.State().String(),
+		ClockSkew:  c.eurekaAPIClient.ClockSkew().String(),
+		Renewals:   c.RenewalStats(),
+		Payload:    payload,
+	}
+}
+
+func (c *Client) debugCache() debugCache {
+	summary := debugCache{
+		Stats: c.cache.Stats(),
+		Apps:  make(map[string]map[string]int),
+	}
+	apps, err := c.cache.Applications()
+	if err != nil {
+		return summary
+	}
+
+	summary.Applications = len(apps.Application)
+	summary.HashCode = apps.AppsHashCode
+	summary.LastRefresh = summary.Stats.LastRefresh
+	for _, app := range apps.Application {
+		statuses := make(map[string]int)
+		for _, inst := range app.Instance {
+			statuses[inst.Status]++
+			summary.Instances++
+		}
+		summary.Apps[app.Name] = statuses
+	}
+	return summary
+}
+
+func (c *Client) recentErrors() []HistoryEntry {
+	var errs []HistoryEntry
+	for _, entry := range c.History() {
+		if entry.Error != "" {
+			errs = append(errs, entry)
+		}
+	}
+	if len(errs) > debugErrorLimit {
+		errs = errs[len(errs)-debugErrorLimit:]
+	}
+	return errs
+}
+
+func writeDebugJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/debug/eureka", client.DebugHandler()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/debug/eureka/registration")
+	if err != nil {
+		t.Fatalf("GET registration failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var reg struct {
+		InstanceID string `json:"instanceId"`
+		State      string `json:"state"`
+		Payload    struct {
+			App string `json:"app"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reg); err != nil {
+		t.Fatalf("failed to decode registration: %v", err)
+	}
+	if reg.InstanceID != client.InstanceID() || reg.State != "REGISTERED" || reg.Payload.App != "TEST-APP" {
+		t.Errorf("registration = %+v; want registered TEST-APP instance", reg)
+	}
+
+	for _, path := range []string{"/", "/cache", "/nodes", "/history", "/errors"} {
+		resp, err := http.Get(server.URL + "/debug/eureka" + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s status = %d; want 200", path, resp.StatusCode)
+		}
+	}
+}
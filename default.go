@@ -0,0 +1,181 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvServiceURLs = "EUREKA_SERVICE_URLS"
+	EnvAppID       = "EUREKA_APP_ID"
+	EnvHost        = "EUREKA_HOST"
+	EnvIP          = "EUREKA_IP"
+	EnvPort        = "EUREKA_PORT"
+	EnvInstanceID  = "EUREKA_INSTANCE_ID"
+	// EnvSpringDefaultZone is the variable Spring Cloud applications are
+	// configured with; it is used when EnvServiceURLs is not set.
+	EnvSpringDefaultZone = "EUREKA_CLIENT_SERVICEURL_DEFAULTZONE"
+)
+
+const (
+	defaultServiceURL = "http://localhost:8761/eureka"
+	defaultPort       = 8080
+)
+
+// ConfigFromEnv builds a Config from the environment. Service URLs are
+// comma-separated and default to a local Eureka server. The application ID
+// defaults to the name of the executable, the host to the hostname and the
+// port to 8080, so tools that only discover other services need nothing but
+// the service URLs. The IP and instance ID are only set if EnvIP and
+// EnvInstanceID are.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		AppID:      os.Getenv(EnvAppID),
+		Host:       os.Getenv(EnvHost),
+		Port:       defaultPort,
+		InstanceID: os.Getenv(EnvInstanceID),
+	}
+
+	urls := os.Getenv(EnvServiceURLs)
+	if urls == "" {
+		urls = os.Getenv(EnvSpringDefaultZone)
+	}
+	if urls == "" {
+		urls = defaultServiceURL
+	}
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			cfg.EurekaServiceURLs = append(cfg.EurekaServiceURLs, u)
+		}
+	}
+
+	if cfg.AppID == "" {
+		cfg.AppID = strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	}
+	if cfg.Host == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to determine hostname, set %s: %w", EnvHost, err)
+		}
+		cfg.Host = host
+	}
+	if v := os.Getenv(EnvIP); v != "" {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return Config{}, fmt.Errorf("%w: invalid %s %q", ErrInvalidConfig, EnvIP, v)
+		}
+		cfg.IP = ip
+	}
+	if p := os.Getenv(EnvPort); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: invalid %s %q: %w", ErrInvalidConfig, EnvPort, p, err)
+		}
+		cfg.Port = port
+	}
+	return cfg, nil
+}
+
+// defaultClient is the process-wide client behind Default and Resolve.
+var defaultClient struct {
+	mu       sync.Mutex
+	client   *Client
+	resolver *Resolver
+	// stop ends the registry refreshes of a client created by Default. It is
+	// nil for clients installed with SetDefault.
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// Default returns the process-wide client, creating it from the environment
+// (see ConfigFromEnv) on first use. A client created this way keeps its
+// registry cache refreshed in the background until CloseDefault is called.
+// If creating the client fails, the next call tries again.
+func Default() (*Client, error) {
+	defaultClient.mu.Lock()
+	defer defaultClient.mu.Unlock()
+	if err := initDefaultLocked(); err != nil {
+		return nil, err
+	}
+	return defaultClient.client, nil
+}
+
+func initDefaultLocked() error {
+	if defaultClient.client != nil {
+		return nil
+	}
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	client, err := New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create default Eureka client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer client.safetyNet()
+		_ = client.Cache().Run(ctx)
+	}()
+	defaultClient.client = client
+	defaultClient.resolver = client.Resolver()
+	defaultClient.stop = cancel
+	defaultClient.stopped = stopped
+	return nil
+}
+
+// SetDefault makes client the process-wide client, closing one previously
+// created by Default. The caller stays responsible for the lifecycle of
+// client, including running its cache. A nil client resets the default so the
+// next call to Default creates one from the environment.
+func SetDefault(client *Client) {
+	defaultClient.mu.Lock()
+	defer defaultClient.mu.Unlock()
+	closeDefaultLocked()
+	if client != nil {
+		defaultClient.client = client
+		defaultClient.resolver = client.Resolver()
+	}
+}
+
+// CloseDefault stops the background refreshes of a client created by Default
+// and forgets it. It does nothing if there is no default client.
+func CloseDefault() {
+	defaultClient.mu.Lock()
+	defer defaultClient.mu.Unlock()
+	closeDefaultLocked()
+}
+
+func closeDefaultLocked() {
+	if defaultClient.stop != nil {
+		defaultClient.stop()
+		<-defaultClient.stopped
+	}
+	defaultClient.client = nil
+	defaultClient.resolver = nil
+	defaultClient.stop = nil
+	defaultClient.stopped = nil
+}
+
+// Resolve picks an endpoint of app using the process-wide client.
+func Resolve(ctx context.Context, app string) (Endpoint, error) {
+	defaultClient.mu.Lock()
+	if err := initDefaultLocked(); err != nil {
+		defaultClient.mu.Unlock()
+		return Endpoint{}, err
+	}
+	resolver := defaultClient.resolver
+	defaultClient.mu.Unlock()
+	return resolver.Resolve(ctx, app)
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvServiceURLs, "")
+	t.Setenv(EnvSpringDefaultZone, "http://a:8761/eureka, http://b:8761/eureka")
+	t.Setenv(EnvAppID, "tool")
+	t.Setenv(EnvHost, "tool.local")
+	t.Setenv(EnvPort, "9090")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv returned error: %v", err)
+	}
+	if len(cfg.EurekaServiceURLs) != 2 || cfg.EurekaServiceURLs[1] != "http://b:8761/eureka" {
+		t.Errorf("EurekaServiceURLs = %q; want both Spring default zone URLs", cfg.EurekaServiceURLs)
+	}
+	if cfg.AppID != "tool" || cfg.Host != "tool.local" || cfg.Port != 9090 {
+		t.Errorf("ConfigFromEnv() = %+v; want tool at tool.local:9090", cfg)
+	}
+
+	t.Setenv(EnvPort, "http")
+	if _, err := ConfigFromEnv(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("ConfigFromEnv with a bad port = %v; want ErrInvalidConfig", err)
+	}
+}
+
+func TestDefaultClientResolves(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`<applications><application><name>FOO</name><instance><instanceId>foo-1</instanceId><ipAddr>10.0.0.1</ipAddr><status>UP</status><port enabled="true">80</port></instance></application></applications>`))
+	})
+	t.Setenv(EnvServiceURLs, f.URL)
+	defer CloseDefault()
+
+	ep, err := Resolve(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if ep.InstanceID != "foo-1" {
+		t.Errorf("Resolve(foo) = %q; want foo-1", ep.InstanceID)
+	}
+
+	first, err := Default()
+	if err != nil {
+		t.Fatalf("Default returned error: %v", err)
+	}
+	if second, _ := Default(); second != first {
+		t.Errorf("Default returned a new client on the second call")
+	}
+	CloseDefault()
+	if third, _ := Default(); third == first {
+		t.Errorf("Default returned the closed client after CloseDefault")
+	}
+}
@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// WithDeltaPolling makes Cache.Run poll the registry delta every interval
+// and merge it into the cache, in addition to the full refreshes. Eureka
+// servers don't push changes, but the delta is small enough to poll every
+// few seconds, so caches converge much faster than with full refreshes
+// alone. If a merged registry doesn't match the server's hashcode, the cache
+// falls back to a full refresh.
+func WithDeltaPolling(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.deltaInterval = interval
+		}
+	}
+}
+
+// fetchDelta is GetDelta with the client's own pending updates applied, like
+// fetchApplications.
+func (c *Client) fetchDelta(ctx context.Context) (eurekaapi.Applications, error) {
+	delta, err := c.eurekaAPIClient.GetDelta(ctx)
+	if err != nil {
+		return delta, err
+	}
+	return c.ownApplications(delta), nil
+}
+
+// runDeltaPolling merges the registry delta into the cache every interval
+// until ctx is cancelled.
+func (c *Cache) runDeltaPolling(ctx context.Context) {
+	defer c.recoverPanic()
+	ticker := c.clock.NewTicker(c.deltaInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		if !c.gate.await(ctx) {
+			return
+		}
+
+		call, leader := c.startRefresh()
+		if !leader {
+			// A full refresh is in flight; it is at least as fresh.
+			continue
+		}
+		c.lead(ctx, call, c.doDeltaRefresh)
+		if call.wait(ctx) != nil && ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// doDeltaRefresh merges the registry delta into the cache, falling back to a
+// full refresh when the merge doesn't reconcile with the server. It does
+// nothing until the cache has been populated.
+func (c *Cache) doDeltaRefresh(ctx context.Context, call *refreshCall) {
+	c.mu.RLock()
+	populated := c.populated
+	raw := c.raw
+	c.mu.RUnlock()
+	if !populated {
+		c.doRefresh(ctx, call)
+		return
+	}
+
+	fetchCtx, cancel := fetchContext(ctx)
+	defer cancel()
+	start := c.clock.Now()
+	delta, err := c.fetchDelta(fetchCtx)
+	if err != nil {
+		c.failures.Add(1)
+		c.events.publish(Event{Type: EventRegistryRefreshFailed, Err: err})
+		c.notify(err)
+		c.finishRefresh(call, err)
+		return
+	}
+
+	merged := applyDelta(raw, delta)
+	if merged.ReconcileHashCode() != delta.AppsHashCode {
+		c.inconsistencies.Add(1)
+		c.doRefresh(ctx, call)
+		return
+	}
+	if c.store(merged, start) {
+		c.events.publish(Event{Type: EventRegistryUpdated, Detail: merged.AppsHashCode})
+	}
+	c.deltas.Add(1)
+	c.notify(nil)
+	c.finishRefresh(call, nil)
+}
+
+// applyDelta returns apps with the instances of delta added, replaced or
+// removed according to their action type. apps is left untouched, as readers
+// may still hold it.
+func applyDelta(apps, delta eurekaapi.Applications) eurekaapi.Applications {
+	merged := make([]eurekaapi.Application, len(apps.Application))
+	copy(merged, apps.Application)
+	index := make(map[string]int, len(merged))
+	for i, app := range merged {
+		index[strings.ToUpper(app.Name)] = i
+	}
+	cloned := make(map[int]bool)
+
+	for _, app := range delta.Application {
+		key := strings.ToUpper(app.Name)
+		i, ok := index[key]
+		if !ok {
+			merged = append(merged, eurekaapi.Application{Name: app.Name})
+			i = len(merged) - 1
+			index[key] = i
+			cloned[i] = true
+		}
+		if !cloned[i] {
+			merged[i].Instance = append([]eurekaapi.Instance(nil), merged[i].Instance...)
+			cloned[i] = true
+		}
+		for _, inst := range app.Instance {
+			merged[i].Instance = applyInstanceDelta(merged[i].Instance, inst)
+		}
+	}
+
+	// Drop applications whose last instance was deleted, as full fetches
+	// don't list them either.
+	kept := merged[:0]
+	for _, app := range merged {
+		if len(app.Instance) > 0 {
+			kept = append(kept, app)
+		}
+	}
+
+	apps.Application = kept
+	apps.AppsHashCode = delta.AppsHashCode
+	apps.VersionsDelta = delta.VersionsDelta
+	return apps
+}
+
+func applyInstanceDelta(instances []eurekaapi.Instance, inst eurekaapi.Instance) []eurekaapi.Instance {
+	action := inst.ActionType
+	inst.ActionType = ""
+	for j := range instances {
+		if instances[j].InstanceID != inst.InstanceID {
+			continue
+		}
+		if action == eurekaapi.DELETED {
+			return append(instances[:j], instances[j+1:]...)
+		}
+		instances[j] = inst
+		return instances
+	}
+	if action == eurekaapi.DELETED {
+		return instances
+	}
+	return append(instances, inst)
+}
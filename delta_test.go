@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestCacheMergesDelta(t *testing.T) {
+	fetches := 0
+	cache := newCache(func(ctx context.Context) (eurekaapi.Applications, error) {
+		fetches++
+		return eurekaapi.Applications{
+			AppsHashCode: "UP_2_",
+			Application: []eurekaapi.Application{
+				{Name: "FOO", Instance: []eurekaapi.Instance{
+					{InstanceID: "foo-1", Status: StatusUp},
+					{InstanceID: "foo-2", Status: StatusUp},
+				}},
+			},
+		}, nil
+	}, time.Minute, time.Minute)
+	var delta eurekaapi.Applications
+	cache.fetchDelta = func(ctx context.Context) (eurekaapi.Applications, error) {
+		return delta, nil
+	}
+	mergeDelta := func() {
+		call, _ := cache.startRefresh()
+		cache.doDeltaRefresh(context.Background(), call)
+	}
+
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	before, _ := cache.Application("FOO")
+
+	delta = eurekaapi.Applications{
+		AppsHashCode: "DOWN_1_UP_1_",
+		Application: []eurekaapi.Application{
+			{Name: "FOO", Instance: []eurekaapi.Instance{
+				{InstanceID: "foo-1", Status: StatusDown, ActionType: eurekaapi.MODIFIED},
+				{InstanceID: "foo-2", ActionType: eurekaapi.DELETED},
+			}},
+			{Name: "BAR", Instance: []eurekaapi.Instance{
+				{InstanceID: "bar-1", Status: StatusUp, ActionType: eurekaapi.ADDED},
+			}},
+		},
+	}
+	mergeDelta()
+
+	foo, _ := cache.Application("FOO")
+	if len(foo.Instance) != 1 || foo.Instance[0].Status != StatusDown || foo.Instance[0].ActionType != "" {
+		t.Errorf("FOO after delta = %+v; want foo-1 DOWN", foo.Instance)
+	}
+	if bar, ok := cache.Application("BAR"); !ok || len(bar.Instance) != 1 {
+		t.Errorf("BAR after delta = %+v, %t; want bar-1", bar, ok)
+	}
+	if len(before.Instance) != 2 || before.Instance[0].Status != StatusUp {
+		t.Errorf("delta modified a previously returned application: %+v", before.Instance)
+	}
+	if fetches != 1 || cache.Stats().Deltas != 1 {
+		t.Errorf("fetches = %d, deltas = %d; want 1 and 1", fetches, cache.Stats().Deltas)
+	}
+
+	// A delta that doesn't reconcile with the server falls back to a full
+	// fetch.
+	delta = eurekaapi.Applications{AppsHashCode: "UP_5_"}
+	mergeDelta()
+	if fetches != 2 || cache.Stats().Inconsistencies != 1 {
+		t.Errorf("fetches = %d, inconsistencies = %d; want 2 and 1", fetches, cache.Stats().Inconsistencies)
+	}
+	if foo, _ := cache.Application("FOO"); len(foo.Instance) != 2 {
+		t.Errorf("FOO after full fetch has %d instances; want 2", len(foo.Instance))
+	}
+}
@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Config holds the arguments of NewClient, so the client can be built by
+// dependency injection frameworks from a single provided value.
+type Config struct {
+	EurekaServiceURLs []string
+	AppID             string
+	Host              string
+	// IP is registered independently of Host, which may be a DNS name; see
+	// WithIPAddress.
+	IP   net.IP
+	Port int
+	// InstanceID overrides the generated instance ID; see WithInstanceID.
+	InstanceID string
+	Options    []Option
+	// Run is validated along with the rest of the configuration, for callers
+	// that go on to use it with Run or NewLifecycleHook.
+	Run RunOptions
+}
+
+// New is like NewClient but returns the concrete *Client, which is what DI
+// frameworks such as wire and fx expect from a provider.
+// The configuration is validated first.
+func New(cfg Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	opts := cfg.Options
+	if cfg.IP != nil {
+		opts = append([]Option{WithIPAddress(cfg.IP)}, opts...)
+	}
+	if cfg.InstanceID != "" {
+		opts = append([]Option{WithInstanceID(cfg.InstanceID)}, opts...)
+	}
+	client, err := NewClient(cfg.EurekaServiceURLs, cfg.AppID, cfg.Host, cfg.Port, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.(*Client), nil
+}
+
+// LifecycleHook ties a Client's registration to an application's start and
+// stop phases. Its methods match the OnStart/OnStop signatures of fx.Hook:
+//
+//	lc.Append(fx.Hook{OnStart: hook.OnStart, OnStop: hook.OnStop})
+type LifecycleHook struct {
+	client *Client
+	opts   RunOptions
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewLifecycleHook returns a hook that registers client with opts on start.
+func NewLifecycleHook(client *Client, opts RunOptions) *LifecycleHook {
+	return &LifecycleHook{client: client, opts: opts}
+}
+
+// OnStart registers the instance and starts sending heartbeats in the
+// background. ctx only bounds the registration.
+func (h *LifecycleHook) OnStart(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return errors.New("lifecycle hook already started")
+	}
+
+	opts := h.opts
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.TTL == 0 {
+		opts.TTL = defaultTTL
+	}
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = heartbeatInterval(opts.TTL)
+	}
+	if _, err := h.client.RegisterInstance(ctx, opts.IP, opts.TTL, opts.UseSSL); err != nil {
+		return err
+	}
+
+	hbCtx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer h.client.safetyNet()
+		_ = h.client.RunHeartbeat(hbCtx, opts.HeartbeatInterval)
+	}()
+	h.cancel = cancel
+	h.stopped = stopped
+	return nil
+}
+
+// OnStop stops the heartbeats and unregisters the instance within ctx,
+// recording the ShutdownReason of the hook's options, if set.
+func (h *LifecycleHook) OnStop(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel == nil {
+		return nil
+	}
+
+	h.cancel()
+	select {
+	case <-h.stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop heartbeat loop: %w", ctx.Err())
+	}
+	h.cancel = nil
+	return h.client.UnregisterWithReason(ctx, h.opts.ShutdownReason)
+}
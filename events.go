@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+const defaultEventBuffer = 64
+
+// EventType tells what an Event is about.
+type EventType string
+
+const (
+	// EventAction reports a client action, as recorded in History.
+	EventAction EventType = "ACTION"
+	// EventStateChanged reports a change of the client's State.
+	EventStateChanged EventType = "STATE_CHANGED"
+	// EventRegistryUpdated reports a refresh that changed the cached registry.
+	EventRegistryUpdated EventType = "REGISTRY_UPDATED"
+	// EventRegistryRefreshFailed reports a failed registry refresh.
+	EventRegistryRefreshFailed EventType = "REGISTRY_REFRESH_FAILED"
+	// EventWarning reports a likely misconfiguration, described by Detail.
+	EventWarning EventType = "WARNING"
+	// EventServersChanged reports a new list of Eureka servers discovered
+	// from the registry, comma-separated in Detail.
+	EventServersChanged EventType = "SERVERS_CHANGED"
+	// EventReRegistrationShared reports a heartbeat loop that found the
+	// lease gone while another one was re-registering the instance, or had
+	// just done so, and took over its result, in Err, instead of
+	// re-registering again.
+	EventReRegistrationShared EventType = "RE_REGISTRATION_SHARED"
+	// EventRegistryInconsistent reports a VerifyConsistency call that found
+	// the cached registry diverging from the server. Detail holds both
+	// hashcodes, as "cache=<hashcode> server=<hashcode>".
+	EventRegistryInconsistent EventType = "REGISTRY_INCONSISTENT"
+)
+
+// Event is a lifecycle or registry event delivered by Client.Events.
+type Event struct {
+	Time time.Time
+	Type EventType
+	// Action is set for EventAction.
+	Action Action
+	// State is the new state for EventStateChanged.
+	State  State
+	Detail string
+	Err    error
+}
+
+// eventBus delivers events to a bounded channel without ever blocking the
+// publisher. Nothing is buffered until someone subscribes.
+type eventBus struct {
+	clock   clock.Clock
+	size    int
+	dropped atomic.Uint64
+
+	mu sync.Mutex
+	ch chan Event
+}
+
+func newEventBus(size int, clk clock.Clock) *eventBus {
+	return &eventBus{clock: clk, size: size}
+}
+
+func (b *eventBus) subscribe() <-chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ch == nil {
+		b.ch = make(chan Event, b.size)
+	}
+	return b.ch
+}
+
+func (b *eventBus) publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ch == nil {
+		return
+	}
+	e.Time = b.clock.Now()
+	select {
+	case b.ch <- e:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+// Events returns a channel delivering the client's lifecycle and registry
+// events, for consumers that prefer a channel to callbacks. Every call
+// returns the same channel, which is never closed. Events are buffered up to
+// WithEventBuffer; once the buffer is full new events are dropped rather
+// than slowing the client down, and counted by DroppedEvents.
+func (c *Client) Events() <-chan Event {
+	return c.events.subscribe()
+}
+
+// DroppedEvents returns how many events were dropped because the Events
+// channel was full.
+func (c *Client) DroppedEvents() uint64 {
+	return c.events.dropped.Load()
+}
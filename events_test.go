@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestEventsReportLifecycle(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	events := client.Events()
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	var got []EventType
+	for len(events) > 0 {
+		e := <-events
+		got = append(got, e.Type)
+		if e.Type == EventStateChanged && e.State != StateRegistered {
+			t.Errorf("state changed to %s; want REGISTERED", e.State)
+		}
+	}
+	if len(got) != 2 || got[0] != EventAction || got[1] != EventStateChanged {
+		t.Errorf("events = %v; want the registration followed by the state change", got)
+	}
+}
+
+func TestEventsDropWhenFull(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	client := newTestClient(t, f, WithEventBuffer(1))
+	_ = client.Heartbeat(context.Background())
+	if client.DroppedEvents() != 0 {
+		t.Fatalf("events dropped before anyone subscribed")
+	}
+
+	events := client.Events()
+	for i := 0; i < 3; i++ {
+		_ = client.Heartbeat(context.Background())
+	}
+	if got := len(events); got != 1 {
+		t.Errorf("buffered %d events; want 1", got)
+	}
+	if got := client.DroppedEvents(); got != 2 {
+		t.Errorf("DroppedEvents() = %d; want 2", got)
+	}
+}
+
+func TestEventsReportRegistryChanges(t *testing.T) {
+	status := StatusUp
+	var fetchErr error
+	cache := newCache(func(context.Context) (eurekaapi.Applications, error) {
+		inst := testInstance("foo-1", status, 80, 0)
+		return eurekaapi.Applications{Application: []eurekaapi.Application{{Name: "FOO", Instance: []eurekaapi.Instance{inst}}}}, fetchErr
+	}, time.Minute, time.Minute)
+	cache.events = newEventBus(10, cache.clock)
+	events := cache.events.subscribe()
+
+	refresh := func() { _ = cache.Refresh(context.Background()) }
+	refresh()
+	refresh()
+	status = StatusDown
+	refresh()
+	fetchErr = errors.New("unreachable")
+	refresh()
+
+	var got []EventType
+	for len(events) > 0 {
+		got = append(got, (<-events).Type)
+	}
+	want := []EventType{EventRegistryUpdated, EventRegistryUpdated, EventRegistryRefreshFailed}
+	if !slices.Equal(got, want) {
+		t.Errorf("events = %v; want %v", got, want)
+	}
+}
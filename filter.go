@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"slices"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// InstanceFilter reports whether an instance should be visible in query
+// results. Filters registered with WithInstanceFilter are applied by the
+// cache, and therefore the resolver, and by the typed query methods.
+type InstanceFilter func(inst eurekaapi.Instance) bool
+
+// ExcludeStatus hides instances in any of the given statuses, e.g. STARTING.
+func ExcludeStatus(statuses ...string) InstanceFilter {
+	return func(inst eurekaapi.Instance) bool {
+		return !slices.Contains(statuses, inst.Status)
+	}
+}
+
+// RequireMetadata hides instances that don't carry the metadata key.
+func RequireMetadata(key string) InstanceFilter {
+	return func(inst eurekaapi.Instance) bool {
+		_, ok := inst.Metadata.Get(key)
+		return ok
+	}
+}
+
+type instanceFilters []InstanceFilter
+
+func (f instanceFilters) keep(inst eurekaapi.Instance) bool {
+	for _, filter := range f {
+		if !filter(inst) {
+			return false
+		}
+	}
+	return true
+}
+
+// application returns a copy of app without the filtered out instances. The
+// instances of app are left untouched, as they may be shared with the cache.
+func (f instanceFilters) application(app eurekaapi.Application) eurekaapi.Application {
+	if len(f) == 0 {
+		return app
+	}
+	instances := make([]eurekaapi.Instance, 0, len(app.Instance))
+	for _, inst := range app.Instance {
+		if f.keep(inst) {
+			instances = append(instances, inst)
+		}
+	}
+	app.Instance = instances
+	return app
+}
+
+func (f instanceFilters) applications(apps eurekaapi.Applications) eurekaapi.Applications {
+	if len(f) == 0 {
+		return apps
+	}
+	filtered := make([]eurekaapi.Application, len(apps.Application))
+	for i, app := range apps.Application {
+		filtered[i] = f.application(app)
+	}
+	apps.Application = filtered
+	return apps
+}
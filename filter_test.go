@@ -0,0 +1,40 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestCacheAppliesInstanceFilters(t *testing.T) {
+	zoned := testInstance("foo-1", StatusUp, 8080, 0)
+	zoned.Metadata = eurekaapi.NewMetadata(map[string]string{"zone": "a"})
+	starting := testInstance("foo-2", StatusStarting, 8080, 0)
+	starting.Metadata = eurekaapi.NewMetadata(map[string]string{"zone": "a"})
+	unzoned := testInstance("foo-3", StatusUp, 8080, 0)
+
+	apps := eurekaapi.Applications{Application: []eurekaapi.Application{
+		{Name: "FOO", Instance: []eurekaapi.Instance{zoned, starting, unzoned}},
+	}}
+	cache := newStaticCache(apps.Application...)
+	cache.filters = instanceFilters{ExcludeStatus(StatusStarting), RequireMetadata("zone")}
+	cache.fetchDelta = func(ctx context.Context) (eurekaapi.Applications, error) {
+		return eurekaapi.Applications{AppsHashCode: apps.ReconcileHashCode()}, nil
+	}
+
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	app, _ := cache.Application("FOO")
+	if len(app.Instance) != 1 || app.Instance[0].InstanceID != "foo-1" {
+		t.Errorf("cached instances = %+v; want only foo-1", app.Instance)
+	}
+	if len(apps.Application[0].Instance) != 3 {
+		t.Errorf("filtering modified the fetched registry")
+	}
+	// Filtering must not make the cache look inconsistent with the server.
+	if ok, err := cache.VerifyConsistency(context.Background()); err != nil || !ok {
+		t.Errorf("VerifyConsistency() = %t, %v; want true", ok, err)
+	}
+}
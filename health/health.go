@@ -0,0 +1,116 @@
+// Package health provides health checkers whose results drive the status an
+// instance publishes to Eureka.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Checker reports whether a dependency is healthy. A nil error means healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// HTTPChecker issues a GET request and compares the response status.
+type HTTPChecker struct {
+	URL string
+	// ExpectedStatus is the status code considered healthy. Zero accepts any
+	// 2xx response.
+	ExpectedStatus int
+	Timeout        time.Duration
+	// Client is used to send the request. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (h *HTTPChecker) Check(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request to %s failed: %w", h.URL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if h.ExpectedStatus != 0 {
+		if resp.StatusCode != h.ExpectedStatus {
+			return fmt.Errorf("unexpected health check status %d, want %d", resp.StatusCode, h.ExpectedStatus)
+		}
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected health check status: %s", resp.Status)
+	}
+	return nil
+}
+
+// TCPChecker considers a target healthy if a TCP connection can be opened.
+type TCPChecker struct {
+	Address string
+	Timeout time.Duration
+}
+
+func (t *TCPChecker) Check(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", t.Address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", t.Address, err)
+	}
+	return conn.Close()
+}
+
+// ExecChecker runs a command and considers a zero exit status healthy.
+type ExecChecker struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+}
+
+func (e *ExecChecker) Check(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	if err := exec.CommandContext(ctx, e.Command, e.Args...).Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("health check command %s exited with status %d", e.Command, exitErr.ExitCode())
+		}
+		return fmt.Errorf("failed to run health check command %s: %w", e.Command, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThreshold(t *testing.T) {
+	failure := errors.New("unhealthy")
+	th := NewThreshold(true, 2, 3)
+
+	steps := []struct {
+		err             error
+		expectedHealthy bool
+		expectedChanged bool
+	}{
+		{failure, true, false},
+		{failure, true, false},
+		{failure, false, true},
+		{nil, false, false},
+		{failure, false, false},
+		{nil, false, false},
+		{nil, true, true},
+	}
+
+	for i, step := range steps {
+		healthy, changed := th.Observe(step.err)
+		if healthy != step.expectedHealthy || changed != step.expectedChanged {
+			t.Errorf("step %d: Observe(%v) = (%t, %t); want (%t, %t)", i, step.err, healthy, changed, step.expectedHealthy, step.expectedChanged)
+		}
+	}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	if err := (&HTTPChecker{URL: server.URL}).Check(context.Background()); err == nil {
+		t.Errorf("expected non-2xx status to be unhealthy")
+	}
+	if err := (&HTTPChecker{URL: server.URL, ExpectedStatus: http.StatusTeapot}).Check(context.Background()); err != nil {
+		t.Errorf("expected status %d to be healthy: %v", http.StatusTeapot, err)
+	}
+}
+
+func TestTCPChecker(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	if err := (&TCPChecker{Address: addr}).Check(context.Background()); err != nil {
+		t.Errorf("expected open port to be healthy: %v", err)
+	}
+	ln.Close()
+	if err := (&TCPChecker{Address: addr}).Check(context.Background()); err == nil {
+		t.Errorf("expected closed port to be unhealthy")
+	}
+}
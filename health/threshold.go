@@ -0,0 +1,48 @@
+package health
+
+// Threshold debounces check results: the state only flips after the given
+// number of consecutive results pointing the other way. Thresholds below one
+// are treated as one.
+type Threshold struct {
+	HealthyThreshold   int
+	UnhealthyThreshold int
+
+	healthy   bool
+	successes int
+	failures  int
+}
+
+// NewThreshold returns a Threshold starting in the given state.
+func NewThreshold(healthy bool, healthyThreshold, unhealthyThreshold int) *Threshold {
+	return &Threshold{
+		HealthyThreshold:   healthyThreshold,
+		UnhealthyThreshold: unhealthyThreshold,
+		healthy:            healthy,
+	}
+}
+
+// Observe records a check result and returns the resulting state and whether
+// it changed.
+func (t *Threshold) Observe(err error) (healthy bool, changed bool) {
+	if err == nil {
+		t.successes++
+		t.failures = 0
+		if !t.healthy && t.successes >= max(t.HealthyThreshold, 1) {
+			t.healthy = true
+			return true, true
+		}
+		return t.healthy, false
+	}
+
+	t.failures++
+	t.successes = 0
+	if t.healthy && t.failures >= max(t.UnhealthyThreshold, 1) {
+		t.healthy = false
+		return false, true
+	}
+	return t.healthy, false
+}
+
+func (t *Threshold) Healthy() bool {
+	return t.healthy
+}
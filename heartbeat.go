@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// minHeartbeatInterval is the shortest interval derived from a TTL, so that
+// tiny TTLs don't make the client hammer the server.
+const minHeartbeatInterval = time.Second
+
+// ErrInstanceNotFound is returned by Heartbeat when the server no longer
+// knows about the instance, typically because its lease expired.
+var ErrInstanceNotFound = errors.New("instance does not exist")
+
+// ErrDirtyTimestampConflict is returned by Renew when the server rejected a
+// heartbeat because it has a newer lastDirtyTimestamp for the instance. The
+// lease was not renewed; the next heartbeat is sent with a fresh timestamp.
+var ErrDirtyTimestampConflict = eurekaapi.ErrDirtyTimestampConflict
+
+// HeartbeatResult describes the server's answer to a heartbeat.
+type HeartbeatResult = eurekaapi.HeartbeatResult
+
+// State describes the client's registration as seen by the heartbeat loop.
+type State int32
+
+const (
+	StateUnregistered State = iota
+	StateRegistered
+	StateDegraded
+)
+
+func (s State) String() string {
+	switch s {
+	case StateUnregistered:
+		return "UNREGISTERED"
+	case StateRegistered:
+		return "REGISTERED"
+	case StateDegraded:
+		return "DEGRADED"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
+}
+
+// HeartbeatPolicy controls how the heartbeat loop reacts to failures.
+type HeartbeatPolicy struct {
+	// MaxConsecutiveFailures is the number of failed heartbeats tolerated
+	// before the client is considered DEGRADED. Zero fails fast on the first
+	// failure.
+	MaxConsecutiveFailures int
+	// ReRegister re-registers the instance once DEGRADED, or immediately when
+	// the server reports the instance as unknown.
+	ReRegister bool
+	// OnDegraded, if set, is called when the client transitions to DEGRADED.
+	OnDegraded func(err error)
+}
+
+// DefaultHeartbeatPolicy tolerates a couple of transient failures before
+// re-registering.
+var DefaultHeartbeatPolicy = HeartbeatPolicy{
+	MaxConsecutiveFailures: 3,
+	ReRegister:             true,
+}
+
+func (c *Client) State() State {
+	return State(c.state.Load())
+}
+
+func (c *Client) setState(s State) State {
+	prev := State(c.state.Swap(int32(s)))
+	if prev != s {
+		c.events.publish(Event{Type: EventStateChanged, State: s, Detail: prev.String()})
+	}
+	return prev
+}
+
+// heartbeatInterval derives a heartbeat interval from a lease TTL in
+// seconds: a third of it, so that the lease survives two missed heartbeats.
+func heartbeatInterval(ttl uint) time.Duration {
+	return max(time.Duration(ttl)*time.Second/3, minHeartbeatInterval)
+}
+
+// registeredTTL returns the TTL the instance was last registered with.
+func (c *Client) registeredTTL() (uint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registration == nil || c.registration.LeaseInfo == nil || c.registration.LeaseInfo.EvictionDurationInSecs == 0 {
+		return 0, false
+	}
+	return c.registration.LeaseInfo.EvictionDurationInSecs, true
+}
+
+// RunHeartbeat sends a heartbeat immediately and then every interval until
+// ctx is cancelled, applying the configured HeartbeatPolicy to failures. If
+// interval is zero, it is derived from the TTL the instance was registered
+// with. An interval longer than that TTL would let the lease lapse between
+// heartbeats; it is reported as an EventWarning. An interval set by
+// Reconfigure takes precedence over the given one.
+func (c *Client) RunHeartbeat(ctx context.Context, interval time.Duration) error {
+	if d := time.Duration(c.heartbeatOverride.Load()); d > 0 {
+		interval = d
+	}
+	ttl, registered := c.registeredTTL()
+	switch {
+	case interval < 0:
+		return fmt.Errorf("heartbeat interval must not be negative, got %s", interval)
+	case interval == 0 && !registered:
+		return errors.New("heartbeat interval must be given until the instance has been registered with a TTL")
+	case interval == 0:
+		interval = heartbeatInterval(ttl)
+	case registered && interval > time.Duration(ttl)*time.Second:
+		c.events.publish(Event{
+			Type:   EventWarning,
+			Detail: fmt.Sprintf("heartbeat interval of %s is longer than the TTL of %ds: the lease will expire between heartbeats", interval, ttl),
+		})
+	}
+
+	c.renewals.setInterval(interval)
+	defer c.renewals.setInterval(0)
+	ticker := c.clock.NewTicker(interval)
+	defer func() { ticker.Stop() }()
+
+	failures := 0
+	for {
+		if !c.heartbeats.await(ctx) {
+			return ctx.Err()
+		}
+		// give each heartbeat its own deadline
+		hbCtx, cancel := context.WithTimeout(ctx, interval/2)
+		err := c.Heartbeat(hbCtx)
+		cancel()
+		failures = c.handleHeartbeatResult(ctx, err, failures)
+
+		for due := false; !due; {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.heartbeatRescheduled:
+				// Reconfigured; the next heartbeat is due one new interval
+				// from now.
+				interval = time.Duration(c.heartbeatOverride.Load())
+				ticker.Stop()
+				ticker = c.clock.NewTicker(interval)
+				c.renewals.setInterval(interval)
+			case <-ticker.C():
+				due = true
+			}
+		}
+	}
+}
+
+// handleHeartbeatResult applies the heartbeat policy and returns the updated
+// count of consecutive failures.
+func (c *Client) handleHeartbeatResult(ctx context.Context, err error, failures int) int {
+	if c.State() == StateUnregistered {
+		// Unregistered while heartbeats kept running, e.g. after LameDuck:
+		// the lease is meant to be gone and must not be restored.
+		return failures
+	}
+	if err == nil {
+		c.setState(StateRegistered)
+		return 0
+	}
+	if ctx.Err() != nil {
+		// Shutting down; a cancelled heartbeat says nothing about the lease.
+		return failures
+	}
+
+	failures++
+	notFound := errors.Is(err, ErrInstanceNotFound)
+	if failures <= c.heartbeatPolicy.MaxConsecutiveFailures && !notFound {
+		return failures
+	}
+
+	if prev := c.setState(StateDegraded); prev != StateDegraded && c.heartbeatPolicy.OnDegraded != nil {
+		c.heartbeatPolicy.OnDegraded(err)
+	}
+	if !c.heartbeatPolicy.ReRegister {
+		return failures
+	}
+	if err := c.reRegister(ctx); err != nil {
+		return failures
+	}
+	c.setState(StateRegistered)
+	return 0
+}
+
+// errUnregistered is the result of a re-registration asked for after the
+// instance was unregistered.
+var errUnregistered = errors.New("instance has been unregistered")
+
+// reRegisterTimeout bounds a re-registration, which doesn't end with the
+// heartbeat that asked for it.
+const reRegisterTimeout = 10 * time.Second
+
+// reRegisterCall is a re-registration shared by every caller that asked for
+// one while it was in flight or shortly after.
+type reRegisterCall struct {
+	done chan struct{}
+	err  error
+	// finished is when the call completed, zero while it is in flight;
+	// guarded by reRegisterMu.
+	finished time.Time
+}
+
+// reRegister posts the most recently registered payload again, once for all
+// heartbeat loops that found the lease gone around the same time: callers
+// that find a re-registration in flight, or one that completed within the
+// last heartbeat interval, take over its result instead of sending their own,
+// and publish EventReRegistrationShared. The registration is detached from
+// ctx, so that a caller giving up doesn't fail it for the others.
+func (c *Client) reRegister(ctx context.Context) error {
+	c.reRegisterMu.Lock()
+	call := c.reRegistering
+	shared := call != nil && (call.finished.IsZero() || c.clock.Since(call.finished) < c.reRegisterWindow())
+	if !shared {
+		call = &reRegisterCall{done: make(chan struct{})}
+		c.reRegistering = call
+		go c.runReRegister(context.WithoutCancel(ctx), call)
+	}
+	c.reRegisterMu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if shared {
+		c.events.publish(Event{Type: EventReRegistrationShared, Detail: c.instanceID, Err: call.err})
+	}
+	return call.err
+}
+
+func (c *Client) runReRegister(ctx context.Context, call *reRegisterCall) {
+	defer c.safetyNet()
+	ctx, cancel := context.WithTimeout(ctx, reRegisterTimeout)
+	defer cancel()
+	err := errUnregistered
+	if c.State() != StateUnregistered {
+		err = c.postRegistration(ctx)
+	}
+
+	c.reRegisterMu.Lock()
+	call.err, call.finished = err, c.clock.Now()
+	c.reRegisterMu.Unlock()
+	close(call.done)
+}
+
+// awaitReRegistration waits for a re-registration in flight, if any, to
+// finish, or for ctx to end.
+func (c *Client) awaitReRegistration(ctx context.Context) {
+	c.reRegisterMu.Lock()
+	call := c.reRegistering
+	c.reRegisterMu.Unlock()
+	if call == nil {
+		return
+	}
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+	}
+}
+
+// reRegisterWindow is how long the result of a re-registration is handed to
+// heartbeats that found the lease gone: one heartbeat interval, as those
+// were most likely sent before it.
+func (c *Client) reRegisterWindow() time.Duration {
+	return max(c.renewals.currentInterval(), minHeartbeatInterval)
+}
+
+// postRegistration posts the most recently registered payload again.
+func (c *Client) postRegistration(ctx context.Context) error {
+	c.mu.Lock()
+	if c.registration == nil {
+		c.mu.Unlock()
+		return errors.New("instance has not been registered yet")
+	}
+	c.stamp(c.registration)
+	instance := *c.registration
+	c.mu.Unlock()
+
+	err := c.eurekaAPIClient.RegisterInstance(ctx, c.appID, &instance)
+	c.history.record(ActionReRegistered, c.instanceID, err)
+	if err != nil {
+		return fmt.Errorf("failed to re-register instance: %w", err)
+	}
+	return nil
+}
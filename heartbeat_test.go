@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestHeartbeatPolicyToleratesFailures(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	var degraded []error
+	client := newTestClient(t, f, WithHeartbeatPolicy(HeartbeatPolicy{
+		MaxConsecutiveFailures: 2,
+		ReRegister:             true,
+		OnDegraded:             func(err error) { degraded = append(degraded, err) },
+	}))
+	ctx := context.Background()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	failures := 0
+	for i := 0; i < 2; i++ {
+		failures = client.handleHeartbeatResult(ctx, client.Heartbeat(ctx), failures)
+		if client.State() != StateRegistered {
+			t.Fatalf("state after %d failures = %s; want REGISTERED", failures, client.State())
+		}
+	}
+
+	failures = client.handleHeartbeatResult(ctx, client.Heartbeat(ctx), failures)
+	if len(degraded) != 1 {
+		t.Fatalf("OnDegraded called %d times; want 1", len(degraded))
+	}
+	if failures != 0 {
+		t.Errorf("failures after re-registration = %d; want 0", failures)
+	}
+	if got := f.count(http.MethodPost); got != 2 {
+		t.Errorf("server received %d registrations; want 2", got)
+	}
+}
+
+func TestHeartbeatNotFoundReRegistersImmediately(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	client := newTestClient(t, f)
+	ctx := context.Background()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	err := client.Heartbeat(ctx)
+	if !errors.Is(err, ErrInstanceNotFound) {
+		t.Fatalf("Heartbeat error = %v; want ErrInstanceNotFound", err)
+	}
+	client.handleHeartbeatResult(ctx, err, 0)
+	if got := f.count(http.MethodPost); got != 2 {
+		t.Errorf("server received %d registrations; want 2", got)
+	}
+}
+
+func TestRunHeartbeatDerivesIntervalFromTTL(t *testing.T) {
+	f := newFakeEureka(t)
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	events := client.Events()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.RunHeartbeat(ctx, 0); err == nil {
+		t.Fatalf("RunHeartbeat without interval before registering returned nil")
+	}
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	// run runs the heartbeat loop until it waits for its ticker and returns
+	// the renewals it expects per minute by then.
+	run := func(interval time.Duration) float64 {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = client.RunHeartbeat(runCtx, interval)
+		}()
+		clk.BlockUntil(1)
+		expected := client.RenewalStats().ExpectedPerMinute
+		cancel()
+		<-done
+		return expected
+	}
+
+	if got := run(0); got != 6 {
+		t.Errorf("ExpectedPerMinute with derived interval = %v; want 6", got)
+	}
+
+	run(time.Minute)
+	for {
+		select {
+		case e := <-events:
+			if e.Type != EventWarning {
+				continue
+			}
+			if !strings.Contains(e.Detail, "longer than the TTL") {
+				t.Errorf("warning = %q; want it to mention the TTL", e.Detail)
+			}
+		default:
+			t.Fatalf("no warning for a heartbeat interval longer than the TTL")
+		}
+		break
+	}
+}
+
+func TestConcurrentNotFoundHeartbeatsReRegisterOnce(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client := newTestClient(t, f)
+	events := client.Events()
+	ctx := context.Background()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	notFound := client.Heartbeat(ctx)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	f.handle(http.MethodPost, func(w http.ResponseWriter, _ *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	const loops = 4
+	var wg sync.WaitGroup
+	wg.Add(loops)
+	go func() {
+		defer wg.Done()
+		client.handleHeartbeatResult(ctx, notFound, 0)
+	}()
+	<-started
+	for range loops - 1 {
+		go func() {
+			defer wg.Done()
+			client.handleHeartbeatResult(ctx, notFound, 0)
+		}()
+	}
+	// Give the other loops time to find the re-registration in flight.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := f.count(http.MethodPost); got != 2 {
+		t.Errorf("server received %d registrations; want the initial one and a single re-registration", got)
+	}
+	shared := 0
+	for len(events) > 0 {
+		if e := <-events; e.Type == EventReRegistrationShared {
+			shared++
+		}
+	}
+	if shared != loops-1 {
+		t.Errorf("%d EventReRegistrationShared; want %d", shared, loops-1)
+	}
+}
+
+func TestHeartbeatConflictIsNotARenewal(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	client := newTestClient(t, f)
+	ctx := context.Background()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if _, err := client.Renew(ctx); !errors.Is(err, ErrDirtyTimestampConflict) {
+		t.Fatalf("Renew returned %v; want ErrDirtyTimestampConflict", err)
+	}
+	select {
+	case <-client.Registered():
+		t.Error("Registered() closed by a rejected heartbeat")
+	default:
+	}
+	if stats := client.RenewalStats(); stats.Renewals != 0 || stats.Failures != 1 {
+		t.Errorf("renewal stats = %+v; want the heartbeat counted as a failure", stats)
+	}
+}
+
+func TestNotFoundHeartbeatsShortlyAfterAReRegistrationShareIt(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	clk := clock.NewManual(time.Unix(1_700_000_000, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	ctx := context.Background()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	notFound := client.Heartbeat(ctx)
+
+	client.handleHeartbeatResult(ctx, notFound, 0)
+	// A heartbeat sent before the re-registration reports the 404 late.
+	client.handleHeartbeatResult(ctx, notFound, 0)
+	if got := f.count(http.MethodPost); got != 2 {
+		t.Fatalf("server received %d registrations; want the late 404 to share the re-registration", got)
+	}
+
+	clk.Advance(minHeartbeatInterval)
+	client.handleHeartbeatResult(ctx, notFound, 0)
+	if got := f.count(http.MethodPost); got != 3 {
+		t.Errorf("server received %d registrations; want a new re-registration once the window passed", got)
+	}
+}
+
+func TestReRegistrationOutlivesTheCallerThatStartedIt(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	f.handle(http.MethodPost, func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() { leader <- client.reRegister(leaderCtx) }()
+	<-started
+	waiter := make(chan error, 1)
+	go func() { waiter <- client.reRegister(context.Background()) }()
+
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller got %v; want context.Canceled", err)
+	}
+	close(release)
+	if err := <-waiter; err != nil {
+		t.Errorf("waiting caller got %v; want the re-registration to succeed", err)
+	}
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	hedgeWindowSize = 100
+	hedgeMinSamples = 10
+)
+
+// Hedging configures hedged requests in Transport. Zero fields take their
+// defaults.
+type Hedging struct {
+	// Percentile of recently observed latencies, between 0 and 1, after which
+	// a second request is sent. Defaults to 0.95.
+	Percentile float64
+	// MinDelay and MaxDelay bound the hedging delay. MaxDelay is also used
+	// until enough latencies have been observed. They default to 5
+	// milliseconds and 1 second.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// WithHedging hedges idempotent GET and HEAD requests: if the first instance
+// hasn't answered within the configured latency percentile, the request is
+// also sent to another instance and whichever response arrives first is used.
+func WithHedging(cfg Hedging) TransportOption {
+	if cfg.Percentile <= 0 || cfg.Percentile > 1 {
+		cfg.Percentile = 0.95
+	}
+	if cfg.MinDelay <= 0 {
+		cfg.MinDelay = 5 * time.Millisecond
+	}
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = max(time.Second, cfg.MinDelay)
+	}
+	return func(t *Transport) {
+		t.hedging = &hedger{cfg: cfg, latencies: make(map[string]*latencyWindow)}
+	}
+}
+
+type hedger struct {
+	cfg Hedging
+
+	mu        sync.Mutex
+	latencies map[string]*latencyWindow // by application
+}
+
+// latencyWindow keeps the most recent latencies of an application.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+func (h *hedger) observe(app string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w, ok := h.latencies[app]
+	if !ok {
+		w = &latencyWindow{}
+		h.latencies[app] = w
+	}
+	if len(w.samples) < hedgeWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % hedgeWindowSize
+}
+
+func (h *hedger) delay(app string) time.Duration {
+	h.mu.Lock()
+	w, ok := h.latencies[app]
+	if !ok || len(w.samples) < hedgeMinSamples {
+		h.mu.Unlock()
+		return h.cfg.MaxDelay
+	}
+	samples := slices.Clone(w.samples)
+	h.mu.Unlock()
+
+	slices.Sort(samples)
+	i := int(math.Ceil(h.cfg.Percentile*float64(len(samples)))) - 1
+	return min(max(samples[max(i, 0)], h.cfg.MinDelay), h.cfg.MaxDelay)
+}
+
+func isHedgeable(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) &&
+		(req.Body == nil || req.Body == http.NoBody)
+}
+
+type hedgeResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+}
+
+// hedge sends req to one instance and, if it is slow to answer, to a second
+// one. The first response wins and the other attempt is cancelled.
+func (t *Transport) hedge(req *http.Request, app string) (*http.Response, error) {
+	first, err := t.pick(req, app, "")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func(ep Endpoint) {
+		ctx, cancel := context.WithCancel(req.Context())
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := t.send(req.WithContext(ctx), app, ep)
+			results <- hedgeResult{attempt, resp, err}
+		}()
+	}
+	launch(first)
+	inflight := 1
+
+	timer := t.resolver.cache.clock.NewTimer(t.hedging.delay(app))
+	defer timer.Stop()
+
+	var lastErr error
+	for inflight > 0 {
+		select {
+		case <-timer.C():
+			if second, err := t.pick(req, app, first.InstanceID); err == nil {
+				launch(second)
+				inflight++
+			}
+		case res := <-results:
+			inflight--
+			if res.err != nil {
+				cancels[res.attempt]()
+				lastErr = res.err
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != res.attempt {
+					cancel()
+				}
+			}
+			// Collect the cancelled attempt in the background.
+			go func(pending int) {
+				for ; pending > 0; pending-- {
+					if loser := <-results; loser.resp != nil {
+						loser.resp.Body.Close()
+					}
+				}
+			}(inflight)
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.attempt]}
+			return res.resp, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// cancelOnClose releases the context of a winning hedged attempt once its
+// body has been consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
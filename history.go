@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+const defaultHistorySize = 100
+
+// Action identifies something the client did against Eureka.
+type Action string
+
+const (
+	ActionRegistered            Action = "REGISTERED"
+	ActionRegisterFailed        Action = "REGISTER_FAILED"
+	ActionReRegistered          Action = "RE_REGISTERED"
+	ActionHeartbeat             Action = "HEARTBEAT"
+	ActionHeartbeatFailed       Action = "HEARTBEAT_FAILED"
+	ActionStatusChanged         Action = "STATUS_CHANGED"
+	ActionStatusOverrideCleared Action = "STATUS_OVERRIDE_CLEARED"
+	ActionMetadataUpdated       Action = "METADATA_UPDATED"
+	ActionUnregistered          Action = "UNREGISTERED"
+)
+
+// HistoryEntry is a single recorded client action.
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Action Action    `json:"action"`
+	Detail string    `json:"detail,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// history is a fixed-size ring buffer of the most recent client actions.
+type history struct {
+	clock   clock.Clock
+	events  *eventBus
+	mu      sync.Mutex
+	entries []HistoryEntry
+	next    int
+	full    bool
+}
+
+func newHistory(size int, clk clock.Clock) *history {
+	if size <= 0 {
+		return &history{clock: clk}
+	}
+	return &history{clock: clk, entries: make([]HistoryEntry, size)}
+}
+
+func (h *history) record(action Action, detail string, err error) {
+	h.events.publish(Event{Type: EventAction, Action: action, Detail: detail, Err: err})
+	if len(h.entries) == 0 {
+		return
+	}
+	entry := HistoryEntry{Time: h.clock.Now(), Action: action, Detail: detail}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// snapshot returns the recorded entries, oldest first.
+func (h *history) snapshot() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]HistoryEntry(nil), h.entries[:h.next]...)
+	}
+	out := make([]HistoryEntry, 0, len(h.entries))
+	out = append(out, h.entries[h.next:]...)
+	return append(out, h.entries[:h.next]...)
+}
+
+// History returns the most recent client actions, oldest first, for incident
+// forensics. Its size is bounded by WithHistorySize.
+func (c *Client) History() []HistoryEntry {
+	return c.history.snapshot()
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestHistoryIsBounded(t *testing.T) {
+	h := newHistory(3, clock.Real())
+	for i := 0; i < 5; i++ {
+		h.record(ActionHeartbeat, fmt.Sprint(i), nil)
+	}
+
+	entries := h.snapshot()
+	if len(entries) != 3 {
+		t.Fatalf("history has %d entries; want 3", len(entries))
+	}
+	for i, entry := range entries {
+		if want := fmt.Sprint(i + 2); entry.Detail != want {
+			t.Errorf("entry %d detail = %q; want %q", i, entry.Detail, want)
+		}
+	}
+}
+
+func TestClientRecordsHistory(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	ctx := context.Background()
+
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if err := client.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+
+	entries := client.History()
+	expected := []Action{ActionRegistered, ActionHeartbeat}
+	if len(entries) != len(expected) {
+		t.Fatalf("history = %+v; want actions %v", entries, expected)
+	}
+	for i, action := range expected {
+		if entries[i].Action != action {
+			t.Errorf("entry %d action = %s; want %s", i, entries[i].Action, action)
+		}
+	}
+}
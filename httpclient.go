@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTPClientOption configures a client returned by HTTPClientFor.
+type HTTPClientOption func(*httpClientConfig)
+
+type httpClientConfig struct {
+	resolverOpts  []ResolverOption
+	transportOpts []TransportOption
+	timeout       time.Duration
+}
+
+// WithResolverOptions configures the resolver of the client, e.g. its
+// balancer.
+func WithResolverOptions(opts ...ResolverOption) HTTPClientOption {
+	return func(cfg *httpClientConfig) {
+		cfg.resolverOpts = append(cfg.resolverOpts, opts...)
+	}
+}
+
+// WithTransportOptions configures the transport of the client. They are
+// applied after the defaults, so WithInstanceRetry replaces the default
+// retry policy.
+func WithTransportOptions(opts ...TransportOption) HTTPClientOption {
+	return func(cfg *httpClientConfig) {
+		cfg.transportOpts = append(cfg.transportOpts, opts...)
+	}
+}
+
+// WithClientTimeout sets the Timeout of the client, which covers all retries
+// of a request. There is none by default.
+func WithClientTimeout(timeout time.Duration) HTTPClientOption {
+	return func(cfg *httpClientConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// HTTPClientFor returns an HTTP client that sends every request to an
+// instance of app, whatever host its URL names, so that it can be handed to
+// code expecting a plain *http.Client. It balances over the instances in the
+// registry cache and retries with DefaultInstanceRetry; use
+// WithTransportOptions to add e.g. circuit breaking or request metrics.
+func (c *Client) HTTPClientFor(app string, opts ...HTTPClientOption) *http.Client {
+	var cfg httpClientConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	transportOpts := append([]TransportOption{WithInstanceRetry(DefaultInstanceRetry)}, cfg.transportOpts...)
+	transport := NewTransport(c.Resolver(cfg.resolverOpts...), transportOpts...)
+	transport.app = app
+	return &http.Client{Transport: transport, Timeout: cfg.timeout}
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestHTTPClientForRetriesOnAnotherInstance(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer good.Close()
+
+	client := newTestClient(t, newFakeEureka(t))
+	client.cache = newStaticCache(eurekaapi.Application{Name: "INVENTORY", Instance: []eurekaapi.Instance{
+		backendInstance(t, "bad", bad),
+		backendInstance(t, "good", good),
+	}})
+	var mu sync.Mutex
+	var observed []RequestMetrics
+	httpClient := client.HTTPClientFor("inventory", WithTransportOptions(WithRequestMetrics(func(m RequestMetrics) {
+		mu.Lock()
+		observed = append(observed, m)
+		mu.Unlock()
+	})))
+
+	for i := 0; i < 2; i++ {
+		// The host is ignored; every request goes to INVENTORY.
+		resp, err := httpClient.Get("http://legacy-host/items")
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusTeapot {
+			t.Errorf("request %d got status %d; want 418 from the healthy instance", i, resp.StatusCode)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var failed int
+	for _, m := range observed {
+		if m.App != "inventory" || m.Method != http.MethodGet {
+			t.Errorf("observed %+v; want a GET to inventory", m)
+		}
+		if m.StatusCode == http.StatusBadGateway {
+			failed++
+			if m.InstanceID != "bad" {
+				t.Errorf("502 reported for instance %s; want bad", m.InstanceID)
+			}
+		}
+	}
+	if failed == 0 {
+		t.Errorf("no failed attempt observed in %+v", observed)
+	}
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithInstanceID sets the ID the instance registers with, instead of one
+// derived from the host, application and port.
+func WithInstanceID(id string) Option {
+	return func(c *Client) {
+		if id != "" {
+			c.instanceID = id
+		}
+	}
+}
+
+// WithInstanceIDFile persists the instance ID in the file at path. If the
+// file holds an ID, e.g. from before a restart, the client reuses it, and so
+// renews the same lease instead of leaving the old instance in the registry
+// until it is evicted. This matters where the host name changes on every
+// restart, as in many container platforms, and with long eviction durations.
+// Otherwise the client's ID is written to the file.
+func WithInstanceIDFile(path string) Option {
+	return func(c *Client) {
+		c.instanceIDFile = path
+	}
+}
+
+// persistInstanceID returns the ID stored in the file at path, or stores id
+// there if the file doesn't exist or is empty.
+func persistInstanceID(path, id string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if stored := strings.TrimSpace(string(data)); stored != "" {
+		return stored, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	// Write to a temporary file first, so a crash can't leave a truncated ID
+	// behind.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(id + "\n"); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstanceIDFileIsReusedAcrossRestarts(t *testing.T) {
+	f := newFakeEureka(t)
+	path := filepath.Join(t.TempDir(), "state", "instance-id")
+
+	first := newTestClient(t, f, WithInstanceIDFile(path))
+	if got, want := first.InstanceID(), "127.0.0.1:test-app:8080"; got != want {
+		t.Fatalf("InstanceID() = %q; want %q", got, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != first.InstanceID()+"\n" {
+		t.Fatalf("instance ID file = %q, %v; want the generated ID", data, err)
+	}
+
+	// After a restart on another host, the stored ID wins over both the
+	// generated and an explicit one.
+	api, err := NewClient([]string{f.URL}, "test-app", "10.0.0.7", 8080, WithInstanceID("explicit"), WithInstanceIDFile(path))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if got := api.InstanceID(); got != first.InstanceID() {
+		t.Errorf("InstanceID() after restart = %q; want %q", got, first.InstanceID())
+	}
+
+	if got := newTestClient(t, f, WithInstanceID("explicit")).InstanceID(); got != "explicit" {
+		t.Errorf("InstanceID() with WithInstanceID = %q; want explicit", got)
+	}
+}
@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// InstancerEvent is what an Instancer publishes: the addresses of the UP
+// instances of an application as host:port, or the error of the latest
+// registry refresh along with the addresses known before it.
+type InstancerEvent struct {
+	Instances []string
+	Err       error
+}
+
+// SDEvent is satisfied by struct types shaped like InstancerEvent, such as
+// go-kit's sd.Event, so that an Instancer can publish them directly.
+type SDEvent interface {
+	~struct {
+		Instances []string
+		Err       error
+	}
+}
+
+// Instancer publishes the instances of an application from the registry
+// cache as events of type E. It implements go-kit's sd.Instancer when E is
+// sd.Event, so it can be handed to sd.NewEndpointer as is:
+//
+//	instancer := pkg.NewInstancer[sd.Event](client, "inventory")
+//	endpointer := sd.NewEndpointer(instancer, factory, logger)
+//
+// The events follow the refreshes of the cache, which must be running.
+type Instancer[E SDEvent] struct {
+	app      string
+	resolver *Resolver
+	unwatch  func()
+
+	mu          sync.Mutex
+	state       InstancerEvent
+	subscribers map[chan<- E]struct{}
+}
+
+// NewInstancer returns an Instancer for app, whose endpoints are resolved
+// with opts. Call Stop once it is no longer needed.
+func NewInstancer[E SDEvent](client ClientAPI, app string, opts ...ResolverOption) *Instancer[E] {
+	i := &Instancer[E]{
+		app:         app,
+		resolver:    client.Resolver(opts...),
+		subscribers: make(map[chan<- E]struct{}),
+	}
+	if _, err := i.resolver.cache.Applications(); err == nil {
+		i.update(nil)
+	}
+	i.unwatch = i.resolver.cache.watch(i.update)
+	return i
+}
+
+// Register sends the current instances to ch, and every change from then on
+// until Deregister is called. Sends block, and with them the refreshes of
+// the cache, so ch must be read promptly.
+func (i *Instancer[E]) Register(ch chan<- E) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.subscribers[ch] = struct{}{}
+	ch <- E(i.state)
+}
+
+// Deregister stops sending events to ch.
+func (i *Instancer[E]) Deregister(ch chan<- E) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.subscribers, ch)
+}
+
+// Stop stops following the cache. Registered channels receive no further
+// events.
+func (i *Instancer[E]) Stop() {
+	i.unwatch()
+}
+
+// update publishes the instances after a refresh of the cache that failed
+// with err, if any.
+func (i *Instancer[E]) update(err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	next := InstancerEvent{Instances: i.state.Instances, Err: err}
+	if err == nil {
+		// The cache has just been populated, so this doesn't refresh it.
+		endpoints, err := i.resolver.Endpoints(context.Background(), i.app)
+		if err != nil && !errors.Is(err, ErrNoInstances) {
+			next.Err = err
+		} else {
+			next.Instances = make([]string, 0, len(endpoints))
+			for _, ep := range endpoints {
+				next.Instances = append(next.Instances, ep.URL().Host)
+			}
+			slices.Sort(next.Instances)
+		}
+	}
+	if slices.Equal(next.Instances, i.state.Instances) && next.Err == i.state.Err {
+		return
+	}
+	i.state = next
+	for ch := range i.subscribers {
+		ch <- E(next)
+	}
+}
@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// kitEvent and kitInstancer mirror go-kit's sd.Event and sd.Instancer.
+type kitEvent struct {
+	Instances []string
+	Err       error
+}
+
+type kitInstancer interface {
+	Register(chan<- kitEvent)
+	Deregister(chan<- kitEvent)
+	Stop()
+}
+
+func TestInstancerPublishesInstances(t *testing.T) {
+	f := newFakeEureka(t)
+	ips := []string{"10.0.0.1"}
+	fail := false
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `<applications><application><name>INVENTORY</name>`)
+		for i, ip := range ips {
+			fmt.Fprintf(w, `<instance><instanceId>inv-%d</instanceId><ipAddr>%s</ipAddr><status>UP</status>`+
+				`<port enabled="true">8080</port></instance>`, i, ip)
+		}
+		fmt.Fprint(w, `</application></applications>`)
+	})
+	client := newTestClient(t, f)
+	// Failed refreshes are expected too; the instancer reports them.
+	refresh := func() { _ = client.Cache().Refresh(context.Background()) }
+	refresh()
+
+	var instancer kitInstancer = NewInstancer[kitEvent](client, "inventory")
+	defer instancer.Stop()
+	events := make(chan kitEvent, 4)
+	instancer.Register(events)
+	if e := <-events; !slices.Equal(e.Instances, []string{"10.0.0.1:8080"}) || e.Err != nil {
+		t.Fatalf("initial event = %+v; want the cached instance", e)
+	}
+
+	ips = append(ips, "10.0.0.2")
+	refresh()
+	if e := <-events; !slices.Equal(e.Instances, []string{"10.0.0.1:8080", "10.0.0.2:8080"}) {
+		t.Errorf("event = %+v; want both instances", e)
+	}
+
+	// A failed refresh reports the error along with the last known instances.
+	fail = true
+	refresh()
+	if e := <-events; e.Err == nil || len(e.Instances) != 2 {
+		t.Errorf("event = %+v; want the error and the last known instances", e)
+	}
+
+	instancer.Deregister(events)
+	fail = false
+	refresh()
+	if len(events) != 0 {
+		t.Errorf("deregistered channel received %+v", <-events)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
@@ -23,10 +22,16 @@ type Env struct {
 	EurekaURL   string `env:"EUREKA_CLIENT_SERVICE_URL_DEFAULTZONE"`
 	AppID       string `env:"APP_ID" default:"go-client"`
 	Port        int    `env:"PORT" default:"8080"`
+	// SpringCompat registers through the Spring Cloud Netflix workarounds,
+	// so that they are validated against the server under test.
+	SpringCompat bool `env:"SPRING_COMPAT"`
+	// RefreshInterval, if set, keeps the registry cache refreshed and serves
+	// the client's debug handler, for the soak test to check convergence.
+	RefreshInterval time.Duration `env:"REFRESH_INTERVAL"`
 }
 
 func main() {
-    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	var cfg Env
@@ -34,86 +39,49 @@ func main() {
 		log.Fatalf("Failed to parse environment variables: %v", err)
 	}
 
-	eurekaClient, err := lib.NewClient([]string{cfg.EurekaURL}, cfg.AppID, cfg.ContainerIP, cfg.Port)
+	var opts []lib.Option
+	if cfg.SpringCompat {
+		opts = append(opts, lib.WithSpringCompat())
+	}
+	if cfg.RefreshInterval > 0 {
+		opts = append(opts, lib.WithRefreshInterval(cfg.RefreshInterval), lib.WithDeltaPolling(time.Second))
+	}
+	eurekaClient, err := lib.NewClient([]string{cfg.EurekaURL}, cfg.AppID, cfg.ContainerIP, cfg.Port, opts...)
 	if err != nil {
 		log.Fatalf("Failed to create Eureka client: %v", err)
 	} else {
 		log.Printf("Eureka client created successfully for app ID: %s", cfg.AppID)
 	}
 
-	instance, err := eurekaClient.RegisterInstance(context.Background(), net.ParseIP(cfg.ContainerIP), ttl, false)
-	if err != nil {
-		log.Fatalf("Failed to register instance: %v", err)
-	} else {
-		log.Printf("Instance registered successfully with ID: %s", instance.ID)
+	var debug http.Handler
+	if cfg.RefreshInterval > 0 {
+		debug = eurekaClient.DebugHandler()
+		go func() { _ = eurekaClient.Cache().Run(ctx) }()
 	}
 
-    var wg sync.WaitGroup
-    var server *http.Server
-
-    wg.Add(1)
-    go func() {
-        defer wg.Done()
-        periodicallySendHeartbeat(ctx, eurekaClient, time.Duration(ttl)*time.Second)
-    }()
-
-    wg.Add(1)
-    go func() {
-        defer wg.Done()
-        server = internal.NewServer(":8080")
-        if err := server.ListenAndServe(); err != nil {
-            log.Fatalf("failed to start server: %v", err)
-        }
-    }()
-
-    <- ctx.Done()
+	server := internal.NewServer(":8080", debug)
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			log.Fatalf("failed to start server: %v", err)
+		}
+	}()
+
+	errCh := eurekaClient.Run(ctx, lib.RunOptions{
+		IP:  net.ParseIP(cfg.ContainerIP),
+		TTL: ttl,
+	})
+	for err := range errCh {
+		log.Printf("Eureka lifecycle error: %v", err)
+	}
 
-    log.Println("Shutdown signal received, starting graceful shutdown...")
+	log.Println("Eureka client stopped, starting graceful shutdown...")
 
-    shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-    wg.Wait()
-
-    if err := eurekaClient.UnregisterInstance(shutdownCtx); err != nil {
-        log.Printf("failed to unregister Eureka instance: %v", err)
-    } else {
-        log.Printf("Instance unregistered: %s", cfg.AppID)
-    }
-
-    if err := server.Shutdown(shutdownCtx); err != nil {
-        log.Printf("failed to shut down server: %v", err)
-    }
-
-    log.Println("Shutdown complete.")
-}
-
-func periodicallySendHeartbeat(ctx context.Context, client lib.ClientAPI, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	// optional: send one immediately
-	send := func() {
-		// give each request its own deadline
-		hbCtx, cancel := context.WithTimeout(ctx, interval/2)
-		defer cancel()
-
-		if err := client.Heartbeat(hbCtx); err != nil {
-			log.Printf("Failed to send heartbeat: %v", err)
-			return
-		}
-		log.Printf("Heartbeat sent for instance ID: %s", client.InstanceID())
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down server: %v", err)
 	}
 
-	send()
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("stopping heartbeat loop: %v", ctx.Err())
-			return
-		case <-ticker.C:
-			send()
-		}
-	}
+	log.Println("Shutdown complete.")
 }
@@ -0,0 +1,192 @@
+// Command soak drives a long-running scenario against the dockerized Eureka
+// started with the soak compose profile. Each round restarts the Eureka
+// server and partitions the soak client from it with toxiproxy, and asserts
+// that the client registers again and that its registry cache converges with
+// the server's.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	lib "github.com/cassis163/eureka-go-client"
+)
+
+type config struct {
+	eurekaURL    string
+	toxiproxyURL string
+	clientURL    string
+	composeFile  string
+	appID        string
+	proxy        string
+	rounds       int
+	partition    time.Duration
+	timeout      time.Duration
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.eurekaURL, "eureka", "http://localhost:8761/eureka", "Eureka service URL, not proxied")
+	flag.StringVar(&cfg.toxiproxyURL, "toxiproxy", "http://localhost:8474", "toxiproxy API URL")
+	flag.StringVar(&cfg.clientURL, "client", "http://localhost:8083", "base URL of the soak client")
+	flag.StringVar(&cfg.composeFile, "compose-file", "../docker-compose.yml", "compose file defining the eureka service")
+	flag.StringVar(&cfg.appID, "app", "SOAK-CLIENT", "application ID of the soak client")
+	flag.StringVar(&cfg.proxy, "proxy", "eureka", "name of the toxiproxy proxy in front of Eureka")
+	flag.IntVar(&cfg.rounds, "rounds", 3, "number of restart and partition rounds")
+	flag.DurationVar(&cfg.partition, "partition", 20*time.Second, "how long each partition lasts; longer than the lease to get the client evicted")
+	flag.DurationVar(&cfg.timeout, "timeout", 90*time.Second, "how long to wait for the client to recover")
+	flag.Parse()
+
+	if err := run(context.Background(), cfg); err != nil {
+		log.Printf("soak test failed: %v", err)
+		os.Exit(1)
+	}
+	log.Println("soak test passed")
+}
+
+func run(ctx context.Context, cfg config) error {
+	eureka, err := lib.NewClient([]string{cfg.eurekaURL}, "soak-runner", "localhost", 8080, lib.WithSpringCompat())
+	if err != nil {
+		return fmt.Errorf("failed to create Eureka client: %w", err)
+	}
+	s := &soak{cfg: cfg, eureka: eureka}
+
+	if err := s.awaitRecovery(ctx, "startup"); err != nil {
+		return err
+	}
+	for round := 1; round <= cfg.rounds; round++ {
+		log.Printf("round %d: restarting Eureka", round)
+		if err := s.compose(ctx, "restart", "eureka"); err != nil {
+			return err
+		}
+		if err := s.awaitRecovery(ctx, fmt.Sprintf("round %d restart", round)); err != nil {
+			return err
+		}
+
+		log.Printf("round %d: partitioning the client for %s", round, cfg.partition)
+		if err := s.setProxyEnabled(ctx, false); err != nil {
+			return err
+		}
+		time.Sleep(cfg.partition)
+		if err := s.setProxyEnabled(ctx, true); err != nil {
+			return err
+		}
+		if err := s.awaitRecovery(ctx, fmt.Sprintf("round %d partition", round)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type soak struct {
+	cfg    config
+	eureka lib.ClientAPI
+}
+
+// awaitRecovery waits until the soak client is registered UP and its cache
+// holds the registry the server currently has.
+func (s *soak) awaitRecovery(ctx context.Context, phase string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var last error
+	for {
+		if last = s.check(ctx); last == nil {
+			log.Printf("%s: client recovered after %s", phase, time.Since(start).Round(time.Second))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: client did not recover within %s: %w", phase, s.cfg.timeout, last)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *soak) check(ctx context.Context) error {
+	apps, err := s.eureka.GetAllApplications(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch registry: %w", err)
+	}
+	registered := false
+	for _, app := range apps.Application {
+		if !strings.EqualFold(app.Name, s.cfg.appID) {
+			continue
+		}
+		for _, inst := range app.Instance {
+			registered = registered || inst.Status == lib.StatusUp
+		}
+	}
+	if !registered {
+		return fmt.Errorf("%s is not registered UP", s.cfg.appID)
+	}
+
+	var cache struct {
+		HashCode string `json:"hashCode"`
+	}
+	if err := s.getJSON(ctx, s.cfg.clientURL+"/debug/cache", &cache); err != nil {
+		return fmt.Errorf("failed to read client cache: %w", err)
+	}
+	if cache.HashCode != apps.AppsHashCode {
+		return fmt.Errorf("client cache hashcode %q differs from the server's %q", cache.HashCode, apps.AppsHashCode)
+	}
+	return nil
+}
+
+func (s *soak) compose(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "docker", append([]string{"compose", "-f", s.cfg.composeFile}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker compose %s failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func (s *soak) setProxyEnabled(ctx context.Context, enabled bool) error {
+	body, err := json.Marshal(map[string]bool{"enabled": enabled})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.toxiproxyURL+"/proxies/"+s.cfg.proxy, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update proxy %s: %w", s.cfg.proxy, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to update proxy %s: %s", s.cfg.proxy, resp.Status)
+	}
+	return nil
+}
+
+func (s *soak) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -15,10 +15,14 @@ func healthCheckHandler(w http.ResponseWriter, _ *http.Request) {
 }
 
 // NewServer builds an *http.Server that inherits ctx as the base context for connections.
-func NewServer(addr string) *http.Server {
+// If debug is set, it is served under /debug/.
+func NewServer(addr string, debug http.Handler) *http.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/hello-world", helloWorldHandler)
 	mux.HandleFunc("/health", healthCheckHandler)
+	if debug != nil {
+		mux.Handle("/debug/", http.StripPrefix("/debug", debug))
+	}
 
 	return &http.Server{
 		Addr:              addr,
@@ -3,16 +3,22 @@
 package eurekaapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
 )
 
 const (
@@ -23,9 +29,32 @@ const (
 	UP                = "UP"
 	DOWN              = "DOWN"
 	STARTING          = "STARTING"
+	OUT_OF_SERVICE    = "OUT_OF_SERVICE"
+	UNKNOWN           = "UNKNOWN"
 	DefaultDataCenter = "MyOwn"
+	AmazonDataCenter  = "Amazon"
+	// AmazonInfoClass is the class the Java client decodes Amazon data
+	// center info with.
+	AmazonInfoClass = "com.netflix.appinfo.AmazonInfo"
+)
+
+// ActionType tells how an instance in a registry delta changed.
+type ActionType string
+
+const (
+	ADDED    ActionType = "ADDED"
+	MODIFIED ActionType = "MODIFIED"
+	DELETED  ActionType = "DELETED"
 )
 
+// ErrDirtyTimestampConflict is returned when the server rejects a heartbeat
+// because it holds a newer lastDirtyTimestamp for the instance.
+var ErrDirtyTimestampConflict = errors.New("server has a newer lastDirtyTimestamp for the instance")
+
+// ErrApplicationNotFound is returned when the registry has no application
+// with the requested name.
+var ErrApplicationNotFound = errors.New("application not found")
+
 type EurekaAPI interface {
     WrapTransport(wrap func(http.RoundTripper) http.RoundTripper)
     
@@ -33,12 +62,16 @@ type EurekaAPI interface {
 	RegisterInstance(ctx context.Context, appID string, inst *Instance) error
 	// De-register application instance: DELETE /apps/{appID}/{instanceID}
 	UnregisterInstance(ctx context.Context, appID, instanceID string) error
-	// Heartbeat: PUT /apps/{appID}/{instanceID}
-	Heartbeat(ctx context.Context, appID, instanceID string) (exists bool, err error)
+	// Heartbeat: PUT /apps/{appID}/{instanceID}?lastDirtyTimestamp={ts}
+	Heartbeat(ctx context.Context, appID, instanceID string, lastDirtyTimestamp int64) (HeartbeatResult, error)
 	// Query registry: GET /apps
 	GetAllApplications(ctx context.Context) (Applications, error)
+	// Query registry changes: GET /apps/delta
+	GetDelta(ctx context.Context) (Applications, error)
 	// Query app: GET /apps/{appID}
 	GetApplication(ctx context.Context, appID string) (Application, error)
+	// Query app by metadata: GET /apps/{appID}?metadata.{key}={value}
+	GetApplicationByMetadata(ctx context.Context, appID string, metadata map[string]string) (Application, error)
 	// Query app/instance: GET /apps/{appID}/{instanceID}
 	GetInstance(ctx context.Context, appID, instanceID string) (Instance, error)
 	// Query by vip/svip: GET /vips/{vip}, /svips/{svip}
@@ -49,14 +82,96 @@ type EurekaAPI interface {
 	ClearStatusOverride(ctx context.Context, appID, instanceID string, suggestedFallback string) error
 	// Update metadata: PUT /apps/{appID}/{instanceID}/metadata?key=value
 	UpdateMetadata(ctx context.Context, appID, instanceID string, kv map[string]string) error
+	// Clear metadata keys: PUT /apps/{appID}/{instanceID}/metadata?key=
+	DeleteMetadata(ctx context.Context, appID, instanceID string, keys ...string) error
+
+	// ClockSkew is the observed offset of the server's clock from ours.
+	ClockSkew() time.Duration
+
+	// Nodes reports the recent health of each configured base URL.
+	Nodes() []NodeStatus
+	// SetBaseURLs replaces the base URLs requests fail over between.
+	SetBaseURLs(baseURLs []string) error
+	// SetNodeConfigs replaces the credentials and headers of the nodes.
+	SetNodeConfigs(configs map[string]NodeConfig) error
+
+	// Do sends an arbitrary request relative to the base URL, for endpoints
+	// not covered by the typed API. The Accept header is negotiated per node
+	// like for the typed reads, and a body is sent as JSON if it looks like
+	// JSON, as XML otherwise. The caller must close the response body.
+	Do(ctx context.Context, method, path string, body []byte) (*http.Response, error)
 }
 
 type EurekaAPIClient struct {
-	client   *http.Client
-	baseURLs []string // Use multiple URLs for failover
+	client          *http.Client
+	heartbeatClient *http.Client
+	// dialers are those of the default transports, so that dial options can
+	// adjust them.
+	dialers []*net.Dialer
+	// transportWrappers are applied to the transports at construction.
+	transportWrappers []TransportWrapper
+	baseURLs          []string // Use multiple URLs for failover
+	// baseURLsMu guards baseURLs, which SetBaseURLs replaces.
+	baseURLsMu sync.RWMutex
+	// readURLs, if set, replace baseURLs for reads.
+	readURLs []string
+
+	// Identical concurrent queries are coalesced into one request.
+	appsFlight     flightGroup[Applications]
+	appFlight      flightGroup[Application]
+	instanceFlight flightGroup[Instance]
+
+	skew skewTracker
+
+	applicationRoot ApplicationRoot
+	fanOutWrites    bool
+	signer          RequestSigner
+	contextHeaders  []ContextHeader
+
+	nodes nodeTracker
+	clock clock.Clock
+
+	throttleRetries     int
+	maxThrottleWait     time.Duration
+	readFailOverClasses []int
+	readSelection       NodeSelection
+	readCounter         atomic.Uint64
+	retries             *retryBudget
+	retryClassifier     RetryClassifier
+	logger              *slog.Logger
+	notFound            notFoundCache
+	// jsonAll and jsonNodes select the base URLs asked for JSON.
+	jsonAll      bool
+	jsonNodes    map[string]bool
+	springCompat bool
+	// nodeConfigs are keyed by normalized base URL once resolved.
+	nodeConfigs   map[string]NodeConfig
+	nodeConfigsMu sync.RWMutex
+
+	responseLimits ResponseLimits
+}
+
+// Option configures optional behavior of an EurekaAPIClient.
+type Option func(*EurekaAPIClient)
+
+// WithApplicationRoot forces the root element expected when decoding single
+// application responses.
+func WithApplicationRoot(root ApplicationRoot) Option {
+	return func(c *EurekaAPIClient) {
+		c.applicationRoot = root
+	}
+}
+
+// WithClock sets the time source used for timestamps and skew estimation.
+func WithClock(clk clock.Clock) Option {
+	return func(c *EurekaAPIClient) {
+		if clk != nil {
+			c.clock = clk
+		}
+	}
 }
 
-func NewEurekaAPIClient(baseURLs ...string) (EurekaAPI, error) {
+func NewEurekaAPIClient(baseURLs []string, opts ...Option) (EurekaAPI, error) {
 	if len(baseURLs) == 0 {
 		return nil, errors.New("at least one Eureka base URL is required")
 	}
@@ -68,15 +183,20 @@ func NewEurekaAPIClient(baseURLs ...string) (EurekaAPI, error) {
 		}
 		norm = append(norm, nu)
 	}
-	return &EurekaAPIClient{
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	heartbeatDialer := &net.Dialer{
+		Timeout:   2 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	c := &EurekaAPIClient{
 		client: &http.Client{
 			Timeout: defaultTimeout,
 			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
-					Timeout:   10 * time.Second,
-					KeepAlive: 30 * time.Second,
-				}).DialContext,
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           dialer.DialContext,
 				ForceAttemptHTTP2:     true,
 				MaxIdleConns:          100,
 				IdleConnTimeout:       90 * time.Second,
@@ -84,59 +204,115 @@ func NewEurekaAPIClient(baseURLs ...string) (EurekaAPI, error) {
 				ExpectContinueTimeout: 1 * time.Second,
 			},
 		},
-		baseURLs: norm,
-	}, nil
-}
+		heartbeatClient: newHeartbeatClient(heartbeatDialer),
+		dialers:         []*net.Dialer{dialer, heartbeatDialer},
+		baseURLs:        norm,
+		clock:           clock.Real(),
+
+		throttleRetries: defaultThrottleRetries,
+		maxThrottleWait: defaultMaxThrottleWait,
 
-func (c *EurekaAPIClient) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
-	if wrap == nil {
-		return
+		readFailOverClasses: defaultReadFailOverClasses,
+		notFound:            notFoundCache{ttl: defaultNotFoundTTL},
+
+		responseLimits: DefaultResponseLimits,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.normalizeReadURLs(); err != nil {
+		return nil, err
 	}
-	if c.client == nil || c.client.Transport == nil {
-		return
+	if err := c.resolveNodeConfigs(); err != nil {
+		return nil, err
 	}
-	c.client.Transport = wrap(c.client.Transport)
+	if err := c.installTransports(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
-// ---------- XML Models ----------
+// ---------- Models ----------
 
 type Instance struct {
-	XMLName                 xml.Name   `xml:"instance"`
-	HostName                string     `xml:"hostName"`
-	App                     string     `xml:"app"`
-	IPAddr                  string     `xml:"ipAddr"`
-	VipAddress              string     `xml:"vipAddress,omitempty"`
-	SecureVipAddress        string     `xml:"secureVipAddress,omitempty"`
-	Status                  string     `xml:"status"`
-	Port                    *Port      `xml:"port,omitempty"`
-	SecurePort              *Port      `xml:"securePort,omitempty"`
-	HomePageURL             string     `xml:"homePageUrl,omitempty"`
-	StatusPageURL           string     `xml:"statusPageUrl,omitempty"`
-	HealthCheckURL          string     `xml:"healthCheckUrl,omitempty"`
-	DataCenterInfo          DataCenter `xml:"dataCenterInfo"`
-	LeaseInfo               *LeaseInfo `xml:"leaseInfo,omitempty"`
-	Metadata                *Metadata  `xml:"metadata,omitempty"`
-	InstanceID              string     `xml:"instanceId,omitempty"`
-	OverriddenStatus        string     `xml:"overriddenstatus,omitempty"`
-	IsCoordinatingDiscovery string     `xml:"isCoordinatingDiscoveryServer,omitempty"`
-	LastUpdatedTimestamp    string     `xml:"lastUpdatedTimestamp,omitempty"`
-	LastDirtyTimestamp      string     `xml:"lastDirtyTimestamp,omitempty"`
-	ActionType              string     `xml:"actionType,omitempty"`
-	CountryID               string     `xml:"countryId,omitempty"`
+	XMLName                 xml.Name   `xml:"instance" json:"-"`
+	HostName                string     `xml:"hostName" json:"hostName"`
+	App                     string     `xml:"app" json:"app"`
+	AppGroupName            string     `xml:"appGroupName,omitempty" json:"appGroupName,omitempty"`
+	IPAddr                  string     `xml:"ipAddr" json:"ipAddr"`
+	Sid                     string     `xml:"sid,omitempty" json:"sid,omitempty"`
+	VipAddress              string     `xml:"vipAddress,omitempty" json:"vipAddress,omitempty"`
+	SecureVipAddress        string     `xml:"secureVipAddress,omitempty" json:"secureVipAddress,omitempty"`
+	Status                  string     `xml:"status" json:"status"`
+	Port                    *Port      `xml:"port,omitempty" json:"port,omitempty"`
+	SecurePort              *Port      `xml:"securePort,omitempty" json:"securePort,omitempty"`
+	HomePageURL             string     `xml:"homePageUrl,omitempty" json:"homePageUrl,omitempty"`
+	StatusPageURL           string     `xml:"statusPageUrl,omitempty" json:"statusPageUrl,omitempty"`
+	HealthCheckURL          string     `xml:"healthCheckUrl,omitempty" json:"healthCheckUrl,omitempty"`
+	SecureHealthCheckURL    string     `xml:"secureHealthCheckUrl,omitempty" json:"secureHealthCheckUrl,omitempty"`
+	DataCenterInfo          DataCenter `xml:"dataCenterInfo" json:"dataCenterInfo"`
+	LeaseInfo               *LeaseInfo `xml:"leaseInfo,omitempty" json:"leaseInfo,omitempty"`
+	Metadata                *Metadata  `xml:"metadata,omitempty" json:"metadata,omitempty"`
+	InstanceID              string     `xml:"instanceId,omitempty" json:"instanceId,omitempty"`
+	OverriddenStatus        string     `xml:"overriddenstatus,omitempty" json:"overriddenstatus,omitempty"`
+	IsCoordinatingDiscovery bool       `xml:"isCoordinatingDiscoveryServer,omitempty" json:"isCoordinatingDiscoveryServer,omitempty,string"`
+	// LastUpdatedTimestamp and LastDirtyTimestamp are in milliseconds since
+	// the epoch. Like CountryID, they are written as strings in JSON, as
+	// Eureka does.
+	LastUpdatedTimestamp int64      `xml:"lastUpdatedTimestamp,omitempty" json:"lastUpdatedTimestamp,omitempty,string"`
+	LastDirtyTimestamp   int64      `xml:"lastDirtyTimestamp,omitempty" json:"lastDirtyTimestamp,omitempty,string"`
+	ActionType           ActionType `xml:"actionType,omitempty" json:"actionType,omitempty"`
+	CountryID            int        `xml:"countryId,omitempty" json:"countryId,omitempty,string"`
+}
+
+// HealthCheckURLs returns the health check URLs of the instance, secure one
+// last, like InstanceInfo.getHealthCheckUrls in the Java client.
+func (inst Instance) HealthCheckURLs() []string {
+	var urls []string
+	for _, u := range []string{inst.HealthCheckURL, inst.SecureHealthCheckURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
 }
 
 type Port struct {
-	Enabled bool `xml:"enabled,attr"`
-	Value   int  `xml:",chardata"`
+	Enabled bool `xml:"enabled,attr" json:"@enabled"`
+	Value   int  `xml:",chardata" json:"$"`
 }
 
 type DataCenter struct {
-	XMLName xml.Name `xml:"dataCenterInfo"`
-	Name    string   `xml:"name"` // "MyOwn" or "Amazon"
+	XMLName xml.Name `xml:"dataCenterInfo" json:"-"`
+	Class   string   `xml:"class,attr,omitempty" json:"@class,omitempty"`
+	Name    string   `xml:"name" json:"name"` // "MyOwn" or "Amazon"
+	// Metadata describes the host, e.g. the EC2 instance for Amazon.
+	Metadata *Metadata `xml:"metadata,omitempty" json:"metadata,omitempty"`
 }
 
 type LeaseInfo struct {
-	EvictionDurationInSecs uint `xml:"evictionDurationInSecs,omitempty"`
+	RenewalIntervalInSecs uint `xml:"renewalIntervalInSecs,omitempty" json:"renewalIntervalInSecs,omitempty"`
+	// DurationInSecs is the lease duration as the server reports it.
+	// Registrations set EvictionDurationInSecs instead.
+	DurationInSecs         uint `xml:"durationInSecs,omitempty" json:"durationInSecs,omitempty"`
+	EvictionDurationInSecs uint `xml:"evictionDurationInSecs,omitempty" json:"evictionDurationInSecs,omitempty"`
+	// The timestamps are set by the server, in milliseconds since the epoch.
+	RegistrationTimestamp int64 `xml:"registrationTimestamp,omitempty" json:"registrationTimestamp,omitempty"`
+	LastRenewalTimestamp  int64 `xml:"lastRenewalTimestamp,omitempty" json:"lastRenewalTimestamp,omitempty"`
+	EvictionTimestamp     int64 `xml:"evictionTimestamp,omitempty" json:"evictionTimestamp,omitempty"`
+	ServiceUpTimestamp    int64 `xml:"serviceUpTimestamp,omitempty" json:"serviceUpTimestamp,omitempty"`
+}
+
+// Duration returns the lease duration, or zero if none is set.
+func (l *LeaseInfo) Duration() time.Duration {
+	if l == nil {
+		return 0
+	}
+	secs := l.EvictionDurationInSecs
+	if secs == 0 {
+		secs = l.DurationInSecs
+	}
+	return time.Duration(secs) * time.Second
 }
 
 type Metadata struct {
@@ -149,54 +325,140 @@ type MetaEntry struct {
 }
 
 type Applications struct {
-	XMLName       xml.Name      `xml:"applications"`
-	VersionsDelta string        `xml:"versions__delta,omitempty"`
-	AppsHashCode  string        `xml:"apps__hashcode,omitempty"`
-	Application   []Application `xml:"application"`
+	XMLName       xml.Name      `xml:"applications" json:"-"`
+	VersionsDelta string        `xml:"versions__delta,omitempty" json:"versions__delta,omitempty"`
+	AppsHashCode  string        `xml:"apps__hashcode,omitempty" json:"apps__hashcode,omitempty"`
+	Application   []Application `xml:"application" json:"application"`
 }
 
 type Application struct {
-	XMLName  xml.Name   `xml:"application"`
-	Name     string     `xml:"name"`
-	Instance []Instance `xml:"instance"`
+	XMLName  xml.Name   `xml:"application" json:"-"`
+	Name     string     `xml:"name" json:"name"`
+	Instance []Instance `xml:"instance" json:"instance"`
 }
 
 // ---------- Util ----------
 
-func (c *EurekaAPIClient) doRequestWithFailOver(doRequest func(baseURL string) (*http.Response, error)) (*http.Response, error) {
+// doRequestWithFailOver tries the base URLs, quarantined ones last, until one
+// of them answers.
+func (c *EurekaAPIClient) doRequestWithFailOver(ctx context.Context, doRequest func(ctx context.Context, baseURL string) (*http.Response, error)) (*http.Response, error) {
+	return c.failOver(ctx, c.nodes.order(c.bases(), c.clock.Now()), nil, doRequest)
+}
+
+// failOver tries the base URLs in the given order until one of them answers
+// with a response that shouldn't fail over. The last node's response is
+// returned either way. Once ctx is done no further node is tried, and a
+// request cut short by it doesn't count against its node. If ctx has a
+// deadline, each attempt only gets its share of the remaining time.
+func (c *EurekaAPIClient) failOver(ctx context.Context, order []string, failOverStatus func(*http.Response) bool, doRequest func(ctx context.Context, baseURL string) (*http.Response, error)) (*http.Response, error) {
 	var lastErr error
-	for _, baseURL := range c.baseURLs {
-		resp, err := doRequest(baseURL)
-		if err == nil {
+	for i, baseURL := range order {
+		if i > 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("%w: %w", err, lastErr)
+			}
+			if !c.retries.allow(c.clock.Now()) {
+				return nil, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, lastErr)
+			}
+		}
+		attemptCtx, cancel := c.attemptContext(ctx, len(order)-i)
+		resp, err := doRequest(attemptCtx, baseURL)
+		if err != nil && ctx.Err() != nil {
+			cancel()
+			return nil, fmt.Errorf("%w: request to %s failed: %w", ctx.Err(), baseURL, err)
+		}
+		retry := c.shouldFailOver(err, resp, failOverStatus)
+		if err == nil && (!retry || i == len(order)-1) {
+			c.nodes.success(baseURL, c.clock.Now())
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
 			return resp, nil
 		}
+		if err == nil {
+			discard(resp)
+			err = fmt.Errorf("unexpected response status: %s", resp.Status)
+		}
+		cancel()
+		c.nodes.failure(baseURL, c.clock.Now(), err)
 		lastErr = fmt.Errorf("request to %s failed: %w", baseURL, err)
+		if !retry {
+			return nil, lastErr
+		}
 	}
 	return nil, lastErr
 }
 
 // ---------- Requests ----------
 
+func (c *EurekaAPIClient) Do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	ctx = withOperation(ctx, "Do")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	reqBody := bytesBody(body)
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := newRequest(ctx, method, joinURL(baseURL, path), reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s request for %s: %w", method, path, err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", bodyContentType(body))
+		}
+		req.Header.Set("Accept", c.accept(baseURL))
+
+		return c.do(req)
+	}
+
+	doFailOver := c.doRequestWithFailOver
+	if isIdempotentRead(method) {
+		doFailOver = c.doReadRequest
+	}
+	resp, err := doFailOver(ctx, doRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %s: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// bodyContentType tells the format of a body given to Do by its first
+// non-blank byte.
+func bodyContentType(body []byte) string {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return jsonContentType
+	}
+	return xmlContentType
+}
+
+// marshalXMLInstance encodes inst as the XML registration body.
+func marshalXMLInstance(inst *Instance) ([]byte, error) {
+	return xml.Marshal(inst)
+}
+
 func (c *EurekaAPIClient) RegisterInstance(ctx context.Context, appID string, inst *Instance) error {
-	body, err := xml.Marshal(inst)
+	ctx = withOperation(ctx, "RegisterInstance")
+	marshal, contentType := marshalXMLInstance, xmlContentType
+	if c.springCompat {
+		marshal, contentType = marshalSpringInstance, jsonContentType
+	}
+	data, err := marshal(inst)
 	if err != nil {
 		return fmt.Errorf("failed to marshal instance: %w", err)
 	}
+	body := bytesBody(data)
 
-	doRequest := func(baseURL string) (*http.Response, error) {
-		log.Printf("%s", fmt.Sprintf("%s/apps/%s", baseURL, appID))
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/apps/%s", baseURL, appID), strings.NewReader(string(body)))
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := newRequest(ctx, http.MethodPost, joinURL(baseURL, "apps", appID), body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
-		req.Header.Set("Content-Type", xmlContentType)
+		req.Header.Set("Content-Type", contentType)
 		req.Header.Set("Accept", xmlAccept)
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doWriteRequest(ctx, doRequest)
 	if err != nil {
 		return fmt.Errorf("failed to register instance: %w", err)
 	}
@@ -205,46 +467,68 @@ func (c *EurekaAPIClient) RegisterInstance(ctx context.Context, appID string, in
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusCreated {
 		return fmt.Errorf("unexpected response status: %s", resp.Status)
 	}
+	c.notFound.forget(appID)
 	return nil
 }
 
-func (c *EurekaAPIClient) Heartbeat(ctx context.Context, appID, instanceID string) (bool, error) {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/apps/%s/%s", baseURL, appID, instanceID), nil)
+func (c *EurekaAPIClient) Heartbeat(ctx context.Context, appID, instanceID string, lastDirtyTimestamp int64) (HeartbeatResult, error) {
+	ctx = withOperation(ctx, "Heartbeat")
+	query := ""
+	if lastDirtyTimestamp > 0 {
+		query = fmt.Sprintf("?lastDirtyTimestamp=%d", lastDirtyTimestamp)
+	}
+
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, joinURL(baseURL, "apps", appID, instanceID)+query, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create heartbeat request: %w", err)
 		}
 		req.Header.Set("Accept", xmlAccept)
 
-		return c.client.Do(req)
+		return c.doWith(c.heartbeatClient, req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	sent := c.clock.Now()
+	resp, err := c.doWriteRequest(ctx, doRequest)
 	if err != nil {
-		return false, fmt.Errorf("failed to send heartbeat: %w", err)
+		return HeartbeatResult{}, fmt.Errorf("failed to send heartbeat: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return false, nil
-	} else if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("unexpected response status for heartbeat: %s", resp.Status)
+	result := newHeartbeatResult(resp, c.clock.Since(sent))
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return result, nil
+	case http.StatusConflict:
+		result.Found = true
+		return result, ErrDirtyTimestampConflict
+	case http.StatusOK:
+		result.Found = true
+		return result, nil
+	default:
+		return result, fmt.Errorf("unexpected response status for heartbeat: %s", resp.Status)
 	}
-	return true, nil
 }
 
 func (c *EurekaAPIClient) GetAllApplications(ctx context.Context) (Applications, error) {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/apps", baseURL), nil)
+	ctx = withOperation(ctx, "GetAllApplications")
+	return c.appsFlight.do(ctx, "apps", func(ctx context.Context) (Applications, error) {
+		return c.getAllApplications(ctx)
+	})
+}
+
+func (c *EurekaAPIClient) getAllApplications(ctx context.Context) (Applications, error) {
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(baseURL, "apps"), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request for all applications: %w", err)
 		}
-		req.Header.Set("Accept", xmlAccept)
+		req.Header.Set("Accept", c.accept(baseURL))
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doReadRequest(ctx, doRequest)
 	if err != nil {
 		return Applications{}, fmt.Errorf("failed to get all applications: %w", err)
 	}
@@ -254,53 +538,121 @@ func (c *EurekaAPIClient) GetAllApplications(ctx context.Context) (Applications,
 		return Applications{}, fmt.Errorf("unexpected response status for all applications: %s", resp.Status)
 	}
 
-	var apps Applications
-	if err := xml.NewDecoder(resp.Body).Decode(&apps); err != nil {
+	apps, err := decodeApplicationsResponse(resp)
+	if err != nil {
 		return Applications{}, fmt.Errorf("failed to decode applications response: %w", err)
 	}
+	internApplications(&apps)
+	c.springApplications(&apps)
+	return apps, nil
+}
+
+func (c *EurekaAPIClient) GetDelta(ctx context.Context) (Applications, error) {
+	ctx = withOperation(ctx, "GetDelta")
+	return c.appsFlight.do(ctx, "apps/delta", func(ctx context.Context) (Applications, error) {
+		return c.getDelta(ctx)
+	})
+}
+
+func (c *EurekaAPIClient) getDelta(ctx context.Context) (Applications, error) {
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(baseURL, "apps", "delta"), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request for registry delta: %w", err)
+		}
+		req.Header.Set("Accept", c.accept(baseURL))
+
+		return c.do(req)
+	}
+
+	resp, err := c.doReadRequest(ctx, doRequest)
+	if err != nil {
+		return Applications{}, fmt.Errorf("failed to get registry delta: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Applications{}, fmt.Errorf("unexpected response status for registry delta: %s", resp.Status)
+	}
+
+	apps, err := decodeApplicationsResponse(resp)
+	if err != nil {
+		return Applications{}, fmt.Errorf("failed to decode registry delta response: %w", err)
+	}
+	internApplications(&apps)
+	c.springApplications(&apps)
 	return apps, nil
 }
 
 func (c *EurekaAPIClient) GetApplication(ctx context.Context, appID string) (Application, error) {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/apps/%s", baseURL, appID), nil)
+	ctx = withOperation(ctx, "GetApplication")
+	if c.notFound.missing(appID, c.clock.Now()) {
+		return Application{}, fmt.Errorf("%w: %s", ErrApplicationNotFound, appID)
+	}
+	return c.appFlight.do(ctx, "apps/"+appID, func(ctx context.Context) (Application, error) {
+		return c.getApplication(ctx, appID, nil)
+	})
+}
+
+func (c *EurekaAPIClient) getApplication(ctx context.Context, appID string, query url.Values) (Application, error) {
+	target := func(baseURL string) string {
+		if len(query) == 0 {
+			return joinURL(baseURL, "apps", appID)
+		}
+		return joinURL(baseURL, "apps", appID) + "?" + query.Encode()
+	}
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target(baseURL), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request for application %s: %w", appID, err)
 		}
-		req.Header.Set("Accept", xmlAccept)
+		req.Header.Set("Accept", c.accept(baseURL))
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doReadRequest(ctx, doRequest)
 	if err != nil {
 		return Application{}, fmt.Errorf("failed to get application %s: %w", appID, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		c.notFound.add(appID, c.clock.Now())
+		return Application{}, fmt.Errorf("%w: %s", ErrApplicationNotFound, appID)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return Application{}, fmt.Errorf("unexpected response status for application %s: %s", appID, resp.Status)
 	}
 
-	var app Application
-	if err := xml.NewDecoder(resp.Body).Decode(&app); err != nil {
+	app, err := decodeApplicationResponse(resp, appID, c.applicationRoot)
+	if err != nil {
 		return Application{}, fmt.Errorf("failed to decode application response: %w", err)
 	}
+	internApplication(&app)
+	c.springApplication(&app)
 	return app, nil
 }
 
 func (c *EurekaAPIClient) GetInstance(ctx context.Context, appID, instanceID string) (Instance, error) {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/apps/%s/%s", baseURL, appID, instanceID), nil)
+	ctx = withOperation(ctx, "GetInstance")
+	return c.instanceFlight.do(ctx, "apps/"+appID+"/"+instanceID, func(ctx context.Context) (Instance, error) {
+		return c.getInstance(ctx, appID, instanceID)
+	})
+}
+
+func (c *EurekaAPIClient) getInstance(ctx context.Context, appID, instanceID string) (Instance, error) {
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(baseURL, "apps", appID, instanceID), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request for instance %s of application %s: %w", instanceID, appID, err)
 		}
-		req.Header.Set("Accept", xmlAccept)
+		req.Header.Set("Accept", c.accept(baseURL))
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doReadRequest(ctx, doRequest)
 	if err != nil {
 		return Instance{}, fmt.Errorf("failed to get instance %s of application %s: %w", instanceID, appID, err)
 	}
@@ -310,25 +662,34 @@ func (c *EurekaAPIClient) GetInstance(ctx context.Context, appID, instanceID str
 		return Instance{}, fmt.Errorf("unexpected response status for instance %s of application %s: %d", instanceID, appID, resp.StatusCode)
 	}
 
-	var inst Instance
-	if err := xml.NewDecoder(resp.Body).Decode(&inst); err != nil {
+	inst, err := decodeInstanceResponse(resp)
+	if err != nil {
 		return Instance{}, fmt.Errorf("failed to decode instance response: %w", err)
 	}
+	internInstance(&inst)
+	c.springInstance(&inst)
 	return inst, nil
 }
 
 func (c *EurekaAPIClient) GetByVIP(ctx context.Context, vip string) (Applications, error) {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/vips/%s", baseURL, vip), nil)
+	ctx = withOperation(ctx, "GetByVIP")
+	return c.appsFlight.do(ctx, "vips/"+vip, func(ctx context.Context) (Applications, error) {
+		return c.getByVIP(ctx, vip)
+	})
+}
+
+func (c *EurekaAPIClient) getByVIP(ctx context.Context, vip string) (Applications, error) {
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(baseURL, "vips", vip), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request for VIP %s: %w", vip, err)
 		}
-		req.Header.Set("Accept", xmlAccept)
+		req.Header.Set("Accept", c.accept(baseURL))
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doReadRequest(ctx, doRequest)
 	if err != nil {
 		return Applications{}, fmt.Errorf("failed to get by VIP %s: %w", vip, err)
 	}
@@ -338,25 +699,34 @@ func (c *EurekaAPIClient) GetByVIP(ctx context.Context, vip string) (Application
 		return Applications{}, fmt.Errorf("unexpected response status for VIP %s: %s", vip, resp.Status)
 	}
 
-	var apps Applications
-	if err := xml.NewDecoder(resp.Body).Decode(&apps); err != nil {
+	apps, err := decodeApplicationsResponse(resp)
+	if err != nil {
 		return Applications{}, fmt.Errorf("failed to decode VIP response: %w", err)
 	}
+	internApplications(&apps)
+	c.springApplications(&apps)
 	return apps, nil
 }
 
 func (c *EurekaAPIClient) GetBySecureVIP(ctx context.Context, svip string) (Applications, error) {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/svips/%s", baseURL, svip), nil)
+	ctx = withOperation(ctx, "GetBySecureVIP")
+	return c.appsFlight.do(ctx, "svips/"+svip, func(ctx context.Context) (Applications, error) {
+		return c.getBySecureVIP(ctx, svip)
+	})
+}
+
+func (c *EurekaAPIClient) getBySecureVIP(ctx context.Context, svip string) (Applications, error) {
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(baseURL, "svips", svip), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request for secure VIP %s: %w", svip, err)
 		}
-		req.Header.Set("Accept", xmlAccept)
+		req.Header.Set("Accept", c.accept(baseURL))
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doReadRequest(ctx, doRequest)
 	if err != nil {
 		return Applications{}, fmt.Errorf("failed to get by secure VIP %s: %w", svip, err)
 	}
@@ -366,25 +736,28 @@ func (c *EurekaAPIClient) GetBySecureVIP(ctx context.Context, svip string) (Appl
 		return Applications{}, fmt.Errorf("unexpected response status for secure VIP %s: %s", svip, resp.Status)
 	}
 
-	var apps Applications
-	if err := xml.NewDecoder(resp.Body).Decode(&apps); err != nil {
+	apps, err := decodeApplicationsResponse(resp)
+	if err != nil {
 		return Applications{}, fmt.Errorf("failed to decode secure VIP response: %w", err)
 	}
+	internApplications(&apps)
+	c.springApplications(&apps)
 	return apps, nil
 }
 
 func (c *EurekaAPIClient) SetStatus(ctx context.Context, appID, instanceID, status string) error {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/apps/%s/%s/status?value=%s", baseURL, appID, instanceID, status), nil)
+	ctx = withOperation(ctx, "SetStatus")
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, joinURL(baseURL, "apps", appID, instanceID, "status")+"?value="+status, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request to set status for instance %s of application %s: %w", instanceID, appID, err)
 		}
 		req.Header.Set("Accept", xmlAccept)
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doWriteRequest(ctx, doRequest)
 	if err != nil {
 		return fmt.Errorf("failed to set status for instance %s of application %s: %w", instanceID, appID, err)
 	}
@@ -397,17 +770,18 @@ func (c *EurekaAPIClient) SetStatus(ctx context.Context, appID, instanceID, stat
 }
 
 func (c *EurekaAPIClient) ClearStatusOverride(ctx context.Context, appID, instanceID string, suggestedFallback string) error {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/apps/%s/%s/status?value=%s", baseURL, appID, instanceID, suggestedFallback), nil)
+	ctx = withOperation(ctx, "ClearStatusOverride")
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, joinURL(baseURL, "apps", appID, instanceID, "status")+"?value="+suggestedFallback, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request to clear status override for instance %s of application %s: %w", instanceID, appID, err)
 		}
 		req.Header.Set("Accept", xmlAccept)
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doWriteRequest(ctx, doRequest)
 	if err != nil {
 		return fmt.Errorf("failed to clear status override for instance %s of application %s: %w", instanceID, appID, err)
 	}
@@ -420,29 +794,44 @@ func (c *EurekaAPIClient) ClearStatusOverride(ctx context.Context, appID, instan
 }
 
 func (c *EurekaAPIClient) UpdateMetadata(ctx context.Context, appID, instanceID string, kv map[string]string) error {
+	ctx = withOperation(ctx, "UpdateMetadata")
 	if len(kv) == 0 {
 		return errors.New("metadata map cannot be empty")
 	}
-
-	query := ""
+	query := make(url.Values, len(kv))
 	for k, v := range kv {
-		if query != "" {
-			query += "&"
-		}
-		query += fmt.Sprintf("%s=%s", k, v)
+		query.Set(k, v)
 	}
+	return c.putMetadata(ctx, appID, instanceID, query)
+}
+
+// DeleteMetadata clears the given metadata keys of an instance. Eureka has no
+// way to remove a key, so they are set to the empty value, which consumers
+// should treat as absent.
+func (c *EurekaAPIClient) DeleteMetadata(ctx context.Context, appID, instanceID string, keys ...string) error {
+	ctx = withOperation(ctx, "DeleteMetadata")
+	if len(keys) == 0 {
+		return errors.New("no metadata keys to delete")
+	}
+	query := make(url.Values, len(keys))
+	for _, k := range keys {
+		query.Set(k, "")
+	}
+	return c.putMetadata(ctx, appID, instanceID, query)
+}
 
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/apps/%s/%s/metadata?%s", baseURL, appID, instanceID, query), nil)
+func (c *EurekaAPIClient) putMetadata(ctx context.Context, appID, instanceID string, query url.Values) error {
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, joinURL(baseURL, "apps", appID, instanceID, "metadata")+"?"+query.Encode(), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request to update metadata for instance %s of application %s: %w", instanceID, appID, err)
 		}
 		req.Header.Set("Accept", xmlAccept)
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doWriteRequest(ctx, doRequest)
 	if err != nil {
 		return fmt.Errorf("failed to update metadata for instance %s of application %s: %w", instanceID, appID, err)
 	}
@@ -455,17 +844,18 @@ func (c *EurekaAPIClient) UpdateMetadata(ctx context.Context, appID, instanceID
 }
 
 func (c *EurekaAPIClient) UnregisterInstance(ctx context.Context, appID, instanceID string) error {
-	doRequest := func(baseURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/apps/%s/%s", baseURL, appID, instanceID), nil)
+	ctx = withOperation(ctx, "UnregisterInstance")
+	doRequest := func(ctx context.Context, baseURL string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, joinURL(baseURL, "apps", appID, instanceID), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request to unregister instance %s of application %s: %w", instanceID, appID, err)
 		}
 		req.Header.Set("Accept", xmlAccept)
 
-		return c.client.Do(req)
+		return c.do(req)
 	}
 
-	resp, err := c.doRequestWithFailOver(doRequest)
+	resp, err := c.doWriteRequest(ctx, doRequest)
 	if err != nil {
 		return fmt.Errorf("failed to unregister instance %s of application %s: %w", instanceID, appID, err)
 	}
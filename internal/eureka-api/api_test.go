@@ -0,0 +1,1169 @@
+package eurekaapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func newTestClient(t *testing.T, baseURLs ...string) *EurekaAPIClient {
+	t.Helper()
+	api, err := NewEurekaAPIClient(baseURLs)
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	return api.(*EurekaAPIClient)
+}
+
+func TestGetApplicationCoalescesConcurrentQueries(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Header().Set("Content-Type", xmlContentType)
+		_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app, err := client.GetApplication(context.Background(), "FOO")
+			if err != nil {
+				t.Errorf("GetApplication returned error: %v", err)
+				return
+			}
+			if app.Name != "FOO" {
+				t.Errorf("GetApplication returned app %q; want FOO", app.Name)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests; want 1", got)
+	}
+}
+
+func TestCoalescedQueryOutlivesTheFirstCaller(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+		w.Header().Set("Content-Type", xmlContentType)
+		_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+	}))
+	defer server.Close()
+	defer close(release)
+	client := newTestClient(t, server.URL)
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.GetApplication(firstCtx, "FOO")
+		first <- err
+	}()
+	<-started
+
+	second := make(chan error, 1)
+	go func() {
+		app, err := client.GetApplication(context.Background(), "FOO")
+		if err == nil && app.Name != "FOO" {
+			err = fmt.Errorf("got app %q", app.Name)
+		}
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The first caller gives up; the shared query goes on for the second.
+	cancelFirst()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller got %v; want context.Canceled", err)
+	}
+
+	// A waiter can give up on its own, too.
+	waiterCtx, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWaiter()
+	if _, err := client.GetApplication(waiterCtx, "FOO"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiter got %v; want context.DeadlineExceeded", err)
+	}
+
+	release <- struct{}{}
+	if err := <-second; err != nil {
+		t.Errorf("second caller got %v; want the shared result", err)
+	}
+}
+
+func TestFlightGroupPanicReachesEveryCaller(t *testing.T) {
+	var g flightGroup[int]
+	release := make(chan struct{})
+	call := func() (recovered any) {
+		defer func() { recovered = recover() }()
+		g.do(context.Background(), "key", func(context.Context) (int, error) {
+			<-release
+			panic("boom")
+		})
+		return nil
+	}
+
+	results := make(chan any, 2)
+	for range 2 {
+		go func() { results <- call() }()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for range 2 {
+		var perr *panicError
+		if r := <-results; !errors.As(asError(r), &perr) || perr.value != "boom" {
+			t.Errorf("caller recovered %v; want the panic of the shared call", r)
+		}
+	}
+	if len(g.calls) != 0 {
+		t.Errorf("%d calls left in the group; want none", len(g.calls))
+	}
+}
+
+func asError(v any) error {
+	err, _ := v.(error)
+	return err
+}
+
+func TestClockSkewFromDateHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	if _, err := client.Heartbeat(context.Background(), "FOO", "foo-1", 0); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+
+	skew := client.ClockSkew()
+	if skew < 59*time.Minute || skew > 61*time.Minute {
+		t.Errorf("ClockSkew() = %v; want about 1h", skew)
+	}
+}
+
+func TestDoFailsOverAndSendsBody(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	var gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody = r.URL.Path, string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, dead.URL, server.URL)
+	resp, err := client.Do(context.Background(), http.MethodPost, "custom/extension", []byte("<payload/>"))
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d; want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if gotPath != defaultBasePath+"/custom/extension" {
+		t.Errorf("path = %q; want %q", gotPath, defaultBasePath+"/custom/extension")
+	}
+	if gotBody != "<payload/>" {
+		t.Errorf("body = %q; want %q", gotBody, "<payload/>")
+	}
+}
+
+func TestDoNegotiatesLikeTypedReads(t *testing.T) {
+	var accept, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept, contentType = r.Header.Get("Accept"), r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	WithJSON(server.URL)(client)
+	resp, err := client.Do(context.Background(), http.MethodPut, "custom/extension", []byte(` {"enabled":true}`))
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if accept != jsonAccept {
+		t.Errorf("Accept = %q; want %q for a JSON node", accept, jsonAccept)
+	}
+	if contentType != jsonContentType {
+		t.Errorf("Content-Type = %q; want %q for a JSON body", contentType, jsonContentType)
+	}
+}
+
+func TestWriteFanOut(t *testing.T) {
+	var registrations atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			registrations.Add(1)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	api, err := NewEurekaAPIClient([]string{first.URL, second.URL}, WithWriteFanOut())
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	if err := api.RegisterInstance(context.Background(), "FOO", &Instance{App: "FOO"}); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if got := registrations.Load(); got != 2 {
+		t.Errorf("registrations = %d; want 2", got)
+	}
+}
+
+func TestWriteFanOutCountsServerErrorsAsNodeFailures(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	api, err := NewEurekaAPIClient([]string{healthy.URL, failing.URL}, WithWriteFanOut(), WithNodeQuarantine(1, time.Minute))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	_ = api.SetStatus(context.Background(), "FOO", "foo-1", UP)
+
+	nodes := api.Nodes()
+	if len(nodes) != 2 || !strings.HasPrefix(nodes[0].URL, healthy.URL) || !strings.HasPrefix(nodes[1].URL, failing.URL) {
+		t.Fatalf("nodes = %+v; want the healthy node before the failing one", nodes)
+	}
+	if nodes[0].ConsecutiveErrors != 0 || nodes[0].LastSuccess.IsZero() {
+		t.Errorf("healthy node = %+v; want a success", nodes[0])
+	}
+	if nodes[1].ConsecutiveErrors == 0 || !nodes[1].Quarantined || !nodes[1].LastSuccess.IsZero() {
+		t.Errorf("failing node = %+v; want failures that quarantine it", nodes[1])
+	}
+}
+
+func TestRequestSignerSeesBody(t *testing.T) {
+	var gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get("X-Signature")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	signer := RequestSignerFunc(func(req *http.Request, body []byte) error {
+		req.Header.Set("X-Signature", fmt.Sprintf("%s:%d", req.Method, len(body)))
+		return nil
+	})
+	api, err := NewEurekaAPIClient([]string{server.URL}, WithRequestSigner(signer))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+
+	inst := &Instance{App: "FOO"}
+	body, _ := xml.Marshal(inst)
+	if err := api.RegisterInstance(context.Background(), "FOO", inst); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if want := fmt.Sprintf("POST:%d", len(body)); gotSignature != want {
+		t.Errorf("signature = %q; want %q", gotSignature, want)
+	}
+}
+
+type requestIDKey struct{}
+
+func TestContextHeaders(t *testing.T) {
+	var gotRequestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestID = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	api, err := NewEurekaAPIClient([]string{server.URL}, WithContextHeaders(ContextHeader{Key: requestIDKey{}, Header: "X-Request-ID"}))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-42")
+	if _, err := api.Heartbeat(ctx, "FOO", "foo-1", 0); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+	if gotRequestID != "req-42" {
+		t.Errorf("X-Request-ID = %q; want req-42", gotRequestID)
+	}
+}
+
+func TestThrottledRequestsBackOffOnSameNode(t *testing.T) {
+	var primary, secondary atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if primary.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondary.Add(1)
+	}))
+	defer backup.Close()
+
+	client := newTestClient(t, server.URL, backup.URL)
+	resp, err := client.Do(context.Background(), http.MethodPost, "throttled", []byte("<payload/>"))
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d; want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if primary.Load() != 2 || secondary.Load() != 0 {
+		t.Errorf("requests = %d primary, %d secondary; want 2, 0", primary.Load(), secondary.Load())
+	}
+	for i, body := range bodies {
+		if body != "<payload/>" {
+			t.Errorf("attempt %d body = %q; want %q", i, body, "<payload/>")
+		}
+	}
+
+	// A Retry-After beyond the maximum wait is handed back to the caller.
+	primary.Store(0)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	resp, err = client.Do(context.Background(), http.MethodPut, "throttled", nil)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || primary.Load() != 1 || secondary.Load() != 0 {
+		t.Errorf("got status %d after %d requests; want 503 after 1", resp.StatusCode, primary.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+
+	for _, test := range tests {
+		result, ok := parseRetryAfter(test.value, now)
+		if result != test.expected || ok != test.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %t; want %v, %t", test.value, result, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestReadsFailOverOnServerErrors(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, broken.URL, server.URL)
+	if _, err := client.GetApplication(context.Background(), "FOO"); err != nil {
+		t.Errorf("GetApplication returned error: %v", err)
+	}
+	if client.Nodes()[0].ConsecutiveErrors != 1 {
+		t.Errorf("expected the failing node to be recorded as failed")
+	}
+
+	// Writes are not retried on another node after a server error.
+	resp, err := client.Do(context.Background(), http.MethodPost, "apps/FOO", nil)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("write status = %d; want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+
+	WithReadFailOver()(client)
+	if _, err := client.GetApplication(context.Background(), "FOO"); err == nil {
+		t.Errorf("GetApplication without read failover succeeded; want the 500 reported")
+	}
+}
+
+type countingTransport struct {
+	requests atomic.Int32
+	next     http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+func TestHeartbeatsUseDedicatedClient(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method == http.MethodGet {
+			_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+		}
+	}))
+	defer server.Close()
+
+	heartbeats := &countingTransport{next: http.DefaultTransport}
+	api, err := NewEurekaAPIClient([]string{server.URL}, WithHeartbeatHTTPClient(&http.Client{Transport: heartbeats}))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	if _, err := api.Heartbeat(context.Background(), "FOO", "i-1", 0); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+	if _, err := api.GetApplication(context.Background(), "FOO"); err != nil {
+		t.Fatalf("GetApplication returned error: %v", err)
+	}
+	if got := heartbeats.requests.Load(); got != 1 {
+		t.Errorf("heartbeat client sent %d requests; want 1", got)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests; want 2", got)
+	}
+}
+
+func TestDNSResolverIsUsed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	var lookups atomic.Int32
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			lookups.Add(1)
+			return nil, errors.New("no DNS in tests")
+		},
+	}
+	api, err := NewEurekaAPIClient([]string{"http://eureka.internal:" + port}, WithDNSResolver(resolver))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	if _, err := api.GetApplication(context.Background(), "FOO"); err == nil {
+		t.Fatalf("GetApplication succeeded without DNS")
+	}
+	if lookups.Load() == 0 {
+		t.Errorf("custom resolver was not used")
+	}
+}
+
+func TestUnixSocketDialer(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "eureka.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+		})},
+	}
+	server.Start()
+	defer server.Close()
+
+	api, err := NewEurekaAPIClient([]string{"http://eureka"}, WithDialContext(UnixSocketDialer(socket)))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	app, err := api.GetApplication(context.Background(), "FOO")
+	if err != nil {
+		t.Fatalf("GetApplication returned error: %v", err)
+	}
+	if app.Name != "FOO" {
+		t.Errorf("GetApplication returned app %q; want FOO", app.Name)
+	}
+}
+
+type headerTransport struct {
+	value string
+	next  http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Add("X-Wrapped", t.value)
+	return t.next.RoundTrip(req)
+}
+
+func TestTransportWrappersApplyInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var got [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.Header.Values("X-Wrapped"))
+		mu.Unlock()
+		_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+	}))
+	defer server.Close()
+
+	wrapper := func(value string) TransportWrapper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return &headerTransport{value: value, next: next}
+		}
+	}
+	api, err := NewEurekaAPIClient([]string{server.URL}, WithTransportWrapper(wrapper("a")), WithTransportWrapper(wrapper("b")))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+
+	// Wrapping while requests are in flight must be safe.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.GetApplication(context.Background(), "FOO"); err != nil {
+				t.Errorf("GetApplication returned error: %v", err)
+			}
+		}()
+	}
+	api.WrapTransport(wrapper("c"))
+	wg.Wait()
+
+	mu.Lock()
+	got = nil
+	mu.Unlock()
+	if _, err := api.Heartbeat(context.Background(), "FOO", "i-1", 0); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+	if len(got) != 1 || fmt.Sprint(got[0]) != "[c b a]" {
+		t.Errorf("wrapped headers = %v; want [[c b a]]", got)
+	}
+}
+
+func TestRetryBudgetCapsFailOver(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	var live atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		live.Add(1)
+	}))
+	defer server.Close()
+
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, dead.URL, server.URL)
+	WithClock(clk)(client)
+	WithRetryBudget(1, time.Minute)(client)
+
+	do := func() error {
+		resp, err := client.Do(context.Background(), http.MethodPut, "status", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := do(); err != nil {
+		t.Fatalf("first request returned error: %v", err)
+	}
+	if err := do(); !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("second request returned %v; want ErrRetryBudgetExhausted", err)
+	}
+	clk.Advance(time.Minute)
+	if err := do(); err != nil {
+		t.Fatalf("request after the window returned error: %v", err)
+	}
+	if got := live.Load(); got != 2 {
+		t.Errorf("live node received %d requests; want 2", got)
+	}
+}
+
+func TestFailOverStopsOnceContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	var next atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.Add(1)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, broken.URL, server.URL)
+	if _, err := client.GetApplication(ctx, "FOO"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetApplication returned %v; want context.Canceled", err)
+	}
+	if got := next.Load(); got != 0 {
+		t.Errorf("next node received %d requests after cancellation; want 0", got)
+	}
+}
+
+func TestFailOverSplitsTheDeadline(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = xml.NewEncoder(w).Encode(Application{Name: "FOO"})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, slow.URL, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.GetApplication(ctx, "FOO"); err != nil {
+		t.Fatalf("GetApplication returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("GetApplication took %s; want the slow node cut off after half the deadline", elapsed)
+	}
+	if client.Nodes()[0].ConsecutiveErrors != 1 {
+		t.Errorf("expected the slow node to be recorded as failed")
+	}
+}
+
+// newBodyDroppingServer returns a server that reads the whole request body
+// and then drops the connection, so the client must send the body again to
+// the next node.
+func newBodyDroppingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack returned error: %v", err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWritesResendBodyOnFailOver(t *testing.T) {
+	var bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	client := newTestClient(t, newBodyDroppingServer(t).URL, server.URL)
+
+	if err := client.RegisterInstance(context.Background(), "FOO", &Instance{InstanceID: "i-1"}); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	resp, err := client.Do(context.Background(), http.MethodPost, "/custom", []byte("<custom/>"))
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 2 || !strings.Contains(bodies[0], "<instanceId>i-1</instanceId>") || bodies[1] != "<custom/>" {
+		t.Errorf("bodies received after failover = %q; want the whole registration and custom body", bodies)
+	}
+}
+
+func TestJSONPerBaseURL(t *testing.T) {
+	var accepts []string
+	var mu sync.Mutex
+	// Both nodes fail to produce XML; only the one asked for JSON answers.
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		accepts = append(accepts, r.Header.Get("Accept"))
+		mu.Unlock()
+		if r.Header.Get("Accept") == jsonAccept {
+			w.Header().Set("Content-Type", jsonAccept)
+			w.Write([]byte(`{"application":{"name":"FOO","instance":[{"instanceId":"foo-1"}]}}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	first := httptest.NewServer(http.HandlerFunc(handler))
+	defer first.Close()
+	second := httptest.NewServer(http.HandlerFunc(handler))
+	defer second.Close()
+
+	api, err := NewEurekaAPIClient([]string{first.URL, second.URL}, WithJSON(second.URL))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	app, err := api.GetApplication(context.Background(), "FOO")
+	if err != nil {
+		t.Fatalf("GetApplication returned error: %v", err)
+	}
+	if len(app.Instance) != 1 || app.Instance[0].InstanceID != "foo-1" {
+		t.Errorf("GetApplication() = %+v; want foo-1 decoded from JSON", app)
+	}
+	if len(accepts) != 2 || accepts[0] != xmlAccept || accepts[1] != jsonAccept {
+		t.Errorf("Accept headers = %q; want XML for the first node and JSON for the second", accepts)
+	}
+}
+
+func TestHeartbeatResult(t *testing.T) {
+	serverTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	conflict := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+		if !conflict {
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`<instance><instanceId>i-1</instanceId><leaseInfo><evictionDurationInSecs>90</evictionDurationInSecs><lastRenewalTimestamp>1714564800000</lastRenewalTimestamp></leaseInfo></instance>`))
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	result, err := client.Heartbeat(context.Background(), "FOO", "i-1", 0)
+	if err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+	if !result.Found || !result.ServerTime.Equal(serverTime) || result.Instance != nil {
+		t.Errorf("Heartbeat() = %+v; want found at the server's time without an instance", result)
+	}
+
+	conflict = true
+	result, err = client.Heartbeat(context.Background(), "FOO", "i-1", 1)
+	if !errors.Is(err, ErrDirtyTimestampConflict) {
+		t.Fatalf("Heartbeat on conflict returned %v; want ErrDirtyTimestampConflict", err)
+	}
+	if result.Instance == nil || result.LeaseDuration != 90*time.Second || !result.LastRenewal.Equal(serverTime) {
+		t.Errorf("Heartbeat() on conflict = %+v; want the server's instance and its 90s lease renewed at %s", result, serverTime)
+	}
+}
+
+func TestGetApplicationRemembersNotFound(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, server.URL)
+	WithClock(clk)(client)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := client.GetApplication(ctx, "foo"); !errors.Is(err, ErrApplicationNotFound) {
+			t.Fatalf("GetApplication returned %v; want ErrApplicationNotFound", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests; want 1", got)
+	}
+
+	clk.Advance(defaultNotFoundTTL)
+	_, _ = client.GetApplication(ctx, "FOO")
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests after the TTL; want 2", got)
+	}
+
+	if err := client.RegisterInstance(ctx, "FOO", &Instance{InstanceID: "foo-1"}); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	_, _ = client.GetApplication(ctx, "foo")
+	if got := requests.Load(); got != 4 {
+		t.Errorf("server received %d requests after registration; want 4", got)
+	}
+
+	WithNotFoundTTL(0)(client)
+	_, _ = client.GetApplication(ctx, "bar")
+	_, _ = client.GetApplication(ctx, "bar")
+	if got := requests.Load(); got != 6 {
+		t.Errorf("server received %d requests with the cache disabled; want 6", got)
+	}
+}
+
+func TestNodeQuarantine(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	var live atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		live.Add(1)
+	}))
+	defer server.Close()
+
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, dead.URL, server.URL)
+	WithClock(clk)(client)
+	WithNodeQuarantine(2, time.Minute)(client)
+
+	do := func() {
+		t.Helper()
+		resp, err := client.Do(context.Background(), http.MethodPut, "status", nil)
+		if err != nil {
+			t.Fatalf("Do returned error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	do()
+	if client.Nodes()[0].Quarantined {
+		t.Fatal("node quarantined after a single failure")
+	}
+	do()
+	nodes := client.Nodes()
+	if !nodes[0].Quarantined || nodes[0].ConsecutiveErrors != 2 {
+		t.Fatalf("dead node = %+v; want quarantined after 2 errors", nodes[0])
+	}
+	if nodes[1].Quarantined || nodes[1].LastSuccess.IsZero() {
+		t.Errorf("live node = %+v; want a recorded success", nodes[1])
+	}
+
+	do()
+	if got := client.Nodes()[0].ConsecutiveErrors; got != 2 {
+		t.Errorf("quarantined node was tried first; %d consecutive errors, want 2", got)
+	}
+
+	clk.Advance(time.Minute)
+	if client.Nodes()[0].Quarantined {
+		t.Error("node still quarantined after the quarantine expired")
+	}
+	do()
+	if got := client.Nodes()[0].ConsecutiveErrors; got != 3 {
+		t.Errorf("node not retried after quarantine; %d consecutive errors, want 3", got)
+	}
+	if got := live.Load(); got != 4 {
+		t.Errorf("live node received %d requests; want 4", got)
+	}
+}
+
+func TestRetryClassifier(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(599)
+	}))
+	defer gateway.Close()
+	var live atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		live.Add(1)
+	}))
+	defer server.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	client := newTestClient(t, gateway.URL, server.URL)
+	WithRetryClassifier(func(err error, resp *http.Response) RetryDecision {
+		if resp != nil && resp.StatusCode == 599 {
+			return RetryNextNode
+		}
+		return RetryDefault
+	})(client)
+	resp, err := client.Do(context.Background(), http.MethodPut, "status", nil)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || live.Load() != 1 {
+		t.Errorf("write answered %d with %d requests to the live node; want 200 after failing over", resp.StatusCode, live.Load())
+	}
+
+	client = newTestClient(t, dead.URL, server.URL)
+	WithRetryClassifier(func(error, *http.Response) RetryDecision { return DoNotRetry })(client)
+	if _, err := client.Do(context.Background(), http.MethodGet, "apps", nil); err == nil {
+		t.Error("Do succeeded; want the transport error of the first node")
+	}
+	if got := live.Load(); got != 1 {
+		t.Errorf("live node received %d requests; want 1", got)
+	}
+}
+
+func TestNodeConfig(t *testing.T) {
+	type seen struct{ user, pass, header string }
+	record := func(ch chan<- seen) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			user, pass, _ := r.BasicAuth()
+			ch <- seen{user, pass, r.Header.Get("X-Gateway-Key")}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+	plainSeen := make(chan seen, 1)
+	plain := httptest.NewServer(record(plainSeen))
+	defer plain.Close()
+	secureSeen := make(chan seen, 1)
+	secure := httptest.NewTLSServer(record(secureSeen))
+	defer secure.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(secure.Certificate())
+	api, err := NewEurekaAPIClient([]string{plain.URL, secure.URL},
+		WithWriteFanOut(),
+		WithNodeConfig(plain.URL, NodeConfig{
+			Username: "alice",
+			Password: "secret",
+			Header:   http.Header{"X-Gateway-Key": {"plain-key"}},
+		}),
+		WithNodeConfig(secure.URL+"/eureka", NodeConfig{
+			Header:    http.Header{"x-gateway-key": {"secure-key"}},
+			TLSConfig: &tls.Config{RootCAs: pool},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	if err := api.SetStatus(context.Background(), "FOO", "foo-1", UP); err != nil {
+		t.Fatalf("SetStatus returned error: %v", err)
+	}
+	if got, want := <-plainSeen, (seen{"alice", "secret", "plain-key"}); got != want {
+		t.Errorf("plain node saw %+v; want %+v", got, want)
+	}
+	if got, want := <-secureSeen, (seen{"", "", "secure-key"}); got != want {
+		t.Errorf("TLS node saw %+v; want %+v", got, want)
+	}
+
+	if _, err := NewEurekaAPIClient([]string{plain.URL}, WithNodeConfig("http://elsewhere", NodeConfig{})); err == nil {
+		t.Error("NewEurekaAPIClient accepted a node config for an unknown base URL")
+	}
+}
+
+func TestRoundRobinReads(t *testing.T) {
+	var counts [3]atomic.Int32
+	urls := make([]string, len(counts))
+	for i := range counts {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			counts[i].Add(1)
+		}))
+		defer server.Close()
+		urls[i] = server.URL
+	}
+
+	client := newTestClient(t, urls...)
+	WithReadNodeSelection(RoundRobinNodes)(client)
+	for range 6 {
+		resp, err := client.Do(context.Background(), http.MethodGet, "apps", nil)
+		if err != nil {
+			t.Fatalf("Do returned error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := client.Do(context.Background(), http.MethodPut, "status", nil)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	for i, want := range []int32{3, 2, 2} {
+		if got := counts[i].Load(); got != want {
+			t.Errorf("node %d received %d requests; want %d", i, got, want)
+		}
+	}
+}
+
+func TestReadURLs(t *testing.T) {
+	var writes, reads atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writes.Add(1)
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reads.Add(1)
+	}))
+	defer replica.Close()
+
+	api, err := NewEurekaAPIClient([]string{primary.URL}, WithReadURLs(replica.URL, replica.URL+"/eureka/v2"))
+	if err != nil {
+		t.Fatalf("NewEurekaAPIClient returned error: %v", err)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		resp, err := api.Do(context.Background(), method, "apps", nil)
+		if err != nil {
+			t.Fatalf("Do returned error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if writes.Load() != 1 || reads.Load() != 1 {
+		t.Errorf("primary received %d and replica %d requests; want 1 each", writes.Load(), reads.Load())
+	}
+	if got := len(api.Nodes()); got != 2 {
+		t.Errorf("Nodes() reported %d nodes; want 2", got)
+	}
+
+	if _, err := NewEurekaAPIClient([]string{primary.URL}, WithReadURLs("replica")); err == nil {
+		t.Error("NewEurekaAPIClient accepted an invalid read URL")
+	}
+}
+
+func TestMetadataQueryIsEscaped(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+	ctx := context.Background()
+
+	if err := client.UpdateMetadata(ctx, "FOO", "i-1", map[string]string{"build": "a&b=c", "owner": "team one"}); err != nil {
+		t.Fatalf("UpdateMetadata returned error: %v", err)
+	}
+	if err := client.DeleteMetadata(ctx, "FOO", "i-1", "build", "owner"); err != nil {
+		t.Fatalf("DeleteMetadata returned error: %v", err)
+	}
+	if err := client.DeleteMetadata(ctx, "FOO", "i-1"); err == nil {
+		t.Errorf("DeleteMetadata without keys returned nil")
+	}
+
+	want := []string{"build=a%26b%3Dc&owner=team+one", "build=&owner="}
+	if len(queries) != len(want) || queries[0] != want[0] || queries[1] != want[1] {
+		t.Errorf("metadata queries = %q; want %q", queries, want)
+	}
+}
+
+func TestGetApplicationByMetadata(t *testing.T) {
+	filtering := false
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/xml")
+		canary := `<instance><instanceId>i-2</instanceId><metadata><track>canary</track></metadata></instance>`
+		if filtering {
+			w.Write([]byte(`<application><name>FOO</name>` + canary + `</application>`))
+			return
+		}
+		w.Write([]byte(`<application><name>FOO</name><instance><instanceId>i-1</instanceId><metadata><track>stable</track></metadata></instance>` + canary + `</application>`))
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	for _, filtering = range []bool{false, true} {
+		app, err := client.GetApplicationByMetadata(context.Background(), "FOO", map[string]string{"track": "canary"})
+		if err != nil {
+			t.Fatalf("GetApplicationByMetadata returned error: %v", err)
+		}
+		if query != "metadata.track=canary" {
+			t.Errorf("query = %q; want metadata.track=canary", query)
+		}
+		if len(app.Instance) != 1 || app.Instance[0].InstanceID != "i-2" {
+			t.Errorf("instances with server filtering %t = %+v; want only i-2", filtering, app.Instance)
+		}
+	}
+}
+
+func TestRequestsAreLogged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := newTestClient(t, server.URL)
+	WithLogger(logger)(client)
+
+	inst := &Instance{HostName: "foo.local", App: "FOO", IPAddr: "10.0.0.1", Status: "UP"}
+	if err := client.RegisterInstance(context.Background(), "FOO", inst); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	for _, want := range []string{"operation=RegisterInstance", "node=" + server.URL, "method=POST", "path=/eureka/v2/apps/FOO", "status=204", "duration="} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("log %q does not contain %s", out.String(), want)
+		}
+	}
+
+	out.Reset()
+	WithLogger(slog.New(slog.NewTextHandler(&out, nil)))(client)
+	if err := client.RegisterInstance(context.Background(), "FOO", inst); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("request logged below the handler's level: %s", out.String())
+	}
+}
+
+func TestResponseLimits(t *testing.T) {
+	big := strings.Repeat("<application><name>FOO</name></application>", 100)
+	instance := "<instance><instanceId>i-1</instanceId><metadata>" +
+		strings.Repeat("<padding>xxxxxxxxxxxxxxxx</padding>", 100) + "</metadata></instance>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/i-1") {
+			w.Header().Set("Content-Length", strconv.Itoa(len(instance)))
+			io.WriteString(w, instance)
+			return
+		}
+		// Streamed without a length, so only reading the body tells.
+		io.WriteString(w, "<applications>")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, big+"</applications>")
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	if _, err := client.GetAllApplications(context.Background()); err != nil {
+		t.Fatalf("GetAllApplications within the default limit returned error: %v", err)
+	}
+
+	WithResponseLimits(ResponseLimits{Registry: 1024, Default: 512})(client)
+	if _, err := client.GetAllApplications(context.Background()); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("GetAllApplications returned %v; want ErrResponseTooLarge", err)
+	}
+	if _, err := client.GetInstance(context.Background(), "FOO", "i-1"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("GetInstance returned %v; want ErrResponseTooLarge", err)
+	}
+
+	WithResponseLimits(ResponseLimits{Operations: map[string]int64{"GetInstance": 1 << 20}})(client)
+	if _, err := client.GetInstance(context.Background(), "FOO", "i-1"); err != nil {
+		t.Errorf("GetInstance with a raised limit returned error: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package eurekaapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// replayableBody is a request body that can be sent any number of times, so
+// that a request can fail over to another node or be retried after the body
+// was consumed. Every request built from it gets a fresh reader and a GetBody
+// returning another one.
+type replayableBody struct {
+	data []byte
+}
+
+// bytesBody returns a body sending data, or nil for no body.
+func bytesBody(data []byte) *replayableBody {
+	if data == nil {
+		return nil
+	}
+	return &replayableBody{data: data}
+}
+
+func (b *replayableBody) open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b.data)), nil
+}
+
+// newRequest builds a request with a fresh copy of body. Build one request
+// per attempt; the body may be nil.
+func newRequest(ctx context.Context, method, url string, body *replayableBody) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil || body == nil {
+		return req, err
+	}
+	req.Body, _ = body.open()
+	req.GetBody = body.open
+	req.ContentLength = int64(len(body.data))
+	return req, nil
+}
@@ -0,0 +1,51 @@
+package eurekaapi
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned when a request failed and the retry
+// budget set with WithRetryBudget allows no further attempt.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// WithRetryBudget caps the retries of all requests together at maxRetries
+// per window. Failing over to another node and retrying a throttled response
+// both count as a retry. Once the budget is spent, requests are attempted
+// once, so retries cannot multiply the traffic of an outage. Without a budget
+// retries are only bounded per request.
+func WithRetryBudget(maxRetries int, window time.Duration) Option {
+	return func(c *EurekaAPIClient) {
+		c.retries = &retryBudget{max: maxRetries, window: window}
+	}
+}
+
+// retryBudget counts retries in a sliding window. A nil budget allows every
+// retry.
+type retryBudget struct {
+	max    int
+	window time.Duration
+
+	mu    sync.Mutex
+	spent []time.Time // oldest first
+}
+
+// allow spends one retry at now, and reports false if none is left.
+func (b *retryBudget) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	expired := 0
+	for expired < len(b.spent) && now.Sub(b.spent[expired]) >= b.window {
+		expired++
+	}
+	b.spent = b.spent[expired:]
+	if len(b.spent) >= b.max {
+		return false
+	}
+	b.spent = append(b.spent, now)
+	return true
+}
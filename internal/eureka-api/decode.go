@@ -0,0 +1,135 @@
+package eurekaapi
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unique"
+)
+
+const decodeBufferSize = 32 << 10
+
+// readerPool holds buffered readers used to decode response bodies. Registry
+// payloads are fetched repeatedly, so reusing the buffers avoids allocating a
+// fresh one for every refresh.
+var readerPool = sync.Pool{
+	New: func() any {
+		return bufio.NewReaderSize(nil, decodeBufferSize)
+	},
+}
+
+// ApplicationRoot selects which root element is accepted when decoding a
+// single application. Some Eureka versions wrap GET /apps/{appID} responses in
+// an <applications> element instead of returning a bare <application>.
+type ApplicationRoot int
+
+const (
+	// ApplicationRootAuto accepts both shapes.
+	ApplicationRootAuto ApplicationRoot = iota
+	// ApplicationRootApplication only accepts a bare <application>.
+	ApplicationRootApplication
+	// ApplicationRootApplications only accepts an <applications> wrapper.
+	ApplicationRootApplications
+)
+
+func withDecoder(r io.Reader, decode func(d *xml.Decoder) error) error {
+	br := readerPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	defer func() {
+		br.Reset(nil)
+		readerPool.Put(br)
+	}()
+	return decode(xml.NewDecoder(br))
+}
+
+func decodeXML(r io.Reader, v any) error {
+	return withDecoder(r, func(d *xml.Decoder) error {
+		return d.Decode(v)
+	})
+}
+
+// decodeApplication decodes a single application, accepting the root
+// elements allowed by root. When wrapped in <applications>, the application
+// named appID is picked.
+func decodeApplication(r io.Reader, appID string, root ApplicationRoot) (Application, error) {
+	var app Application
+	err := withDecoder(r, func(d *xml.Decoder) error {
+		start, err := firstStartElement(d)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case start.Name.Local == "application" && root != ApplicationRootApplications:
+			return d.DecodeElement(&app, &start)
+		case start.Name.Local == "applications" && root != ApplicationRootApplication:
+			var apps Applications
+			if err := d.DecodeElement(&apps, &start); err != nil {
+				return err
+			}
+			for _, a := range apps.Application {
+				if strings.EqualFold(a.Name, appID) {
+					app = a
+					return nil
+				}
+			}
+			if len(apps.Application) == 1 {
+				app = apps.Application[0]
+				return nil
+			}
+			return fmt.Errorf("application %s not found in <applications> response", appID)
+		default:
+			return fmt.Errorf("unexpected root element <%s>", start.Name.Local)
+		}
+	})
+	return app, err
+}
+
+func firstStartElement(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se, nil
+		}
+	}
+}
+
+// intern returns a canonical copy of s, so that the many repeated values in a
+// registry (statuses, app names, data center names) share one backing array
+// instead of each decoded instance retaining its own copy.
+func intern(s string) string {
+	if s == "" {
+		return s
+	}
+	return unique.Make(s).Value()
+}
+
+func internApplications(apps *Applications) {
+	for i := range apps.Application {
+		internApplication(&apps.Application[i])
+	}
+}
+
+func internApplication(app *Application) {
+	app.Name = intern(app.Name)
+	for i := range app.Instance {
+		internInstance(&app.Instance[i])
+	}
+}
+
+func internInstance(inst *Instance) {
+	inst.App = intern(inst.App)
+	inst.Status = intern(inst.Status)
+	inst.OverriddenStatus = intern(inst.OverriddenStatus)
+	inst.VipAddress = intern(inst.VipAddress)
+	inst.SecureVipAddress = intern(inst.SecureVipAddress)
+	inst.ActionType = ActionType(intern(string(inst.ActionType)))
+	inst.DataCenterInfo.Class = intern(inst.DataCenterInfo.Class)
+	inst.DataCenterInfo.Name = intern(inst.DataCenterInfo.Name)
+}
@@ -0,0 +1,77 @@
+package eurekaapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// decodeSnippetSize is how much of a response body a DecodeError quotes.
+const decodeSnippetSize = 256
+
+// DecodeError is returned when a response body could not be decoded. It
+// carries the Content-Type and the start of the body, which usually tell
+// what went wrong, e.g. that a gateway answered with an HTML error page.
+type DecodeError struct {
+	ContentType string
+	// Body holds up to the first 256 bytes of the body, with credentials
+	// redacted.
+	Body string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%v (Content-Type %q, empty body)", e.Err, e.ContentType)
+	}
+	return fmt.Sprintf("%v (Content-Type %q, body starts with %q)", e.Err, e.ContentType, e.Body)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// snippetReader remembers the first bytes read through it.
+type snippetReader struct {
+	r    io.Reader
+	head []byte
+}
+
+func (s *snippetReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if room := decodeSnippetSize - len(s.head); room > 0 {
+		s.head = append(s.head, p[:min(n, room)]...)
+	}
+	return n, err
+}
+
+// decodeBody runs decode on the body of resp and, if it fails, wraps the
+// error in a DecodeError describing the body.
+func decodeBody(resp *http.Response, decode func(io.Reader) error) error {
+	body := &snippetReader{r: resp.Body}
+	err := decode(body)
+	if err == nil {
+		return nil
+	}
+	return &DecodeError{
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        redact(string(body.head)),
+		Err:         err,
+	}
+}
+
+var (
+	// secretPattern matches values of keys that commonly hold credentials,
+	// in query strings, JSON, XML and header dumps alike.
+	secretPattern = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|authorization|api[-_]?key)["']?\s*(?:[:=]|>)\s*["']?)[^"'\s<&,;]+`)
+	// userinfoPattern matches credentials embedded in URLs.
+	userinfoPattern = regexp.MustCompile(`://[^/@\s"'<>]+@`)
+)
+
+// redact masks credentials that may appear in an error page, such as a
+// gateway echoing the request.
+func redact(s string) string {
+	s = secretPattern.ReplaceAllString(s, "${1}[REDACTED]")
+	return userinfoPattern.ReplaceAllString(s, "://[REDACTED]@")
+}
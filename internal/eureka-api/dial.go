@@ -0,0 +1,55 @@
+package eurekaapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WithDNSResolver sets the resolver used to look up Eureka hosts, e.g. one
+// that queries an internal DNS server.
+func WithDNSResolver(resolver *net.Resolver) Option {
+	return func(c *EurekaAPIClient) {
+		for _, d := range c.dialers {
+			d.Resolver = resolver
+		}
+	}
+}
+
+// WithDualStackFallbackDelay tunes dual-stack dialing of Eureka hosts that
+// have both A and AAAA records. Connections are raced as in Happy Eyeballs
+// (RFC 6555): the fallback address family is tried once the preferred one
+// hasn't connected within delay. Zero keeps the default of 300 milliseconds
+// and a negative delay disables the fallback race.
+func WithDualStackFallbackDelay(delay time.Duration) Option {
+	return func(c *EurekaAPIClient) {
+		for _, d := range c.dialers {
+			d.FallbackDelay = delay
+		}
+	}
+}
+
+// DialFunc dials a connection to a Eureka node, like net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// WithDialContext replaces how connections to Eureka are dialed, e.g. to
+// reach it through a local Unix domain socket proxy.
+func WithDialContext(dial DialFunc) Option {
+	return func(c *EurekaAPIClient) {
+		for _, client := range []*http.Client{c.client, c.heartbeatClient} {
+			if t, ok := client.Transport.(*http.Transport); ok {
+				t.DialContext = dial
+			}
+		}
+	}
+}
+
+// UnixSocketDialer returns a DialFunc connecting every request to the Unix
+// domain socket at path, whatever host the base URL names.
+func UnixSocketDialer(path string) DialFunc {
+	var d net.Dialer
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return d.DialContext(ctx, "unix", path)
+	}
+}
@@ -0,0 +1,93 @@
+package eurekaapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// NodeSelection chooses which base URL a read is sent to first.
+type NodeSelection int
+
+const (
+	// PrimaryWithFailOver sends reads to the first base URL and only moves
+	// on to the next ones when it fails.
+	PrimaryWithFailOver NodeSelection = iota
+	// RoundRobinNodes starts each read at the next base URL in turn,
+	// spreading the query load over the cluster. Failed reads still fail
+	// over.
+	RoundRobinNodes
+)
+
+// WithReadNodeSelection sets how reads pick the base URL they start at.
+// Writes always start at the first one. Defaults to PrimaryWithFailOver.
+func WithReadNodeSelection(selection NodeSelection) Option {
+	return func(c *EurekaAPIClient) {
+		c.readSelection = selection
+	}
+}
+
+// defaultReadFailOverClasses makes reads try the next node when one answers
+// with a server error.
+var defaultReadFailOverClasses = []int{5}
+
+// WithReadFailOver sets the response status classes (5 for 5xx, 4 for 4xx)
+// that make an idempotent read try the next node, in addition to transport
+// errors. Without arguments, reads only fail over on transport errors.
+func WithReadFailOver(classes ...int) Option {
+	return func(c *EurekaAPIClient) {
+		c.readFailOverClasses = classes
+	}
+}
+
+// doReadRequest is doRequestWithFailOver for idempotent requests: a response
+// in one of the configured status classes is treated like a transport error
+// unless it comes from the last node.
+func (c *EurekaAPIClient) doReadRequest(ctx context.Context, doRequest func(ctx context.Context, baseURL string) (*http.Response, error)) (*http.Response, error) {
+	return c.failOver(ctx, c.nodes.order(c.readOrder(), c.clock.Now()), c.readFailOverStatus, doRequest)
+}
+
+// readOrder returns the base URLs in the order a read tries them.
+func (c *EurekaAPIClient) readOrder() []string {
+	urls := c.readURLsOrBase()
+	if c.readSelection != RoundRobinNodes || len(urls) == 1 {
+		return urls
+	}
+	start := int((c.readCounter.Add(1) - 1) % uint64(len(urls)))
+	return append(slices.Clone(urls[start:]), urls[:start]...)
+}
+
+func (c *EurekaAPIClient) readFailOverStatus(resp *http.Response) bool {
+	return slices.Contains(c.readFailOverClasses, resp.StatusCode/100)
+}
+
+func isIdempotentRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// attemptContext bounds one of attemptsLeft attempts to its share of the time
+// left until the deadline of ctx, so that a slow node can't use up the whole
+// budget and leave none for failing over. The last attempt gets all that is
+// left.
+func (c *EurekaAPIClient) attemptContext(ctx context.Context, attemptsLeft int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || attemptsLeft <= 1 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, deadline.Sub(c.clock.Now())/time.Duration(attemptsLeft))
+}
+
+// cancelOnClose releases the context of an attempt once the body of its
+// response has been consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
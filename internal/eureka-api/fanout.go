@@ -0,0 +1,82 @@
+package eurekaapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithWriteFanOut sends registration, heartbeat, status and metadata writes
+// to every base URL instead of only the first one that answers. It is meant
+// for standalone Eureka peers that don't replicate between each other.
+func WithWriteFanOut() Option {
+	return func(c *EurekaAPIClient) {
+		c.fanOutWrites = true
+	}
+}
+
+func (c *EurekaAPIClient) doWriteRequest(ctx context.Context, doRequest func(ctx context.Context, baseURL string) (*http.Response, error)) (*http.Response, error) {
+	if !c.fanOutWrites || len(c.bases()) == 1 {
+		return c.doRequestWithFailOver(ctx, doRequest)
+	}
+	return c.doRequestOnAll(ctx, doRequest)
+}
+
+// doRequestOnAll sends the request to every base URL concurrently. If any node
+// answered with a non-2xx status, that response is returned so the caller
+// reacts to it (e.g. re-registers after a 404); otherwise the first successful
+// response is returned. Transport errors are only reported if no node answered.
+func (c *EurekaAPIClient) doRequestOnAll(ctx context.Context, doRequest func(ctx context.Context, baseURL string) (*http.Response, error)) (*http.Response, error) {
+	baseURLs := c.bases()
+	resps := make([]*http.Response, len(baseURLs))
+	errs := make([]error, len(baseURLs))
+
+	var wg sync.WaitGroup
+	for i, baseURL := range baseURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = doRequest(ctx, baseURL)
+			switch {
+			case errs[i] != nil:
+				c.nodes.failure(baseURL, c.clock.Now(), errs[i])
+				errs[i] = fmt.Errorf("request to %s failed: %w", baseURL, errs[i])
+			case resps[i].StatusCode >= http.StatusInternalServerError:
+				// The node answered, but isn't healthy; the response is
+				// still handed on below.
+				c.nodes.failure(baseURL, c.clock.Now(), fmt.Errorf("unexpected response status: %s", resps[i].Status))
+			default:
+				c.nodes.success(baseURL, c.clock.Now())
+			}
+		}()
+	}
+	wg.Wait()
+
+	chosen := -1
+	for i, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		if chosen == -1 || (isSuccess(resps[chosen]) && !isSuccess(resp)) {
+			chosen = i
+		}
+	}
+	if chosen == -1 {
+		return nil, errors.Join(errs...)
+	}
+
+	for i, resp := range resps {
+		if i != chosen && resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	return resps[chosen], nil
+}
+
+func isSuccess(resp *http.Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode <= 299
+}
@@ -0,0 +1,58 @@
+package eurekaapi
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompress makes the body of resp readable if it is gzipped although the
+// transport didn't decompress it. That happens when Accept-Encoding is set by
+// hand, e.g. in NodeConfig.Header, and with older servers that send gzip
+// under a nonstandard or missing Content-Encoding. The body is recognized by
+// its magic bytes, so plain bodies pass through unchanged.
+func decompress(resp *http.Response) {
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip", "x-gzip", "application/x-gzip":
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	resp.Body = &gunzipBody{body: resp.Body}
+}
+
+// gunzipBody decompresses the body it wraps if it starts with the gzip magic
+// bytes. It only looks at them on the first read, so that the caller isn't
+// blocked before reading.
+type gunzipBody struct {
+	body io.ReadCloser
+	r    io.Reader
+	err  error
+}
+
+func (b *gunzipBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.r == nil {
+		br := bufio.NewReader(b.body)
+		b.r = br
+		if head, _ := br.Peek(len(gzipMagic)); bytes.Equal(head, gzipMagic) {
+			if b.r, b.err = gzip.NewReader(br); b.err != nil {
+				return 0, b.err
+			}
+		}
+	}
+	return b.r.Read(p)
+}
+
+func (b *gunzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,30 @@
+package eurekaapi
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ReconcileHashCode computes the registry hashcode the way Eureka servers do:
+// the count of instances per status, ordered by status name, formatted as
+// "DOWN_1_UP_4_". Comparing it with the apps__hashcode reported by the server
+// reveals whether a local copy of the registry has drifted.
+func (a Applications) ReconcileHashCode() string {
+	counts := make(map[string]int)
+	for _, app := range a.Application {
+		for _, inst := range app.Instance {
+			counts[inst.Status]++
+		}
+	}
+
+	var b strings.Builder
+	for _, status := range slices.Sorted(maps.Keys(counts)) {
+		b.WriteString(status)
+		b.WriteByte('_')
+		b.WriteString(strconv.Itoa(counts[status]))
+		b.WriteByte('_')
+	}
+	return b.String()
+}
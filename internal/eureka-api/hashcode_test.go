@@ -0,0 +1,20 @@
+package eurekaapi
+
+import "testing"
+
+func TestReconcileHashCode(t *testing.T) {
+	apps := Applications{
+		Application: []Application{
+			{Name: "FOO", Instance: []Instance{{Status: UP}, {Status: DOWN}, {Status: UP}}},
+			{Name: "BAR", Instance: []Instance{{Status: UP}, {Status: OUT_OF_SERVICE}}},
+		},
+	}
+
+	expected := "DOWN_1_OUT_OF_SERVICE_1_UP_3_"
+	if result := apps.ReconcileHashCode(); result != expected {
+		t.Errorf("ReconcileHashCode() = %q; want %q", result, expected)
+	}
+	if result := (Applications{}).ReconcileHashCode(); result != "" {
+		t.Errorf("ReconcileHashCode() of empty registry = %q; want empty", result)
+	}
+}
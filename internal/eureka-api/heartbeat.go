@@ -0,0 +1,82 @@
+package eurekaapi
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const defaultHeartbeatTimeout = 5 * time.Second
+
+// newHeartbeatClient builds the HTTP client heartbeats are sent with. It has
+// its own small connection pool and short timeouts, so a hung registry fetch
+// or an exhausted pool on the main client cannot delay a lease renewal.
+func newHeartbeatClient(dialer *net.Dialer) *http.Client {
+	return &http.Client{
+		Timeout: defaultHeartbeatTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			MaxIdleConnsPerHost:   1,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   2 * time.Second,
+			ResponseHeaderTimeout: defaultHeartbeatTimeout,
+		},
+	}
+}
+
+// WithHeartbeatHTTPClient replaces the dedicated client heartbeats are sent
+// with.
+func WithHeartbeatHTTPClient(client *http.Client) Option {
+	return func(c *EurekaAPIClient) {
+		if client != nil {
+			c.heartbeatClient = client
+		}
+	}
+}
+
+// HeartbeatResult describes the server's answer to a heartbeat.
+type HeartbeatResult struct {
+	// Found is false when the server doesn't know the instance, typically
+	// because its lease expired.
+	Found bool
+	// ServerTime is the time the server answered at, from its Date header.
+	// It is zero if the header is missing.
+	ServerTime time.Time
+	// RoundTrip is how long the heartbeat took, including retries.
+	RoundTrip time.Duration
+	// Instance is the server's copy of the instance, if the response carried
+	// one, e.g. on a lastDirtyTimestamp conflict.
+	Instance *Instance
+	// LeaseDuration is the duration of the renewed lease, if the response
+	// carried lease info.
+	LeaseDuration time.Duration
+	// LastRenewal is when the server renewed the lease, if the response
+	// carried lease info.
+	LastRenewal time.Time
+}
+
+// newHeartbeatResult reads what the heartbeat response carries besides its
+// status.
+func newHeartbeatResult(resp *http.Response, roundTrip time.Duration) HeartbeatResult {
+	result := HeartbeatResult{RoundTrip: roundTrip}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		result.ServerTime = date
+	}
+	if resp.ContentLength == 0 || resp.Header.Get("Content-Type") == "" {
+		return result
+	}
+	inst, err := decodeInstanceResponse(resp)
+	if err != nil {
+		// The body is informational; the status decides the outcome.
+		return result
+	}
+	result.Instance = &inst
+	if inst.LeaseInfo != nil {
+		result.LeaseDuration = inst.LeaseInfo.Duration()
+		if inst.LeaseInfo.LastRenewalTimestamp > 0 {
+			result.LastRenewal = time.UnixMilli(inst.LeaseInfo.LastRenewalTimestamp)
+		}
+	}
+	return result
+}
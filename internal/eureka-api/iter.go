@@ -0,0 +1,27 @@
+package eurekaapi
+
+import "iter"
+
+// All yields every instance of every application.
+func (apps Applications) All() iter.Seq[Instance] {
+	return func(yield func(Instance) bool) {
+		for _, app := range apps.Application {
+			for _, inst := range app.Instance {
+				if !yield(inst) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// All yields the instances of the application.
+func (app Application) All() iter.Seq[Instance] {
+	return func(yield func(Instance) bool) {
+		for _, inst := range app.Instance {
+			if !yield(inst) {
+				return
+			}
+		}
+	}
+}
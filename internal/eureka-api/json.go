@@ -0,0 +1,246 @@
+package eurekaapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonAccept      = "application/json"
+	jsonContentType = "application/json"
+)
+
+// WithJSON asks the given base URLs, or every one if none is given, for JSON
+// instead of XML, e.g. for a server known to produce malformed XML. Responses
+// are decoded according to their Content-Type either way.
+func WithJSON(baseURLs ...string) Option {
+	return func(c *EurekaAPIClient) {
+		if len(baseURLs) == 0 {
+			c.jsonAll = true
+			return
+		}
+		if c.jsonNodes == nil {
+			c.jsonNodes = make(map[string]bool, len(baseURLs))
+		}
+		for _, u := range baseURLs {
+			if norm, err := normalizeBaseURL(u); err == nil {
+				u = norm
+			}
+			c.jsonNodes[u] = true
+		}
+	}
+}
+
+// accept returns the Accept header for requests to baseURL.
+func (c *EurekaAPIClient) accept(baseURL string) string {
+	if c.jsonAll || c.jsonNodes[baseURL] {
+		return jsonAccept
+	}
+	return xmlAccept
+}
+
+func isJSON(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == jsonAccept || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeApplicationsResponse decodes a registry response in either format.
+func decodeApplicationsResponse(resp *http.Response) (Applications, error) {
+	var apps Applications
+	if !isJSON(resp) {
+		err := decodeBody(resp, func(r io.Reader) error { return decodeXML(r, &apps) })
+		return apps, err
+	}
+	var doc struct {
+		Applications Applications `json:"applications"`
+	}
+	err := decodeBody(resp, func(r io.Reader) error { return decodeJSON(r, &doc) })
+	return doc.Applications, err
+}
+
+// decodeInstanceResponse decodes an instance response in either format.
+func decodeInstanceResponse(resp *http.Response) (Instance, error) {
+	var inst Instance
+	if !isJSON(resp) {
+		err := decodeBody(resp, func(r io.Reader) error { return decodeXML(r, &inst) })
+		return inst, err
+	}
+	var doc struct {
+		Instance Instance `json:"instance"`
+	}
+	err := decodeBody(resp, func(r io.Reader) error { return decodeJSON(r, &doc) })
+	return doc.Instance, err
+}
+
+// decodeApplicationResponse is decodeApplication for responses in either
+// format.
+func decodeApplicationResponse(resp *http.Response, appID string, root ApplicationRoot) (Application, error) {
+	if !isJSON(resp) {
+		var app Application
+		err := decodeBody(resp, func(r io.Reader) (err error) {
+			app, err = decodeApplication(r, appID, root)
+			return err
+		})
+		return app, err
+	}
+	var doc struct {
+		Application  *Application  `json:"application"`
+		Applications *Applications `json:"applications"`
+	}
+	if err := decodeBody(resp, func(r io.Reader) error { return decodeJSON(r, &doc) }); err != nil {
+		return Application{}, err
+	}
+	switch {
+	case doc.Application != nil && root != ApplicationRootApplications:
+		return *doc.Application, nil
+	case doc.Applications != nil && root != ApplicationRootApplication:
+		for _, a := range doc.Applications.Application {
+			if strings.EqualFold(a.Name, appID) {
+				return a, nil
+			}
+		}
+		if len(doc.Applications.Application) == 1 {
+			return doc.Applications.Application[0], nil
+		}
+		return Application{}, fmt.Errorf("application %s not found in applications response", appID)
+	default:
+		return Application{}, fmt.Errorf("unexpected JSON response for application %s", appID)
+	}
+}
+
+func decodeJSON(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// Eureka's JSON is produced by Java serializers that differ between versions:
+// numbers and booleans may be quoted, and lists with a single element may be
+// written as that element. The decoders below accept every variant.
+
+// jsonString accepts a JSON string, number or boolean as a string.
+type jsonString string
+
+func (s *jsonString) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		return json.Unmarshal(data, (*string)(s))
+	}
+	if string(data) == "null" {
+		*s = ""
+		return nil
+	}
+	*s = jsonString(data)
+	return nil
+}
+
+// int parses s as an integer; empty is zero.
+func (s jsonString) int() (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(s), 10, 64)
+}
+
+// bool parses s as a boolean; empty is false.
+func (s jsonString) bool() (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(string(s))
+}
+
+// jsonList accepts a JSON array, or a single object standing for a list of
+// one.
+type jsonList[T any] []T
+
+func (l *jsonList[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*l = jsonList[T]{v}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]T)(l))
+}
+
+func (p *Port) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Enabled jsonString `json:"@enabled"`
+		Value   jsonString `json:"$"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	p.Enabled = aux.Enabled == "true"
+	if aux.Value == "" {
+		p.Value = 0
+		return nil
+	}
+	_, err := fmt.Sscan(string(aux.Value), &p.Value)
+	return err
+}
+
+func (inst *Instance) UnmarshalJSON(data []byte) error {
+	type plain Instance
+	aux := struct {
+		*plain
+		IsCoordinatingDiscovery jsonString `json:"isCoordinatingDiscoveryServer"`
+		LastUpdatedTimestamp    jsonString `json:"lastUpdatedTimestamp"`
+		LastDirtyTimestamp      jsonString `json:"lastDirtyTimestamp"`
+		CountryID               jsonString `json:"countryId"`
+	}{plain: (*plain)(inst)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if inst.IsCoordinatingDiscovery, err = aux.IsCoordinatingDiscovery.bool(); err != nil {
+		return fmt.Errorf("invalid isCoordinatingDiscoveryServer: %w", err)
+	}
+	if inst.LastUpdatedTimestamp, err = aux.LastUpdatedTimestamp.int(); err != nil {
+		return fmt.Errorf("invalid lastUpdatedTimestamp: %w", err)
+	}
+	if inst.LastDirtyTimestamp, err = aux.LastDirtyTimestamp.int(); err != nil {
+		return fmt.Errorf("invalid lastDirtyTimestamp: %w", err)
+	}
+	countryID, err := aux.CountryID.int()
+	if err != nil {
+		return fmt.Errorf("invalid countryId: %w", err)
+	}
+	inst.CountryID = int(countryID)
+	return nil
+}
+
+func (app *Application) UnmarshalJSON(data []byte) error {
+	type plain Application
+	aux := struct {
+		*plain
+		Instance jsonList[Instance] `json:"instance"`
+	}{plain: (*plain)(app)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	app.Instance = aux.Instance
+	return nil
+}
+
+func (apps *Applications) UnmarshalJSON(data []byte) error {
+	type plain Applications
+	aux := struct {
+		*plain
+		VersionsDelta jsonString            `json:"versions__delta"`
+		Application   jsonList[Application] `json:"application"`
+	}{plain: (*plain)(apps)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	apps.VersionsDelta = string(aux.VersionsDelta)
+	apps.Application = aux.Application
+	return nil
+}
@@ -0,0 +1,114 @@
+package eurekaapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// for its operation.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ResponseLimits caps the size of response bodies, so that a misbehaving
+// server or gateway can't make the client buffer arbitrary amounts of data.
+// Zero fields take their defaults.
+type ResponseLimits struct {
+	// Registry applies to registry queries, which return whole applications,
+	// and to Do. Defaults to 256 MiB.
+	Registry int64
+	// Default applies to all other operations, e.g. heartbeats, status and
+	// metadata updates. Defaults to 1 MiB.
+	Default int64
+	// Operations overrides the limit of single operations, keyed by method
+	// name, e.g. "GetInstance".
+	Operations map[string]int64
+}
+
+// DefaultResponseLimits are the limits applied unless WithResponseLimits is
+// used.
+var DefaultResponseLimits = ResponseLimits{
+	Registry: 256 << 20,
+	Default:  1 << 20,
+}
+
+// registryOperations are the operations limited by ResponseLimits.Registry.
+var registryOperations = map[string]bool{
+	"Do":                       true,
+	"GetAllApplications":       true,
+	"GetDelta":                 true,
+	"GetApplication":           true,
+	"GetApplicationByMetadata": true,
+	"GetByVIP":                 true,
+	"GetBySecureVIP":           true,
+}
+
+// WithResponseLimits sets the maximum response body size per operation.
+func WithResponseLimits(limits ResponseLimits) Option {
+	if limits.Registry <= 0 {
+		limits.Registry = DefaultResponseLimits.Registry
+	}
+	if limits.Default <= 0 {
+		limits.Default = DefaultResponseLimits.Default
+	}
+	return func(c *EurekaAPIClient) {
+		c.responseLimits = limits
+	}
+}
+
+// limit returns the maximum response body size of op.
+func (l ResponseLimits) limit(op string) int64 {
+	if n, ok := l.Operations[op]; ok && n > 0 {
+		return n
+	}
+	if registryOperations[op] {
+		return l.Registry
+	}
+	return l.Default
+}
+
+// limitResponse makes reading the body of resp fail once it exceeds the limit
+// of the operation req belongs to, counting decompressed bytes. Responses
+// declaring a larger length are rejected right away.
+func (c *EurekaAPIClient) limitResponse(req *http.Request, resp *http.Response) error {
+	op, _ := req.Context().Value(operationKey{}).(string)
+	limit := c.responseLimits.limit(op)
+	if resp.ContentLength > limit {
+		resp.Body.Close()
+		return fmt.Errorf("%w: %s response of %d bytes exceeds the limit of %d", ErrResponseTooLarge, op, resp.ContentLength, limit)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, op: op, limit: limit, remaining: limit}
+	return nil
+}
+
+// limitedBody fails reads once more than limit bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	op        string
+	limit     int64
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.tooLarge()
+	}
+	// Read one byte more than allowed to tell a body of exactly the limit
+	// from a larger one.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	n = int(b.remaining)
+	b.remaining = -1
+	return n, b.tooLarge()
+}
+
+func (b *limitedBody) tooLarge() error {
+	return fmt.Errorf("%w: %s response exceeds the limit of %d bytes", ErrResponseTooLarge, b.op, b.limit)
+}
@@ -0,0 +1,47 @@
+package eurekaapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithLogger logs every request sent to a Eureka server at debug level, with
+// the operation, node, method, path, status and duration. Requests are not
+// logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *EurekaAPIClient) {
+		c.logger = logger
+	}
+}
+
+type operationKey struct{}
+
+// withOperation names the API operation requests made with ctx belong to,
+// for the logs.
+func withOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// logRequest logs a request that got resp or failed with err after elapsed.
+func (c *EurekaAPIClient) logRequest(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	ctx := req.Context()
+	if c.logger == nil || !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	op, _ := ctx.Value(operationKey{}).(string)
+	attrs := []slog.Attr{
+		slog.String("operation", op),
+		slog.String("node", req.URL.Scheme+"://"+req.URL.Host),
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "eureka request", attrs...)
+}
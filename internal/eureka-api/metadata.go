@@ -0,0 +1,69 @@
+package eurekaapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"maps"
+	"slices"
+)
+
+// NewMetadata builds instance metadata from kv. Entries are sorted by key so
+// the marshaled payload is stable.
+func NewMetadata(kv map[string]string) *Metadata {
+	if len(kv) == 0 {
+		return nil
+	}
+	m := &Metadata{Entries: make([]MetaEntry, 0, len(kv))}
+	for _, k := range slices.Sorted(maps.Keys(kv)) {
+		m.Entries = append(m.Entries, MetaEntry{
+			XMLName: xml.Name{Local: k},
+			Value:   kv[k],
+		})
+	}
+	return m
+}
+
+// Map returns the metadata entries keyed by name.
+func (m *Metadata) Map() map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	kv := make(map[string]string, len(m.Entries))
+	for _, e := range m.Entries {
+		kv[e.XMLName.Local] = e.Value
+	}
+	return kv
+}
+
+// Get returns the value of the metadata entry with the given key.
+func (m *Metadata) Get(key string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	for _, e := range m.Entries {
+		if e.XMLName.Local == key {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// MarshalJSON encodes the metadata as a flat object, as Eureka does.
+func (m *Metadata) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Map())
+}
+
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var kv map[string]string
+	if err := json.Unmarshal(data, &kv); err != nil {
+		return err
+	}
+	// Jackson names the Java map type of empty metadata.
+	delete(kv, "@class")
+	if decoded := NewMetadata(kv); decoded != nil {
+		*m = *decoded
+	} else {
+		*m = Metadata{}
+	}
+	return nil
+}
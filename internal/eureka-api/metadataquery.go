@@ -0,0 +1,56 @@
+package eurekaapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// metadataQueryPrefix prefixes the metadata keys in the query parameters of
+// servers that filter instances by metadata.
+const metadataQueryPrefix = "metadata."
+
+// GetApplicationByMetadata returns the instances of an application whose
+// metadata has all the given entries. The filter is passed to the server as
+// metadata.{key}={value} query parameters, which some Eureka distributions
+// apply; as stock servers ignore them, the response is filtered again on the
+// client.
+func (c *EurekaAPIClient) GetApplicationByMetadata(ctx context.Context, appID string, metadata map[string]string) (Application, error) {
+	ctx = withOperation(ctx, "GetApplicationByMetadata")
+	if len(metadata) == 0 {
+		return c.GetApplication(ctx, appID)
+	}
+	if c.notFound.missing(appID, c.clock.Now()) {
+		return Application{}, fmt.Errorf("%w: %s", ErrApplicationNotFound, appID)
+	}
+
+	query := make(url.Values, len(metadata))
+	for k, v := range metadata {
+		query.Set(metadataQueryPrefix+k, v)
+	}
+	app, err := c.appFlight.do(ctx, "apps/"+appID+"?"+query.Encode(), func(ctx context.Context) (Application, error) {
+		return c.getApplication(ctx, appID, query)
+	})
+	if err != nil {
+		return Application{}, err
+	}
+
+	matching := make([]Instance, 0, len(app.Instance))
+	for _, inst := range app.Instance {
+		if MatchesMetadata(inst, metadata) {
+			matching = append(matching, inst)
+		}
+	}
+	app.Instance = matching
+	return app, nil
+}
+
+// MatchesMetadata reports whether inst has every entry of metadata.
+func MatchesMetadata(inst Instance, metadata map[string]string) bool {
+	for k, want := range metadata {
+		if v, ok := inst.Metadata.Get(k); !ok || v != want {
+			return false
+		}
+	}
+	return true
+}
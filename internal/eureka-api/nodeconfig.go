@@ -0,0 +1,158 @@
+package eurekaapi
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// NodeConfig holds settings for the requests to a single base URL, for
+// clusters whose nodes sit behind different gateways.
+type NodeConfig struct {
+	// Username and Password, if set, are sent as basic auth instead of any
+	// credentials in the base URL.
+	Username string
+	Password string
+	// Header is set on every request to the node.
+	Header http.Header
+	// TLSConfig replaces the TLS configuration of connections to the node,
+	// e.g. to present a client certificate. It requires the default
+	// transports.
+	TLSConfig *tls.Config
+}
+
+// WithNodeConfig applies cfg to the requests sent to baseURL, which must be
+// one of the base or read URLs of the client.
+func WithNodeConfig(baseURL string, cfg NodeConfig) Option {
+	return func(c *EurekaAPIClient) {
+		if c.nodeConfigs == nil {
+			c.nodeConfigs = make(map[string]NodeConfig)
+		}
+		c.nodeConfigs[baseURL] = cfg
+	}
+}
+
+// resolveNodeConfigs keys the node configs by normalized base URL and checks
+// that each one belongs to a base URL of the client.
+func (c *EurekaAPIClient) resolveNodeConfigs() error {
+	if len(c.nodeConfigs) == 0 {
+		return nil
+	}
+	resolved := make(map[string]NodeConfig, len(c.nodeConfigs))
+	for baseURL, cfg := range c.nodeConfigs {
+		norm, err := normalizeBaseURL(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL %q in node config: %w", baseURL, err)
+		}
+		if !slices.Contains(c.allURLs(), norm) {
+			return fmt.Errorf("node config for %q matches no base URL", baseURL)
+		}
+		resolved[norm] = cfg
+	}
+	c.nodeConfigs = resolved
+	return nil
+}
+
+// SetNodeConfigs replaces the node configs, e.g. to rotate credentials, for
+// the requests sent from now on. Their TLS configurations can't change, as the
+// transports are built from them at construction.
+func (c *EurekaAPIClient) SetNodeConfigs(configs map[string]NodeConfig) error {
+	resolved := make(map[string]NodeConfig, len(configs))
+	for baseURL, cfg := range configs {
+		norm, err := normalizeBaseURL(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL %q in node config: %w", baseURL, err)
+		}
+		resolved[norm] = cfg
+	}
+
+	c.nodeConfigsMu.Lock()
+	defer c.nodeConfigsMu.Unlock()
+	for baseURL, cfg := range resolved {
+		if cfg.TLSConfig != c.nodeConfigs[baseURL].TLSConfig {
+			return fmt.Errorf("TLS config for %s can't be changed at runtime", baseURL)
+		}
+	}
+	for baseURL, cfg := range c.nodeConfigs {
+		if _, ok := resolved[baseURL]; !ok && cfg.TLSConfig != nil {
+			return fmt.Errorf("TLS config for %s can't be changed at runtime", baseURL)
+		}
+	}
+	c.nodeConfigs = resolved
+	return nil
+}
+
+// nodeFor returns the config of the base URL req is sent to.
+func (c *EurekaAPIClient) nodeFor(req *http.Request) (NodeConfig, bool) {
+	c.nodeConfigsMu.RLock()
+	defer c.nodeConfigsMu.RUnlock()
+	u := req.URL.String()
+	for baseURL, cfg := range c.nodeConfigs {
+		if isUnder(u, baseURL) {
+			return cfg, true
+		}
+	}
+	return NodeConfig{}, false
+}
+
+// isUnder reports whether the request URL u was built from baseURL.
+func isUnder(u, baseURL string) bool {
+	return u == baseURL || strings.HasPrefix(u, baseURL+"/") || strings.HasPrefix(u, baseURL+"?")
+}
+
+// applyNodeConfig sets the credentials and headers configured for the node
+// req is sent to.
+func (c *EurekaAPIClient) applyNodeConfig(req *http.Request) {
+	cfg, ok := c.nodeFor(req)
+	if !ok {
+		return
+	}
+	for k, v := range cfg.Header {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	if cfg.Username != "" || cfg.Password != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+}
+
+// nodeRouter sends requests to nodes with their own TLS configuration through
+// a transport of their own.
+type nodeRouter struct {
+	next  http.RoundTripper
+	nodes map[string]http.RoundTripper // by base URL
+}
+
+func (r *nodeRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := req.URL.String()
+	for baseURL, rt := range r.nodes {
+		if isUnder(u, baseURL) {
+			return rt.RoundTrip(req)
+		}
+	}
+	return r.next.RoundTrip(req)
+}
+
+// routeNodes gives the nodes with a TLS configuration their own copy of rt.
+func (c *EurekaAPIClient) routeNodes(rt http.RoundTripper) (http.RoundTripper, error) {
+	router := &nodeRouter{next: rt, nodes: make(map[string]http.RoundTripper)}
+	c.nodeConfigsMu.RLock()
+	defer c.nodeConfigsMu.RUnlock()
+	for baseURL, cfg := range c.nodeConfigs {
+		if cfg.TLSConfig == nil {
+			continue
+		}
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("TLS config for %s requires the default HTTP transport", baseURL)
+		}
+		t = t.Clone()
+		t.TLSClientConfig = cfg.TLSConfig
+		router.nodes[baseURL] = t
+	}
+	if len(router.nodes) == 0 {
+		return rt, nil
+	}
+	return router, nil
+}
@@ -0,0 +1,106 @@
+package eurekaapi
+
+import (
+	"sync"
+	"time"
+)
+
+// NodeStatus describes the recent health of a single Eureka base URL.
+type NodeStatus struct {
+	URL               string    `json:"url"`
+	LastSuccess       time.Time `json:"lastSuccess,omitzero"`
+	LastFailure       time.Time `json:"lastFailure,omitzero"`
+	LastError         string    `json:"lastError,omitempty"`
+	ConsecutiveErrors int       `json:"consecutiveErrors"`
+	// Quarantined nodes are only tried after all others failed, until
+	// QuarantinedUntil; see WithNodeQuarantine.
+	Quarantined      bool      `json:"quarantined"`
+	QuarantinedUntil time.Time `json:"quarantinedUntil,omitzero"`
+}
+
+// WithNodeQuarantine moves a base URL to the back of the failover order for
+// duration once it failed failures times in a row, so that requests stop
+// waiting on a node that is down. A success lifts the quarantine. Zero
+// failures disables it, which is the default.
+func WithNodeQuarantine(failures int, duration time.Duration) Option {
+	return func(c *EurekaAPIClient) {
+		c.nodes.quarantineAfter = failures
+		c.nodes.quarantineFor = duration
+	}
+}
+
+type nodeTracker struct {
+	quarantineAfter int
+	quarantineFor   time.Duration
+
+	mu    sync.Mutex
+	nodes map[string]*NodeStatus
+}
+
+func (t *nodeTracker) node(baseURL string) *NodeStatus {
+	if t.nodes == nil {
+		t.nodes = make(map[string]*NodeStatus)
+	}
+	n, ok := t.nodes[baseURL]
+	if !ok {
+		n = &NodeStatus{URL: baseURL}
+		t.nodes[baseURL] = n
+	}
+	return n
+}
+
+func (t *nodeTracker) success(baseURL string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.node(baseURL)
+	n.LastSuccess = now
+	n.ConsecutiveErrors = 0
+	n.QuarantinedUntil = time.Time{}
+}
+
+func (t *nodeTracker) failure(baseURL string, now time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.node(baseURL)
+	n.LastFailure = now
+	n.LastError = err.Error()
+	n.ConsecutiveErrors++
+	if t.quarantineAfter > 0 && n.ConsecutiveErrors >= t.quarantineAfter {
+		n.QuarantinedUntil = now.Add(t.quarantineFor)
+	}
+}
+
+// order returns baseURLs with the quarantined ones moved to the back.
+func (t *nodeTracker) order(baseURLs []string, now time.Time) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.quarantineAfter <= 0 {
+		return baseURLs
+	}
+	healthy := make([]string, 0, len(baseURLs))
+	var quarantined []string
+	for _, baseURL := range baseURLs {
+		if now.Before(t.node(baseURL).QuarantinedUntil) {
+			quarantined = append(quarantined, baseURL)
+		} else {
+			healthy = append(healthy, baseURL)
+		}
+	}
+	return append(healthy, quarantined...)
+}
+
+// Nodes returns the status of every configured base URL, in failover order,
+// followed by the read URLs.
+func (c *EurekaAPIClient) Nodes() []NodeStatus {
+	c.nodes.mu.Lock()
+	defer c.nodes.mu.Unlock()
+	now := c.clock.Now()
+	urls := c.allURLs()
+	out := make([]NodeStatus, 0, len(urls))
+	for _, baseURL := range urls {
+		n := *c.nodes.node(baseURL)
+		n.Quarantined = now.Before(n.QuarantinedUntil)
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package eurekaapi
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultNotFoundTTL is how long a 404 for an application is remembered by
+// default.
+const defaultNotFoundTTL = 5 * time.Second
+
+// WithNotFoundTTL sets how long GetApplication remembers that an application
+// doesn't exist, answering repeated lookups with ErrApplicationNotFound
+// without asking the server. Defaults to 5 seconds; zero disables it.
+func WithNotFoundTTL(ttl time.Duration) Option {
+	return func(c *EurekaAPIClient) {
+		c.notFound.ttl = max(ttl, 0)
+	}
+}
+
+// notFoundCache remembers applications the server answered with 404, so
+// that callers hot-looping on a missing name don't hit Eureka every time.
+type notFoundCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	expires map[string]time.Time // by upper-cased app ID
+}
+
+// missing reports whether appID was found missing less than ttl before now.
+func (n *notFoundCache) missing(appID string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	key := strings.ToUpper(appID)
+	expires, ok := n.expires[key]
+	if ok && !now.Before(expires) {
+		delete(n.expires, key)
+		return false
+	}
+	return ok
+}
+
+func (n *notFoundCache) add(appID string, now time.Time) {
+	if n.ttl <= 0 {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.expires == nil {
+		n.expires = make(map[string]time.Time)
+	}
+	n.expires[strings.ToUpper(appID)] = now.Add(n.ttl)
+}
+
+// forget drops appID, e.g. once an instance of it is registered.
+func (n *notFoundCache) forget(appID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.expires, strings.ToUpper(appID))
+}
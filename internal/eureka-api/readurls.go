@@ -0,0 +1,50 @@
+package eurekaapi
+
+import (
+	"fmt"
+	"slices"
+)
+
+// WithReadURLs sends registry reads to readURLs instead of the base URLs,
+// e.g. to read-only Eureka replicas. Registrations, heartbeats and other
+// writes keep going to the base URLs.
+func WithReadURLs(readURLs ...string) Option {
+	return func(c *EurekaAPIClient) {
+		c.readURLs = append(c.readURLs, readURLs...)
+	}
+}
+
+// normalizeReadURLs normalizes the read URLs and drops duplicates.
+func (c *EurekaAPIClient) normalizeReadURLs() error {
+	norm := make([]string, 0, len(c.readURLs))
+	for _, u := range c.readURLs {
+		nu, err := normalizeBaseURL(u)
+		if err != nil {
+			return fmt.Errorf("invalid read URL %q: %w", u, err)
+		}
+		if !slices.Contains(norm, nu) {
+			norm = append(norm, nu)
+		}
+	}
+	c.readURLs = norm
+	return nil
+}
+
+// readURLsOrBase returns the URLs reads are sent to.
+func (c *EurekaAPIClient) readURLsOrBase() []string {
+	if len(c.readURLs) > 0 {
+		return c.readURLs
+	}
+	return c.bases()
+}
+
+// allURLs returns the base URLs followed by the read URLs not among them.
+func (c *EurekaAPIClient) allURLs() []string {
+	all := slices.Clone(c.bases())
+	for _, u := range c.readURLs {
+		if !slices.Contains(all, u) {
+			all = append(all, u)
+		}
+	}
+	return all
+}
@@ -0,0 +1,79 @@
+package eurekaapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ContextHeader maps a context value to a request header, so identifiers
+// such as trace or request IDs carried in the caller's context reach Eureka.
+type ContextHeader struct {
+	Key    any
+	Header string
+}
+
+// WithContextHeaders attaches the given context values as headers on every
+// request made with that context.
+func WithContextHeaders(headers ...ContextHeader) Option {
+	return func(c *EurekaAPIClient) {
+		c.contextHeaders = append(c.contextHeaders, headers...)
+	}
+}
+
+func (c *EurekaAPIClient) setContextHeaders(req *http.Request) {
+	ctx := req.Context()
+	for _, h := range c.contextHeaders {
+		if v := ctx.Value(h.Key); v != nil {
+			req.Header.Set(h.Header, fmt.Sprint(v))
+		}
+	}
+}
+
+// do sends req with the main HTTP client.
+func (c *EurekaAPIClient) do(req *http.Request) (*http.Response, error) {
+	return c.doWith(c.client, req)
+}
+
+// doWith sends req with client, backing off and retrying while the node
+// throttles it.
+func (c *EurekaAPIClient) doWith(client *http.Client, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(client, req)
+		if err != nil {
+			return nil, err
+		}
+		wait, retry := c.throttleWait(resp, attempt)
+		if !retry || !c.retries.allow(c.clock.Now()) {
+			return resp, nil
+		}
+		discard(resp)
+		if err := c.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send decorates, signs and sends req and records the observed server clock skew.
+func (c *EurekaAPIClient) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	c.setContextHeaders(req)
+	c.applyNodeConfig(req)
+	if err := c.sign(req); err != nil {
+		return nil, err
+	}
+
+	sent := c.clock.Now()
+	resp, err := client.Do(req)
+	c.logRequest(req, resp, err, c.clock.Since(sent))
+	if err != nil {
+		return nil, err
+	}
+	c.skew.observe(resp, sent, c.clock.Now())
+	decompress(resp)
+	if err := c.limitResponse(req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
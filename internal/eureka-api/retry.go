@@ -0,0 +1,46 @@
+package eurekaapi
+
+import "net/http"
+
+// RetryDecision tells whether a request moves on to the next base URL.
+type RetryDecision int
+
+const (
+	// RetryDefault leaves the decision to the built-in rules: transport
+	// errors always fail over, responses only for reads in one of the
+	// classes set with WithReadFailOver.
+	RetryDefault RetryDecision = iota
+	// RetryNextNode fails over to the next base URL.
+	RetryNextNode
+	// DoNotRetry returns the response or error as is.
+	DoNotRetry
+)
+
+// RetryClassifier classifies the outcome of a request sent to one base URL.
+// Exactly one of err and resp is non-nil. A classifier that reads the body of
+// resp must replace it, because the response may be returned to the caller.
+type RetryClassifier func(err error, resp *http.Response) RetryDecision
+
+// WithRetryClassifier lets site-specific conditions, such as a gateway's
+// custom status codes, decide whether a request fails over to the next base
+// URL. Outcomes it returns RetryDefault for are handled as usual.
+func WithRetryClassifier(classify RetryClassifier) Option {
+	return func(c *EurekaAPIClient) {
+		c.retryClassifier = classify
+	}
+}
+
+// shouldFailOver reports whether a request that ended in err or resp is
+// tried on the next base URL. failOverStatus, if not nil, reports the
+// responses that fail over by default.
+func (c *EurekaAPIClient) shouldFailOver(err error, resp *http.Response, failOverStatus func(*http.Response) bool) bool {
+	if c.retryClassifier != nil {
+		switch c.retryClassifier(err, resp) {
+		case RetryNextNode:
+			return true
+		case DoNotRetry:
+			return false
+		}
+	}
+	return err != nil || (failOverStatus != nil && failOverStatus(resp))
+}
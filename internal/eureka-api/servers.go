@@ -0,0 +1,38 @@
+package eurekaapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// bases returns the base URLs requests fail over between.
+func (c *EurekaAPIClient) bases() []string {
+	c.baseURLsMu.RLock()
+	defer c.baseURLsMu.RUnlock()
+	return c.baseURLs
+}
+
+// SetBaseURLs replaces the base URLs, e.g. as Eureka servers join or leave
+// the cluster. Requests already failing over keep the previous list. Node
+// configs and read URLs are left alone, and the health of URLs seen before is
+// remembered.
+func (c *EurekaAPIClient) SetBaseURLs(baseURLs []string) error {
+	if len(baseURLs) == 0 {
+		return errors.New("at least one Eureka base URL is required")
+	}
+	norm := make([]string, 0, len(baseURLs))
+	for _, u := range baseURLs {
+		nu, err := normalizeBaseURL(u)
+		if err != nil {
+			return fmt.Errorf("invalid base URL %q: %w", u, err)
+		}
+		if !slices.Contains(norm, nu) {
+			norm = append(norm, nu)
+		}
+	}
+	c.baseURLsMu.Lock()
+	defer c.baseURLsMu.Unlock()
+	c.baseURLs = norm
+	return nil
+}
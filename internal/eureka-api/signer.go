@@ -0,0 +1,51 @@
+package eurekaapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RequestSigner signs outgoing requests, e.g. by adding an HMAC header that a
+// gateway in front of Eureka verifies. It is called once per attempt, after
+// the request is fully built, with the request body.
+type RequestSigner interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// RequestSignerFunc adapts a function to the RequestSigner interface.
+type RequestSignerFunc func(req *http.Request, body []byte) error
+
+func (f RequestSignerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// WithRequestSigner sets the signer applied to every request.
+func WithRequestSigner(signer RequestSigner) Option {
+	return func(c *EurekaAPIClient) {
+		c.signer = signer
+	}
+}
+
+func (c *EurekaAPIClient) sign(req *http.Request) error {
+	if c.signer == nil {
+		return nil
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	}
+	if err := c.signer.Sign(req, body); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
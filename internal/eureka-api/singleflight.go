@@ -0,0 +1,122 @@
+package eurekaapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// flightGroup coalesces concurrent calls with the same key into a single
+// execution whose result is handed to every caller, in the spirit of
+// golang.org/x/sync/singleflight. The zero value is ready to use.
+//
+// The shared call doesn't end with the caller that started it: it runs on a
+// context that keeps the values of that caller's but is only cancelled once
+// every caller has given up, and whose deadline is the latest of theirs. Each
+// caller waits no longer than its own ctx.
+//
+// Callers sharing a result share its slices too, so results must be treated
+// as read-only.
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flight[T]
+}
+
+type flight[T any] struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	// waiters counts the callers still waiting, and deadline is the latest
+	// of their deadlines unless unbounded; both are guarded by the group's mu.
+	waiters   int
+	deadline  time.Time
+	unbounded bool
+
+	val      T
+	err      error
+	panicked any
+}
+
+// panicError carries a panic of a shared call to the callers waiting for it.
+type panicError struct {
+	value any
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("shared call panicked: %v", p.value)
+}
+
+func (g *flightGroup[T]) do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight[T])
+	}
+	f, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight[T]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = f
+		go g.run(&flightContext[T]{Context: callCtx, group: g, flight: f}, key, f, fn)
+	}
+	f.waiters++
+	if deadline, ok := ctx.Deadline(); !ok {
+		f.unbounded = true
+	} else if deadline.After(f.deadline) {
+		f.deadline = deadline
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.panicked != nil {
+			panic(&panicError{value: f.panicked})
+		}
+		return f.val, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody is left to use the result; later callers start afresh.
+			f.cancel()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// flightContext is the context of a shared call. Its deadline follows the
+// callers that join it.
+type flightContext[T any] struct {
+	context.Context
+	group  *flightGroup[T]
+	flight *flight[T]
+}
+
+func (c *flightContext[T]) Deadline() (time.Time, bool) {
+	c.group.mu.Lock()
+	defer c.group.mu.Unlock()
+	if c.flight.unbounded {
+		return time.Time{}, false
+	}
+	return c.flight.deadline, true
+}
+
+func (g *flightGroup[T]) run(ctx context.Context, key string, f *flight[T], fn func(ctx context.Context) (T, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.panicked = r
+		}
+		g.mu.Lock()
+		if g.calls[key] == f {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		f.cancel()
+		close(f.done)
+	}()
+	f.val, f.err = fn(ctx)
+}
@@ -0,0 +1,48 @@
+package eurekaapi
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// skewSmoothing is the weight given to a new clock skew sample. Date headers
+// only have second precision, so individual samples are noisy.
+const skewSmoothing = 0.2
+
+// skewTracker estimates the offset between the server's clock and ours from
+// the Date header of responses.
+type skewTracker struct {
+	skew    atomic.Int64 // nanoseconds, server minus local
+	samples atomic.Uint64
+}
+
+func (t *skewTracker) observe(resp *http.Response, sent, received time.Time) {
+	if resp == nil {
+		return
+	}
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	// Assume the server stamped the response halfway through the round trip.
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	sample := serverTime.Sub(midpoint)
+
+	if t.samples.Add(1) == 1 {
+		t.skew.Store(int64(sample))
+		return
+	}
+	prev := time.Duration(t.skew.Load())
+	t.skew.Store(int64(prev + time.Duration(skewSmoothing*float64(sample-prev))))
+}
+
+func (t *skewTracker) get() time.Duration {
+	return time.Duration(t.skew.Load())
+}
+
+// ClockSkew returns the estimated offset of the server's clock relative to
+// the local clock. A positive value means the server is ahead.
+func (c *EurekaAPIClient) ClockSkew() time.Duration {
+	return c.skew.get()
+}
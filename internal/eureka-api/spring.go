@@ -0,0 +1,102 @@
+package eurekaapi
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// DefaultDataCenterInfoClass is the class the Java client decodes MyOwn data
+// center info with.
+const DefaultDataCenterInfoClass = "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo"
+
+// WithSpringCompat enables workarounds for Spring Cloud Netflix Eureka
+// servers. Registrations are sent as JSON in the shape Jackson produces,
+// with ports written as {"$": 8080, "@enabled": "true"} and the data center
+// @class set, and statuses written in another casing, such as "up" or
+// "OutOfService", are normalized when decoding. JSON field names are matched
+// regardless of casing either way.
+func WithSpringCompat() Option {
+	return func(c *EurekaAPIClient) {
+		c.springCompat = true
+	}
+}
+
+// springPort is a Port encoded the way Jackson encodes Eureka's PortWrapper.
+type springPort Port
+
+func (p *springPort) MarshalJSON() ([]byte, error) {
+	enabled := "false"
+	if p.Enabled {
+		enabled = "true"
+	}
+	return json.Marshal(struct {
+		Value   int    `json:"$"`
+		Enabled string `json:"@enabled"`
+	}{p.Value, enabled})
+}
+
+// marshalSpringInstance encodes inst as the JSON registration body Spring
+// Cloud Netflix servers expect.
+func marshalSpringInstance(inst *Instance) ([]byte, error) {
+	type plain Instance
+	dataCenter := inst.DataCenterInfo
+	if dataCenter.Class == "" {
+		dataCenter.Class = DefaultDataCenterInfoClass
+		if dataCenter.Name == AmazonDataCenter {
+			dataCenter.Class = AmazonInfoClass
+		}
+	}
+	doc := struct {
+		Instance any `json:"instance"`
+	}{struct {
+		*plain
+		Port           *springPort `json:"port,omitempty"`
+		SecurePort     *springPort `json:"securePort,omitempty"`
+		DataCenterInfo DataCenter  `json:"dataCenterInfo"`
+	}{(*plain)(inst), (*springPort)(inst.Port), (*springPort)(inst.SecurePort), dataCenter}}
+	return json.Marshal(doc)
+}
+
+// springApplications normalizes the statuses in apps in Spring compatibility
+// mode.
+func (c *EurekaAPIClient) springApplications(apps *Applications) {
+	for i := range apps.Application {
+		c.springApplication(&apps.Application[i])
+	}
+}
+
+func (c *EurekaAPIClient) springApplication(app *Application) {
+	for i := range app.Instance {
+		c.springInstance(&app.Instance[i])
+	}
+}
+
+func (c *EurekaAPIClient) springInstance(inst *Instance) {
+	if !c.springCompat {
+		return
+	}
+	inst.Status = normalizeStatus(inst.Status)
+	inst.OverriddenStatus = normalizeStatus(inst.OverriddenStatus)
+}
+
+// normalizeStatus maps spellings such as "up", "out-of-service" or
+// "OutOfService" to the status constants. Unknown statuses are returned
+// unchanged.
+func normalizeStatus(status string) string {
+	var b strings.Builder
+	for i, r := range status {
+		switch {
+		case r == '-' || r == ' ':
+			r = '_'
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(rune(status[i-1])):
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	switch s := b.String(); s {
+	case UP, DOWN, STARTING, OUT_OF_SERVICE, UNKNOWN:
+		return s
+	}
+	return status
+}
@@ -0,0 +1,103 @@
+package eurekaapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultThrottleRetries = 2
+	defaultMaxThrottleWait = 10 * time.Second
+	// throttleBaseDelay is the backoff used when a throttled response carries
+	// no usable Retry-After header. It doubles with every attempt.
+	throttleBaseDelay = 500 * time.Millisecond
+)
+
+// WithThrottleBackoff controls how 429 and 503 responses are handled. Such a
+// response is retried against the same node up to retries times, waiting as
+// long as its Retry-After header asks, but never longer than maxWait. A
+// response asking for a longer wait is returned to the caller as is. Failing
+// over to the next node instead would only spread the load of a struggling
+// cluster. A retries value of zero disables the backoff.
+func WithThrottleBackoff(retries int, maxWait time.Duration) Option {
+	return func(c *EurekaAPIClient) {
+		c.throttleRetries = retries
+		c.maxThrottleWait = maxWait
+	}
+}
+
+func isThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// throttleWait reports how long to wait before retrying a throttled response,
+// and false if resp should be returned to the caller.
+func (c *EurekaAPIClient) throttleWait(resp *http.Response, attempt int) (time.Duration, bool) {
+	if !isThrottled(resp) || attempt >= c.throttleRetries {
+		return 0, false
+	}
+	wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now())
+	if !ok {
+		wait = throttleBaseDelay << attempt
+	}
+	if wait > c.maxThrottleWait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// parseRetryAfter parses a Retry-After value, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
+
+func (c *EurekaAPIClient) sleep(ctx context.Context, d time.Duration) error {
+	timer := c.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// rewind returns a copy of req that can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry.Body = body
+	return retry, nil
+}
+
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
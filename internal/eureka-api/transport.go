@@ -0,0 +1,90 @@
+package eurekaapi
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// TransportWrapper decorates the round tripper requests to Eureka are sent
+// with, e.g. to add tracing or metrics.
+type TransportWrapper func(http.RoundTripper) http.RoundTripper
+
+// WithTransportWrapper wraps the transports of the client at construction,
+// before any request is sent. Wrappers are applied in the order given, so the
+// last one is outermost.
+func WithTransportWrapper(wrap TransportWrapper) Option {
+	return func(c *EurekaAPIClient) {
+		if wrap != nil {
+			c.transportWrappers = append(c.transportWrappers, wrap)
+		}
+	}
+}
+
+// swappableTransport lets the round tripper be replaced while requests are
+// in flight. Requests that already started keep the round tripper they were
+// sent with.
+type swappableTransport struct {
+	mu sync.Mutex // serializes wrap
+	rt atomic.Pointer[http.RoundTripper]
+}
+
+func newSwappableTransport(rt http.RoundTripper) *swappableTransport {
+	t := &swappableTransport{}
+	t.rt.Store(&rt)
+	return t
+}
+
+func (t *swappableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return (*t.rt.Load()).RoundTrip(req)
+}
+
+func (t *swappableTransport) wrap(wrap TransportWrapper) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rt := wrap(*t.rt.Load())
+	t.rt.Store(&rt)
+}
+
+// httpClients returns the distinct clients requests are sent with.
+func (c *EurekaAPIClient) httpClients() []*http.Client {
+	if c.heartbeatClient == nil || c.heartbeatClient == c.client {
+		return []*http.Client{c.client}
+	}
+	return []*http.Client{c.client, c.heartbeatClient}
+}
+
+// installTransports routes nodes to their own transports where needed,
+// applies the configured wrappers and makes the transports safe to wrap later
+// on.
+func (c *EurekaAPIClient) installTransports() error {
+	for _, client := range c.httpClients() {
+		rt := client.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		rt, err := c.routeNodes(rt)
+		if err != nil {
+			return err
+		}
+		for _, wrap := range c.transportWrappers {
+			rt = wrap(rt)
+		}
+		client.Transport = newSwappableTransport(rt)
+	}
+	return nil
+}
+
+// WrapTransport wraps the transports of a client that may already be sending
+// requests. Calls are applied in order; requests already in flight are not
+// affected. Prefer WithTransportWrapper where possible.
+func (c *EurekaAPIClient) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	if wrap == nil {
+		return
+	}
+	for _, client := range c.httpClients() {
+		if t, ok := client.Transport.(*swappableTransport); ok {
+			t.wrap(wrap)
+		}
+	}
+}
@@ -29,3 +29,26 @@ func normalizeBaseURL(baseURL string) (string, error) {
 	}
 	return u.String(), nil
 }
+
+// NormalizeBaseURL returns baseURL in the form requests are built from, so
+// that equivalent URLs compare equal.
+func NormalizeBaseURL(baseURL string) (string, error) {
+	return normalizeBaseURL(baseURL)
+}
+
+// joinURL appends path elements to baseURL with exactly one slash between
+// each, however baseURL and the elements are slashed. Empty elements are
+// skipped. Elements are not escaped.
+func joinURL(baseURL string, elems ...string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(baseURL, "/"))
+	for _, elem := range elems {
+		elem = strings.Trim(elem, "/")
+		if elem == "" {
+			continue
+		}
+		b.WriteByte('/')
+		b.WriteString(elem)
+	}
+	return b.String()
+}
@@ -26,3 +26,24 @@ func TestNormalizeBaseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		elems    []string
+		expected string
+	}{
+		{"http://example.com/eureka/v2", []string{"apps"}, "http://example.com/eureka/v2/apps"},
+		{"http://example.com/eureka/v2/", []string{"apps", "FOO"}, "http://example.com/eureka/v2/apps/FOO"},
+		{"http://example.com/registry/api//", []string{"/apps/", "//FOO"}, "http://example.com/registry/api/apps/FOO"},
+		{"http://example.com/custom", []string{"/apps/delta"}, "http://example.com/custom/apps/delta"},
+		{"http://example.com", []string{"apps", "", "FOO"}, "http://example.com/apps/FOO"},
+		{"http://example.com/eureka/v2", nil, "http://example.com/eureka/v2"},
+	}
+
+	for _, test := range tests {
+		if result := joinURL(test.baseURL, test.elems...); result != test.expected {
+			t.Errorf("joinURL(%q, %q) = %q; want %q", test.baseURL, test.elems, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package eurekaapi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ErrInvalidInstance is wrapped by the errors returned from
+// Instance.Validate.
+var ErrInvalidInstance = errors.New("invalid instance")
+
+// Validate reports every problem that would make the server reject inst, or
+// register it in a way consumers can't use, when registered under appID.
+func (inst *Instance) Validate(appID string) error {
+	var problems []error
+	if inst.HostName == "" {
+		problems = append(problems, errors.New("empty hostName"))
+	}
+	if inst.App == "" {
+		problems = append(problems, errors.New("empty app"))
+	} else if !strings.EqualFold(inst.App, appID) {
+		problems = append(problems, fmt.Errorf("app %q doesn't match the application %q it is registered under", inst.App, appID))
+	}
+	if net.ParseIP(inst.IPAddr) == nil {
+		problems = append(problems, fmt.Errorf("ipAddr %q is not an IP address", inst.IPAddr))
+	}
+	switch inst.Status {
+	case UP, DOWN, STARTING, OUT_OF_SERVICE, UNKNOWN:
+	default:
+		problems = append(problems, fmt.Errorf("unknown status %q", inst.Status))
+	}
+	if inst.DataCenterInfo.Name == "" {
+		problems = append(problems, errors.New("missing dataCenterInfo name"))
+	}
+	for name, port := range map[string]*Port{"port": inst.Port, "securePort": inst.SecurePort} {
+		if port != nil && port.Enabled && (port.Value <= 0 || port.Value > 65535) {
+			problems = append(problems, fmt.Errorf("%s %d out of range", name, port.Value))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidInstance, errors.Join(problems...))
+}
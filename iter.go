@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"context"
+	"iter"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// Instances yields the cached instances of app, without copying the cached
+// slice. It yields nothing if app isn't cached.
+func (c *Cache) Instances(app string) iter.Seq[eurekaapi.Instance] {
+	return func(yield func(eurekaapi.Instance) bool) {
+		application, ok := c.Application(app)
+		if !ok {
+			return
+		}
+		for inst := range application.All() {
+			if !yield(inst) {
+				return
+			}
+		}
+	}
+}
+
+// Instances yields the instances of app from the registry cache, populating
+// the cache first if needed. If that fails, the error is the only value
+// yielded.
+func (c *Client) Instances(ctx context.Context, app string) iter.Seq2[eurekaapi.Instance, error] {
+	return func(yield func(eurekaapi.Instance, error) bool) {
+		if err := c.cache.refreshIfStale(ctx, 0); err != nil {
+			yield(eurekaapi.Instance{}, err)
+			return
+		}
+		for inst := range c.cache.Instances(app) {
+			if !yield(inst, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestInstancesIterators(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`<applications>` +
+			`<application><name>FOO</name><instance><instanceId>foo-1</instanceId></instance><instance><instanceId>foo-2</instanceId></instance></application>` +
+			`<application><name>BAR</name><instance><instanceId>bar-1</instanceId></instance></application>` +
+			`</applications>`))
+	})
+	client := newTestClient(t, f)
+
+	var ids []string
+	for inst, err := range client.Instances(context.Background(), "foo") {
+		if err != nil {
+			t.Fatalf("Instances yielded error: %v", err)
+		}
+		ids = append(ids, inst.InstanceID)
+	}
+	if len(ids) != 2 || ids[0] != "foo-1" || ids[1] != "foo-2" {
+		t.Errorf("Instances(foo) = %v; want [foo-1 foo-2]", ids)
+	}
+	for range client.Instances(context.Background(), "foo") {
+		break
+	}
+	if got := f.count(http.MethodGet); got != 1 {
+		t.Errorf("server received %d registry fetches; want 1", got)
+	}
+
+	apps, err := client.Cache().Applications()
+	if err != nil {
+		t.Fatalf("Applications returned error: %v", err)
+	}
+	count := 0
+	for range apps.All() {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("Applications.All yielded %d instances; want 3", count)
+	}
+	for inst := range client.Cache().Instances("missing") {
+		t.Errorf("Instances(missing) yielded %s", inst.InstanceID)
+	}
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Where the Kubernetes downward API is expected to expose the pod.
+const (
+	// EnvPodIP should hold status.podIP.
+	EnvPodIP = "POD_IP"
+	// EnvPodZone may hold the zone of the pod's node.
+	EnvPodZone = "POD_ZONE"
+	// PodLabelsFile is the downwardAPI volume file holding metadata.labels,
+	// searched for the zone label if EnvPodZone is not set.
+	PodLabelsFile = "/etc/podinfo/labels"
+)
+
+const zoneLabel = "topology.kubernetes.io/zone"
+
+// kubernetesEnv reads the pod's address and zone from the downward API.
+type kubernetesEnv struct {
+	getenv   func(key string) string
+	readFile func(name string) ([]byte, error)
+}
+
+// KubernetesDataCenterInfo reads the pod's address and zone from the
+// Kubernetes downward API, e.g. on EKS. Expose status.podIP as POD_IP and
+// either the zone as POD_ZONE or the pod labels in a downwardAPI volume at
+// /etc/podinfo/labels. Outside Kubernetes the client falls back to MyOwn.
+func KubernetesDataCenterInfo() DataCenterInfoProvider {
+	return kubernetesEnv{getenv: os.Getenv, readFile: os.ReadFile}
+}
+
+func (k kubernetesEnv) DataCenterInfo(context.Context) (DataCenterInfo, error) {
+	if k.getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return DataCenterInfo{}, fmt.Errorf("%w: not running in a Kubernetes pod", ErrDataCenterInfoUnavailable)
+	}
+
+	info := DataCenterInfo{
+		Name: DataCenterMyOwn,
+		Zone: k.getenv(EnvPodZone),
+	}
+	if podIP := k.getenv(EnvPodIP); podIP != "" {
+		if info.IP = net.ParseIP(podIP); info.IP == nil {
+			return DataCenterInfo{}, fmt.Errorf("invalid %s %q", EnvPodIP, podIP)
+		}
+	}
+	if info.Zone == "" {
+		if data, err := k.readFile(PodLabelsFile); err == nil {
+			info.Zone = podLabel(data, zoneLabel)
+		}
+	}
+	return info, nil
+}
+
+// podLabel returns the value of key in a downward API labels file, which
+// holds one key="quoted value" pair per line.
+func podLabel(data []byte, key string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || k != key {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(v); err == nil {
+			return unquoted
+		}
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestKubernetesHostInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		labels   string
+		wantIP   string
+		wantZone string
+	}{
+		{
+			name:     "zone from environment",
+			env:      map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1", EnvPodIP: "10.1.4.7", EnvPodZone: "eu-west-1a"},
+			wantIP:   "10.1.4.7",
+			wantZone: "eu-west-1a",
+		},
+		{
+			name:     "zone from labels",
+			env:      map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1", EnvPodIP: "10.1.4.7"},
+			labels:   "app=\"web\"\ntopology.kubernetes.io/zone=\"eu-west-1b\"\n",
+			wantIP:   "10.1.4.7",
+			wantZone: "eu-west-1b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := kubernetesEnv{
+				getenv: func(key string) string { return tt.env[key] },
+				readFile: func(name string) ([]byte, error) {
+					if name == PodLabelsFile && tt.labels != "" {
+						return []byte(tt.labels), nil
+					}
+					return nil, os.ErrNotExist
+				},
+			}
+			info, err := k.DataCenterInfo(context.Background())
+			if err != nil {
+				t.Fatalf("DataCenterInfo returned error: %v", err)
+			}
+			if !info.IP.Equal(net.ParseIP(tt.wantIP)) || info.Zone != tt.wantZone {
+				t.Errorf("DataCenterInfo() = %s in %q; want %s in %q", info.IP, info.Zone, tt.wantIP, tt.wantZone)
+			}
+		})
+	}
+}
+
+func TestKubernetesHostInfoOutsidePod(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+	client.dataCenterProvider = kubernetesEnv{getenv: func(string) string { return "" }}
+
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if got := client.registration.IPAddr; got != testIP.String() {
+		t.Errorf("registered address = %s; want %s", got, testIP)
+	}
+}
@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LameDuck drains the instance before shutdown. It marks the instance
+// OUT_OF_SERVICE, keeps heartbeating for duration so the change reaches the
+// caches of consumers, and then unregisters it. If ctx is cancelled early the
+// drain is cut short, but the instance is still unregistered.
+func (c *Client) LameDuck(ctx context.Context, duration time.Duration) error {
+	if err := c.SetStatus(ctx, StatusOutOfService); err != nil {
+		return fmt.Errorf("failed to enter lame-duck mode: %w", err)
+	}
+
+	drainCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer c.safetyNet()
+		ttl, ok := c.registeredTTL()
+		if !ok {
+			ttl = defaultTTL
+		}
+		interval := min(heartbeatInterval(ttl), max(duration, time.Second))
+		_ = c.RunHeartbeat(drainCtx, interval)
+	}()
+
+	timer := c.clock.NewTimer(duration)
+	select {
+	case <-timer.C():
+	case <-ctx.Done():
+	}
+	timer.Stop()
+	cancel()
+	<-done
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.WithoutCancel(ctx), defaultShutdownTimeout)
+	defer cancelShutdown()
+	return c.UnregisterInstance(shutdownCtx)
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestLameDuck(t *testing.T) {
+	f := newFakeEureka(t)
+	heartbeats := make(chan struct{}, 10)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/status") {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		heartbeats <- struct{}{}
+	})
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.LameDuck(context.Background(), 20*time.Second)
+	}()
+
+	// Wait for the first heartbeat, the drain timer and the heartbeat ticker.
+	<-heartbeats
+	clk.BlockUntil(2)
+	if got := f.count(http.MethodDelete); got != 0 {
+		t.Fatalf("instance unregistered before the drain period elapsed")
+	}
+	clk.Advance(20 * time.Second)
+	if err := <-errCh; err != nil {
+		t.Fatalf("LameDuck returned error: %v", err)
+	}
+
+	if got := f.count(http.MethodPut); got < 2 {
+		t.Errorf("server received %d PUT requests; want the status change and at least one heartbeat", got)
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+	var actions []Action
+	for _, entry := range client.History() {
+		actions = append(actions, entry.Action)
+	}
+	if len(actions) < 3 || actions[1] != ActionStatusChanged || actions[len(actions)-1] != ActionUnregistered {
+		t.Errorf("history = %v; want the status change first and the unregistration last", actions)
+	}
+}
+
+func TestLameDuckStopsRunningHeartbeatsFromReRegistering(t *testing.T) {
+	f := newFakeEureka(t)
+	var deleted atomic.Bool
+	notFound := make(chan struct{}, 100)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/status"):
+			w.WriteHeader(http.StatusNoContent)
+		case deleted.Load():
+			w.WriteHeader(http.StatusNotFound)
+			notFound <- struct{}{}
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	f.handle(http.MethodDelete, func(w http.ResponseWriter, r *http.Request) {
+		deleted.Store(true)
+		w.WriteHeader(http.StatusOK)
+	})
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	// The application's own heartbeat loop keeps running across the drain.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = client.RunHeartbeat(ctx, 10*time.Second) }()
+	clk.BlockUntil(1)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.LameDuck(context.Background(), 20*time.Second)
+	}()
+	clk.BlockUntil(3)
+	clk.Advance(20 * time.Second)
+	if err := <-errCh; err != nil {
+		t.Fatalf("LameDuck returned error: %v", err)
+	}
+
+	// The loop only sends its second heartbeat once the 404 of the first was
+	// handled.
+	for got := 0; got < 2; {
+		clk.Advance(10 * time.Second)
+		select {
+		case <-notFound:
+			got++
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if got := f.count(http.MethodPost); got != 1 {
+		t.Errorf("server received %d registrations; want the drained instance to stay unregistered", got)
+	}
+	if client.State() != StateUnregistered {
+		t.Errorf("state = %s; want UNREGISTERED", client.State())
+	}
+}
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+const (
+	// defaultEvictionDuration is the lease duration Eureka servers assume
+	// for instances that don't report one.
+	defaultEvictionDuration   = 90 * time.Second
+	defaultLeaseCheckInterval = 10 * time.Second
+)
+
+// LeaseExpiryPolicy makes the cache drop instances whose lease has run out,
+// judged by the timestamps the server reported for them. Normally the server
+// evicts such instances itself, but while it can't be reached the cache would
+// otherwise keep routing to them indefinitely.
+type LeaseExpiryPolicy struct {
+	// Grace is added to each lease's eviction duration, so that instances
+	// aren't dropped by the client while the server would still keep them.
+	Grace time.Duration
+	// CheckInterval is how often Cache.Run sweeps the cache for expired
+	// instances. Defaults to 10s.
+	CheckInterval time.Duration
+}
+
+// WithLeaseExpiry enables client-side lease expiry for the registry cache.
+// By default the cache holds on to instances until a registry fetch drops
+// them.
+func WithLeaseExpiry(policy LeaseExpiryPolicy) Option {
+	return func(c *Client) {
+		if policy.CheckInterval <= 0 {
+			policy.CheckInterval = defaultLeaseCheckInterval
+		}
+		c.leaseExpiry = &policy
+	}
+}
+
+// leaseExpires returns when the lease of inst runs out in server time, or
+// false if the server reported no timestamp to judge it by.
+func leaseExpires(inst eurekaapi.Instance) (time.Time, bool) {
+	last := inst.LastUpdatedTimestamp
+	duration := defaultEvictionDuration
+	if inst.LeaseInfo != nil {
+		last = max(last, inst.LeaseInfo.LastRenewalTimestamp)
+		if d := inst.LeaseInfo.Duration(); d > 0 {
+			duration = d
+		}
+	}
+	if last <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(last).Add(duration), true
+}
+
+// filter hides instances whose lease, plus the policy's grace, has run out by
+// now.
+func (p *LeaseExpiryPolicy) filter(now time.Time) InstanceFilter {
+	return func(inst eurekaapi.Instance) bool {
+		expires, ok := leaseExpires(inst)
+		return !ok || now.Before(expires.Add(p.Grace))
+	}
+}
+
+// runLeaseExpiry evicts expired instances from the cache every check
+// interval until ctx is cancelled.
+func (c *Cache) runLeaseExpiry(ctx context.Context) {
+	defer c.recoverPanic()
+	ticker := c.clock.NewTicker(c.leaseExpiry.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		c.evictExpired()
+	}
+}
+
+// evictExpired drops the instances whose lease has run out from the cache.
+// The hashcode is left alone, as it describes the registry the server sent.
+func (c *Cache) evictExpired() {
+	keep := c.leaseExpiry.filter(c.serverNow())
+
+	c.mu.Lock()
+	var apps []eurekaapi.Application
+	var evicted int
+	for i, app := range c.apps.Application {
+		kept := instanceFilters{keep}.application(app)
+		if n := len(app.Instance) - len(kept.Instance); n > 0 {
+			if apps == nil {
+				// Readers may hold the previous slice, so replace it.
+				apps = append([]eurekaapi.Application(nil), c.apps.Application...)
+			}
+			apps[i] = kept
+			evicted += n
+		}
+	}
+	if evicted > 0 {
+		c.apps.Application = apps
+	}
+	hashCode := c.apps.AppsHashCode
+	c.mu.Unlock()
+
+	if evicted == 0 {
+		return
+	}
+	c.evicted.Add(uint64(evicted))
+	c.events.publish(Event{Type: EventRegistryUpdated, Detail: hashCode})
+	c.notify(nil)
+}
+
+// serverNow estimates the current time on the Eureka server's clock, which
+// the timestamps of cached instances are in.
+func (c *Cache) serverNow() time.Time {
+	if c.clockSkew == nil {
+		return c.clock.Now()
+	}
+	return c.clock.Now().Add(c.clockSkew())
+}
+
+// visible returns the filters deciding which fetched instances the cache
+// keeps: the client's instance filters and, if enabled, lease expiry.
+func (c *Cache) visible() instanceFilters {
+	if c.leaseExpiry == nil {
+		return c.filters
+	}
+	return append(slices.Clip(c.filters), c.leaseExpiry.filter(c.serverNow()))
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestCacheLeaseExpiry(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	clk := clock.NewManual(now)
+	renewed := func(id string, at time.Time) eurekaapi.Instance {
+		return eurekaapi.Instance{
+			InstanceID: id,
+			Status:     StatusUp,
+			LeaseInfo:  &eurekaapi.LeaseInfo{EvictionDurationInSecs: 90, LastRenewalTimestamp: at.UnixMilli()},
+		}
+	}
+	cache := newCache(func(ctx context.Context) (eurekaapi.Applications, error) {
+		return eurekaapi.Applications{Application: []eurekaapi.Application{
+			{Name: "FOO", Instance: []eurekaapi.Instance{
+				renewed("fresh", now),
+				renewed("stale", now.Add(-100*time.Second)),
+				{InstanceID: "unknown", Status: StatusUp},
+			}},
+		}}, nil
+	}, time.Minute, time.Minute)
+	cache.clock = clk
+	cache.leaseExpiry = &LeaseExpiryPolicy{Grace: 5 * time.Second}
+
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	ids := func() []string {
+		app, _ := cache.Application("FOO")
+		var ids []string
+		for _, inst := range app.Instance {
+			ids = append(ids, inst.InstanceID)
+		}
+		return ids
+	}
+	if got := ids(); len(got) != 2 || got[0] != "fresh" || got[1] != "unknown" {
+		t.Fatalf("instances after refresh = %v; want [fresh unknown]", got)
+	}
+
+	// The fresh lease runs out after 90s plus 5s of grace, while the server
+	// is unreachable.
+	clk.Advance(94 * time.Second)
+	cache.evictExpired()
+	if got := ids(); len(got) != 2 {
+		t.Errorf("instances within grace = %v; want [fresh unknown]", got)
+	}
+	clk.Advance(time.Second)
+	cache.evictExpired()
+	if got := ids(); len(got) != 1 || got[0] != "unknown" {
+		t.Errorf("instances after expiry = %v; want [unknown]", got)
+	}
+	if got := cache.Stats().Expired; got != 1 {
+		t.Errorf("Expired = %d; want 1", got)
+	}
+}
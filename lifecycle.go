@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	defaultTTL             = 90
+	defaultShutdownTimeout = 5 * time.Second
+)
+
+// RunOptions configures Run.
+type RunOptions struct {
+	IP     net.IP
+	TTL    uint
+	UseSSL bool
+	// HeartbeatInterval defaults to a third of the TTL, 30 seconds for the
+	// default TTL of 90.
+	HeartbeatInterval time.Duration
+	// ShutdownTimeout bounds the deregistration once ctx is cancelled.
+	// Defaults to 5 seconds.
+	ShutdownTimeout time.Duration
+	// ShutdownReason, if set, is recorded in the metadata of the instance
+	// before it is unregistered; see UnregisterWithReason.
+	ShutdownReason ShutdownReason
+}
+
+// Run registers the instance, sends heartbeats until ctx is cancelled and then
+// unregisters it. It returns immediately; the returned channel receives an
+// error if registration or deregistration fails and is closed once Run is done.
+func (c *Client) Run(ctx context.Context, opts RunOptions) <-chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer c.safetyNet()
+		if err := c.run(ctx, opts); err != nil {
+			errCh <- err
+		}
+	}()
+	return errCh
+}
+
+func (c *Client) run(ctx context.Context, opts RunOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.TTL == 0 {
+		opts.TTL = defaultTTL
+	}
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = heartbeatInterval(opts.TTL)
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if _, err := c.registerInstance(ctx, opts.IP, opts.TTL, opts.UseSSL, false); err != nil {
+		return err
+	}
+
+	if err := c.RunHeartbeat(ctx, opts.HeartbeatInterval); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("heartbeat loop stopped: %w", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+	return c.UnregisterWithReason(shutdownCtx, opts.ShutdownReason)
+}
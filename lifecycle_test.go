@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunRegistersHeartbeatsAndUnregisters(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := client.Run(ctx, RunOptions{IP: testIP, TTL: 30, HeartbeatInterval: 10 * time.Millisecond})
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	for err := range errCh {
+		t.Errorf("Run reported error: %v", err)
+	}
+
+	if got := f.count(http.MethodPost); got != 1 {
+		t.Errorf("server received %d registrations; want 1", got)
+	}
+	if got := f.count(http.MethodPut); got == 0 {
+		t.Errorf("server received no heartbeats")
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+}
+
+func TestLifecycleHook(t *testing.T) {
+	f := newFakeEureka(t)
+	client, err := New(Config{
+		EurekaServiceURLs: []string{f.URL},
+		AppID:             "test-app",
+		Host:              "127.0.0.1",
+		Port:              8080,
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	hook := NewLifecycleHook(client, RunOptions{IP: testIP, HeartbeatInterval: 10 * time.Millisecond})
+	if err := hook.OnStart(context.Background()); err != nil {
+		t.Fatalf("OnStart returned error: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := hook.OnStop(context.Background()); err != nil {
+		t.Fatalf("OnStop returned error: %v", err)
+	}
+
+	if got := f.count(http.MethodPost); got != 1 {
+		t.Errorf("server received %d registrations; want 1", got)
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+}
+
+func TestLifecycleHookRecordsShutdownReason(t *testing.T) {
+	f := newFakeEureka(t)
+	var recorded url.Values
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/metadata") {
+			recorded = r.URL.Query()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	client, err := New(Config{
+		EurekaServiceURLs: []string{f.URL},
+		AppID:             "test-app",
+		Host:              "127.0.0.1",
+		Port:              8080,
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	hook := NewLifecycleHook(client, RunOptions{IP: testIP, HeartbeatInterval: time.Minute, ShutdownReason: ShutdownReasonDeploy})
+	if err := hook.OnStart(context.Background()); err != nil {
+		t.Fatalf("OnStart returned error: %v", err)
+	}
+	if err := hook.OnStop(context.Background()); err != nil {
+		t.Fatalf("OnStop returned error: %v", err)
+	}
+
+	if got := recorded.Get(MetadataShutdownReason); got != string(ShutdownReasonDeploy) {
+		t.Errorf("shutdownReason = %q; want %s", got, ShutdownReasonDeploy)
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+}
@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WithListener registers the port l is bound to rather than the one given to
+// NewClient, so that ephemeral ports, e.g. from net.Listen("tcp", ":0"), are
+// registered correctly. The port is read when the instance is registered. A
+// secure port or instance ID derived from the given port, the default, follow
+// it, unless the instance ID is persisted with WithInstanceIDFile.
+func WithListener(l net.Listener) Option {
+	return func(c *Client) {
+		c.portSource = func(context.Context) (int, error) {
+			return addrPort(l.Addr())
+		}
+	}
+}
+
+// WithHTTPServer registers the port srv serves on rather than the one given to
+// NewClient, like WithListener. If srv.Addr has no port or port 0, the port
+// is taken from the listener srv serves on, and RegisterInstance waits for
+// srv to be started. The option sets srv.BaseContext, wrapping the one
+// already set, so srv must not be started before NewClient is called.
+func WithHTTPServer(srv *http.Server) Option {
+	return func(c *Client) {
+		if port, err := serverAddrPort(srv.Addr); err == nil && port != 0 {
+			c.portSource = func(context.Context) (int, error) {
+				return port, nil
+			}
+			return
+		}
+
+		var (
+			once      sync.Once
+			addr      net.Addr
+			listening = make(chan struct{})
+		)
+		base := srv.BaseContext
+		srv.BaseContext = func(l net.Listener) context.Context {
+			once.Do(func() {
+				addr = l.Addr()
+				close(listening)
+			})
+			if base != nil {
+				return base(l)
+			}
+			return context.Background()
+		}
+		c.portSource = func(ctx context.Context) (int, error) {
+			select {
+			case <-listening:
+				return addrPort(addr)
+			case <-ctx.Done():
+				return 0, fmt.Errorf("HTTP server has not started listening: %w", ctx.Err())
+			}
+		}
+	}
+}
+
+// resolvePort replaces the port given to NewClient with the one of the
+// configured listener or server, once that is known.
+func (c *Client) resolvePort(ctx context.Context) error {
+	if c.portSource == nil {
+		return nil
+	}
+	port, err := c.portSource(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to determine the port to register: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := ":" + strconv.Itoa(c.port)
+	if c.instanceIDFile == "" && strings.HasPrefix(c.instanceID, c.host+":") && strings.HasSuffix(c.instanceID, prev) {
+		c.instanceID = strings.TrimSuffix(c.instanceID, prev) + ":" + strconv.Itoa(port)
+	}
+	if c.securePort == c.port {
+		c.securePort = port
+	}
+	c.port = port
+	c.portSource = nil
+	return nil
+}
+
+func addrPort(addr net.Addr) (int, error) {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.Port, nil
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return 0, fmt.Errorf("listener address %s has no port: %w", addr, err)
+	}
+	return strconv.Atoi(port)
+}
+
+// serverAddrPort returns the port of an http.Server Addr, which may be a
+// service name such as "https".
+func serverAddrPort(addr string) (int, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	return net.LookupPort("tcp", port)
+}
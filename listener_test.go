@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// captureRegistration records the instance registered with f.
+func captureRegistration(t *testing.T, f *fakeEureka) *eurekaapi.Instance {
+	t.Helper()
+	var registered eurekaapi.Instance
+	f.handle(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		if err := xml.NewDecoder(r.Body).Decode(&registered); err != nil {
+			t.Errorf("failed to decode registration: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return &registered
+}
+
+func TestWithListenerRegistersEphemeralPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned error: %v", err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	f := newFakeEureka(t)
+	registered := captureRegistration(t, f)
+	api, err := NewClient([]string{f.URL}, "test-app", "127.0.0.1", 0, WithListener(l))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if _, err := api.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	if registered.Port.Value != port || registered.SecurePort.Value != port {
+		t.Errorf("registered ports %d and %d; want the listener's %d", registered.Port.Value, registered.SecurePort.Value, port)
+	}
+	if want := ":" + strconv.Itoa(port); !strings.HasSuffix(api.InstanceID(), want) {
+		t.Errorf("instance ID = %s; want it to end with %s", api.InstanceID(), want)
+	}
+}
+
+func TestWithHTTPServerWaitsForEphemeralPort(t *testing.T) {
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	}
+	f := newFakeEureka(t)
+	registered := captureRegistration(t, f)
+	client := newTestClient(t, f, WithHTTPServer(srv))
+
+	// Not serving yet: registration waits until ctx is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, err := client.RegisterInstance(ctx, testIP, 30, false)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RegisterInstance before serving returned %v; want context.DeadlineExceeded", err)
+	}
+
+	go srv.ListenAndServe()
+	defer srv.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	resp, err := http.Get("http://127.0.0.1:" + strconv.Itoa(registered.Port.Value))
+	if err != nil {
+		t.Fatalf("registered port %d is not served: %v", registered.Port.Value, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("registered port %d answered %d; want the server's 418", registered.Port.Value, resp.StatusCode)
+	}
+}
+
+func TestWithHTTPServerUsesFixedPort(t *testing.T) {
+	f := newFakeEureka(t)
+	registered := captureRegistration(t, f)
+	client := newTestClient(t, f, WithHTTPServer(&http.Server{Addr: ":9090"}))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if registered.Port.Value != 9090 {
+		t.Errorf("registered port %d; want 9090", registered.Port.Value)
+	}
+}
+
+func TestRunRegistersListenerPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned error: %v", err)
+	}
+	defer l.Close()
+
+	f := newFakeEureka(t)
+	registered := captureRegistration(t, f)
+	client := newTestClient(t, f, WithListener(l))
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := client.Run(ctx, RunOptions{IP: testIP})
+	<-client.Ready()
+	cancel()
+	for err := range errCh {
+		t.Errorf("Run returned error: %v", err)
+	}
+	if want := l.Addr().(*net.TCPAddr).Port; registered.Port.Value != want {
+		t.Errorf("registered port %d; want the listener's %d", registered.Port.Value, want)
+	}
+}
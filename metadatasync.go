@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// RunMetadataSync keeps the registered metadata in line with source, which is
+// called every interval and returns the metadata the instance should have,
+// e.g. its current load or build hash. Only keys whose value differs from
+// the registered metadata are pushed, with UpdateMetadata. Keys missing from
+// source are left alone. Failed pushes are retried on the next tick. It runs
+// until ctx is cancelled.
+func (c *Client) RunMetadataSync(ctx context.Context, interval time.Duration, source func() map[string]string) error {
+	if interval <= 0 {
+		return fmt.Errorf("metadata sync interval must be positive, got %s", interval)
+	}
+	ticker := c.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+		}
+		_ = c.syncMetadata(ctx, source())
+	}
+}
+
+// syncMetadata pushes the entries of kv that differ from the registered
+// metadata and records them in the registration, so that re-registering
+// keeps them.
+func (c *Client) syncMetadata(ctx context.Context, kv map[string]string) error {
+	c.mu.Lock()
+	if c.registration == nil {
+		c.mu.Unlock()
+		return errors.New("instance has not been registered yet")
+	}
+	registered := c.registration.Metadata.Map()
+	c.mu.Unlock()
+
+	changed := make(map[string]string)
+	for k, v := range kv {
+		if old, ok := registered[k]; !ok || old != v {
+			changed[k] = v
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := c.UpdateMetadata(ctx, changed); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registration != nil {
+		merged := c.registration.Metadata.Map()
+		for k, v := range changed {
+			merged[k] = v
+		}
+		c.registration.Metadata = eurekaapi.NewMetadata(merged)
+	}
+	return nil
+}
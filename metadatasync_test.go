@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSyncMetadataPushesChangedKeys(t *testing.T) {
+	f := newFakeEureka(t)
+	var mu sync.Mutex
+	var pushed []url.Values
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/metadata") {
+			mu.Lock()
+			pushed = append(pushed, r.URL.Query())
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, f, WithMetadata(map[string]string{"build": "abc", "zone": "a"}))
+	ctx := context.Background()
+
+	if err := client.syncMetadata(ctx, map[string]string{"load": "1"}); err == nil {
+		t.Errorf("syncMetadata before registering returned nil")
+	}
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	for _, kv := range []map[string]string{
+		{"build": "abc", "load": "1"},
+		{"build": "abc", "load": "1"},
+		{"build": "def", "load": "1"},
+	} {
+		if err := client.syncMetadata(ctx, kv); err != nil {
+			t.Fatalf("syncMetadata(%v) returned error: %v", kv, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushed) != 2 {
+		t.Fatalf("metadata pushed %d times; want 2: %v", len(pushed), pushed)
+	}
+	if len(pushed[0]) != 1 || pushed[0].Get("load") != "1" {
+		t.Errorf("first push = %v; want only load=1", pushed[0])
+	}
+	if len(pushed[1]) != 1 || pushed[1].Get("build") != "def" {
+		t.Errorf("second push = %v; want only build=def", pushed[1])
+	}
+	if got, _ := client.registration.Metadata.Get("load"); got != "1" {
+		t.Errorf("registered load = %q; want it kept for re-registration", got)
+	}
+}
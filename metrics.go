@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestMetrics describes a request Transport sent to an instance. Retried
+// and hedged requests are reported once per attempt.
+type RequestMetrics struct {
+	App        string
+	InstanceID string
+	Method     string
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// WithRequestMetrics calls observe after every request sent to an instance,
+// e.g. to feed latency histograms and error counters per application. It is
+// called synchronously and must not block.
+func WithRequestMetrics(observe func(RequestMetrics)) TransportOption {
+	return func(t *Transport) {
+		t.metrics = observe
+	}
+}
+
+func (t *Transport) observe(req *http.Request, app string, ep Endpoint, resp *http.Response, err error, elapsed time.Duration) {
+	m := RequestMetrics{
+		App:        app,
+		InstanceID: ep.InstanceID,
+		Method:     req.Method,
+		Duration:   elapsed,
+		Err:        err,
+	}
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+	}
+	t.metrics(m)
+}
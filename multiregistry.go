@@ -0,0 +1,243 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Cluster is one of the Eureka clusters a MultiRegistry publishes to.
+type Cluster struct {
+	Name   string
+	Client ClientAPI
+}
+
+// ClusterError is the error of an operation on one cluster of a
+// MultiRegistry.
+type ClusterError struct {
+	Cluster string
+	Err     error
+}
+
+func (e *ClusterError) Error() string {
+	return fmt.Sprintf("cluster %s: %v", e.Cluster, e.Err)
+}
+
+func (e *ClusterError) Unwrap() error {
+	return e.Err
+}
+
+// ClusterStatus describes the registration of the instance in one cluster.
+type ClusterStatus struct {
+	Name string
+	// Drained is set once the cluster has been drained; it receives no
+	// further requests from the MultiRegistry.
+	Drained  bool
+	State    State
+	Renewals RenewalStats
+	Nodes    []NodeStatus
+}
+
+// MultiRegistry mirrors the registration, heartbeats and status of an
+// instance to several Eureka clusters, e.g. while migrating from one cluster
+// to another. Each cluster has its own client, configured as usual. Requests
+// go to all clusters that haven't been drained in parallel; failures are
+// joined as ClusterErrors and don't keep the other clusters from being
+// updated.
+type MultiRegistry struct {
+	clusters []*mirror
+}
+
+type mirror struct {
+	Cluster
+
+	mu      sync.Mutex
+	drained bool
+	// stop ends the heartbeat loop RunHeartbeat runs for the cluster, which
+	// closes stopped.
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewMultiRegistry returns a MultiRegistry publishing to clusters, which
+// must have distinct names.
+func NewMultiRegistry(clusters ...Cluster) (*MultiRegistry, error) {
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("%w: at least one cluster is required", ErrInvalidConfig)
+	}
+	m := &MultiRegistry{}
+	seen := make(map[string]bool, len(clusters))
+	for _, cl := range clusters {
+		switch {
+		case cl.Name == "":
+			return nil, fmt.Errorf("%w: cluster name must not be empty", ErrInvalidConfig)
+		case cl.Client == nil:
+			return nil, fmt.Errorf("%w: cluster %s has no client", ErrInvalidConfig, cl.Name)
+		case seen[cl.Name]:
+			return nil, fmt.Errorf("%w: duplicate cluster %s", ErrInvalidConfig, cl.Name)
+		}
+		seen[cl.Name] = true
+		m.clusters = append(m.clusters, &mirror{Cluster: cl})
+	}
+	return m, nil
+}
+
+// RegisterInstance registers the instance with every cluster.
+func (m *MultiRegistry) RegisterInstance(ctx context.Context, ip net.IP, ttl uint, useSSL bool) error {
+	return m.each(func(c ClientAPI) error {
+		_, err := c.RegisterInstance(ctx, ip, ttl, useSSL)
+		return err
+	})
+}
+
+// Heartbeat sends a heartbeat to every cluster.
+func (m *MultiRegistry) Heartbeat(ctx context.Context) error {
+	return m.each(func(c ClientAPI) error {
+		return c.Heartbeat(ctx)
+	})
+}
+
+// SetStatus sets the status of the instance in every cluster.
+func (m *MultiRegistry) SetStatus(ctx context.Context, status string) error {
+	return m.each(func(c ClientAPI) error {
+		return c.SetStatus(ctx, status)
+	})
+}
+
+// UnregisterInstance unregisters the instance from every cluster.
+func (m *MultiRegistry) UnregisterInstance(ctx context.Context) error {
+	return m.each(func(c ClientAPI) error {
+		return c.UnregisterInstance(ctx)
+	})
+}
+
+// RunHeartbeat runs the heartbeat loop of every cluster, see
+// Client.RunHeartbeat, until ctx is cancelled. The loop of a cluster stops
+// when it is drained; the others go on.
+func (m *MultiRegistry) RunHeartbeat(ctx context.Context, interval time.Duration) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(m.clusters))
+	for i, cl := range m.clusters {
+		cl.mu.Lock()
+		if cl.drained || cl.stop != nil {
+			cl.mu.Unlock()
+			continue
+		}
+		loopCtx, stop := context.WithCancel(ctx)
+		stopped := make(chan struct{})
+		cl.stop, cl.stopped = stop, stopped
+		cl.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(stopped)
+			defer stop()
+			err := cl.Client.RunHeartbeat(loopCtx, interval)
+			if err != nil && ctx.Err() == nil && !cl.isDrained() {
+				errs[i] = &ClusterError{Cluster: cl.Name, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, cl := range m.clusters {
+		cl.mu.Lock()
+		cl.stop, cl.stopped = nil, nil
+		cl.mu.Unlock()
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Drain takes the instance out of the cluster name: it stops the heartbeats
+// RunHeartbeat sends there and puts the instance into lame-duck mode for
+// duration, see Client.LameDuck, or unregisters it right away if duration is
+// zero. The other clusters are left as they are, and the drained one receives
+// no further requests.
+func (m *MultiRegistry) Drain(ctx context.Context, name string, duration time.Duration) error {
+	cl := m.cluster(name)
+	if cl == nil {
+		return fmt.Errorf("unknown cluster %s", name)
+	}
+	cl.mu.Lock()
+	if cl.drained {
+		cl.mu.Unlock()
+		return nil
+	}
+	cl.drained = true
+	stop, stopped := cl.stop, cl.stopped
+	cl.mu.Unlock()
+	if stop != nil {
+		stop()
+		<-stopped
+	}
+
+	var err error
+	if duration > 0 {
+		err = cl.Client.LameDuck(ctx, duration)
+	} else {
+		err = cl.Client.UnregisterInstance(ctx)
+	}
+	if err != nil {
+		return &ClusterError{Cluster: name, Err: err}
+	}
+	return nil
+}
+
+// Status reports the registration of the instance in each cluster, in the
+// order the clusters were given.
+func (m *MultiRegistry) Status() []ClusterStatus {
+	statuses := make([]ClusterStatus, len(m.clusters))
+	for i, cl := range m.clusters {
+		statuses[i] = ClusterStatus{
+			Name:     cl.Name,
+			Drained:  cl.isDrained(),
+			State:    cl.Client.State(),
+			Renewals: cl.Client.RenewalStats(),
+			Nodes:    cl.Client.NodeStatus(),
+		}
+	}
+	return statuses
+}
+
+// each calls fn for the client of every cluster that hasn't been drained, in
+// parallel, and joins their errors.
+func (m *MultiRegistry) each(fn func(ClientAPI) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(m.clusters))
+	for i, cl := range m.clusters {
+		if cl.isDrained() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(cl.Client); err != nil {
+				errs[i] = &ClusterError{Cluster: cl.Name, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (m *MultiRegistry) cluster(name string) *mirror {
+	for _, cl := range m.clusters {
+		if cl.Name == name {
+			return cl
+		}
+	}
+	return nil
+}
+
+func (cl *mirror) isDrained() bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.drained
+}
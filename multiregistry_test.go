@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMultiRegistryMirrorsAndDrains(t *testing.T) {
+	oldCluster, newCluster := newFakeEureka(t), newFakeEureka(t)
+	for _, f := range []*fakeEureka{oldCluster, newCluster} {
+		f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	m, err := NewMultiRegistry(
+		Cluster{Name: "old", Client: newTestClient(t, oldCluster)},
+		Cluster{Name: "new", Client: newTestClient(t, newCluster)},
+	)
+	if err != nil {
+		t.Fatalf("NewMultiRegistry returned error: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := m.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if oldCluster.count(http.MethodPost) != 1 || newCluster.count(http.MethodPost) != 1 {
+		t.Fatalf("registrations = %d, %d; want one per cluster", oldCluster.count(http.MethodPost), newCluster.count(http.MethodPost))
+	}
+
+	if err := m.Drain(ctx, "old", 0); err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+	if err := m.SetStatus(ctx, StatusOutOfService); err != nil {
+		t.Fatalf("SetStatus returned error: %v", err)
+	}
+	if oldCluster.count(http.MethodDelete) != 1 || oldCluster.count(http.MethodPut) != 0 {
+		t.Errorf("drained cluster got %d DELETEs and %d PUTs; want only the unregistration",
+			oldCluster.count(http.MethodDelete), oldCluster.count(http.MethodPut))
+	}
+	if newCluster.count(http.MethodDelete) != 0 || newCluster.count(http.MethodPut) != 1 {
+		t.Errorf("remaining cluster got %d DELETEs and %d PUTs; want only the status update",
+			newCluster.count(http.MethodDelete), newCluster.count(http.MethodPut))
+	}
+
+	status := m.Status()
+	if len(status) != 2 || !status[0].Drained || status[0].State != StateUnregistered || status[1].Drained {
+		t.Errorf("status = %+v; want old drained and unregistered, new not drained", status)
+	}
+}
+
+func TestMultiRegistryReportsFailuresPerCluster(t *testing.T) {
+	healthy, broken := newFakeEureka(t), newFakeEureka(t)
+	broken.handle(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	m, err := NewMultiRegistry(
+		Cluster{Name: "healthy", Client: newTestClient(t, healthy)},
+		Cluster{Name: "broken", Client: newTestClient(t, broken)},
+	)
+	if err != nil {
+		t.Fatalf("NewMultiRegistry returned error: %v", err)
+	}
+
+	err = m.RegisterInstance(context.Background(), testIP, 30, false)
+	var clusterErr *ClusterError
+	if !errors.As(err, &clusterErr) || clusterErr.Cluster != "broken" {
+		t.Fatalf("RegisterInstance returned %v; want a ClusterError for broken", err)
+	}
+	if healthy.count(http.MethodPost) != 1 {
+		t.Errorf("healthy cluster got %d registrations; want 1", healthy.count(http.MethodPost))
+	}
+}
+
+func TestMultiRegistryDrainStopsHeartbeats(t *testing.T) {
+	a, b := newFakeEureka(t), newFakeEureka(t)
+	m, err := NewMultiRegistry(
+		Cluster{Name: "a", Client: newTestClient(t, a)},
+		Cluster{Name: "b", Client: newTestClient(t, b)},
+	)
+	if err != nil {
+		t.Fatalf("NewMultiRegistry returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.RunHeartbeat(ctx, 10*time.Millisecond) }()
+
+	deadline := time.Now().Add(time.Second)
+	for a.count(http.MethodPut) == 0 || b.count(http.MethodPut) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no heartbeats were sent to both clusters")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := m.Drain(context.Background(), "a", 0); err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+	sent := a.count(http.MethodPut)
+	time.Sleep(50 * time.Millisecond)
+	if got := a.count(http.MethodPut); got != sent {
+		t.Errorf("drained cluster got %d more heartbeats", got-sent)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("RunHeartbeat returned %v; want context.Canceled", err)
+	}
+}
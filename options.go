@@ -0,0 +1,499 @@
+package pkg
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+const (
+	defaultRefreshInterval    = 30 * time.Second
+	defaultMaxRefreshInterval = 5 * time.Minute
+
+	defaultHomePagePath    = "/"
+	defaultStatusPagePath  = "/info"
+	defaultHealthCheckPath = "/health"
+)
+
+// Option configures optional behavior of a Client.
+type Option func(*Client)
+
+// WithRefreshInterval sets how often the registry cache is refreshed.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.refreshInterval = interval
+		}
+	}
+}
+
+// WithMaxRefreshInterval caps how far the refresh interval may be stretched
+// when registry fetches are slower than the configured interval.
+func WithMaxRefreshInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.maxRefreshInterval = interval
+		}
+	}
+}
+
+// WithHeartbeatPolicy sets how RunHeartbeat reacts to failed heartbeats.
+func WithHeartbeatPolicy(policy HeartbeatPolicy) Option {
+	return func(c *Client) {
+		c.heartbeatPolicy = policy
+	}
+}
+
+// WithHomePagePath sets the path used to derive the registered homePageUrl.
+func WithHomePagePath(path string) Option {
+	return func(c *Client) {
+		c.homePagePath = path
+	}
+}
+
+// WithStatusPagePath sets the path used to derive the registered
+// statusPageUrl. Defaults to /info, matching Spring Boot.
+func WithStatusPagePath(path string) Option {
+	return func(c *Client) {
+		c.statusPagePath = path
+	}
+}
+
+// WithHealthCheckPath sets the path used to derive the registered
+// healthCheckUrl. Defaults to /health, matching Spring Boot.
+func WithHealthCheckPath(path string) Option {
+	return func(c *Client) {
+		c.healthCheckPath = path
+	}
+}
+
+// WithSecureHealthCheckPath sets the path used to derive the registered
+// secureHealthCheckUrl, for services whose health endpoint is only served over
+// HTTPS. When unset, instances registered with SSL reuse the health check path.
+// It is only registered while the secure port is enabled, i.e. with SSL or
+// WithDualStackPorts.
+func WithSecureHealthCheckPath(path string) Option {
+	return func(c *Client) {
+		c.secureHealthCheckPath = path
+	}
+}
+
+// WithIPAddress sets the IPv4 address registered for the instance,
+// independently of the host name it registers with. It is used whenever
+// RegisterInstance is given no IP, ahead of the data center's.
+func WithIPAddress(ip net.IP) Option {
+	return func(c *Client) {
+		c.ip = ip
+	}
+}
+
+// WithMetadata sets metadata published with the instance on registration.
+func WithMetadata(kv map[string]string) Option {
+	return func(c *Client) {
+		for k, v := range kv {
+			c.metadata[k] = v
+		}
+	}
+}
+
+// WithContainerMetadata detects whether the process runs in a container and,
+// if so, publishes its container ID, hostname and orchestrator (plus the pod,
+// namespace and node on Kubernetes) as instance metadata. Metadata set with
+// WithMetadata takes precedence.
+func WithContainerMetadata() Option {
+	return func(c *Client) {
+		c.containerMetadata = hostContainerEnv.detect()
+	}
+}
+
+// WithDataCenterInfoProvider sets how the data center info the instance
+// registers with is produced. Defaults to MyOwn.
+func WithDataCenterInfoProvider(provider DataCenterInfoProvider) Option {
+	return func(c *Client) {
+		c.dataCenterProvider = provider
+	}
+}
+
+// WithAmazonDataCenter is WithDataCenterInfoProvider(AmazonDataCenterInfo()).
+func WithAmazonDataCenter() Option {
+	return WithDataCenterInfoProvider(AmazonDataCenterInfo())
+}
+
+// WithECSDataCenter is WithDataCenterInfoProvider(ECSDataCenterInfo()).
+func WithECSDataCenter() Option {
+	return WithDataCenterInfoProvider(ECSDataCenterInfo())
+}
+
+// WithKubernetesDataCenter is
+// WithDataCenterInfoProvider(KubernetesDataCenterInfo()).
+func WithKubernetesDataCenter() Option {
+	return WithDataCenterInfoProvider(KubernetesDataCenterInfo())
+}
+
+// WithManagementPort sets the management.port metadata read by Spring Boot
+// Admin and Spring Cloud consumers. Defaults to the instance port.
+func WithManagementPort(port int) Option {
+	return func(c *Client) {
+		c.managementPort = port
+	}
+}
+
+// WithJMXPort publishes the jmx.port metadata key.
+func WithJMXPort(port int) Option {
+	return func(c *Client) {
+		c.jmxPort = port
+	}
+}
+
+// WithCrashSafetyNet makes Run, and the goroutines the client starts itself
+// (heartbeats, background registration, signal handling and the goroutines of
+// Cache.Run), recover panics and clean up the registration with action before
+// re-panicking. Goroutines started by the application, including the one it
+// calls RunHeartbeat or Cache.Run on, are not covered: defer
+// DeregisterOnPanic at their top instead. DeregisterOnPanic and Exit use
+// action regardless of whether the safety net is set.
+func WithCrashSafetyNet(action CrashAction) Option {
+	return func(c *Client) {
+		c.crashSafetyNet = true
+		c.crashAction = action
+	}
+}
+
+// WithShutdownDrain makes HandleSignals keep the instance in lame-duck mode
+// for duration before unregistering it, so consumers stop routing to it
+// while in-flight requests complete.
+func WithShutdownDrain(duration time.Duration) Option {
+	return func(c *Client) {
+		c.shutdownDrain = duration
+	}
+}
+
+// ApplicationRoot selects the root element accepted when decoding single
+// application responses.
+type ApplicationRoot = eurekaapi.ApplicationRoot
+
+const (
+	ApplicationRootAuto         = eurekaapi.ApplicationRootAuto
+	ApplicationRootApplication  = eurekaapi.ApplicationRootApplication
+	ApplicationRootApplications = eurekaapi.ApplicationRootApplications
+)
+
+// WithApplicationRoot forces the root element expected in GET /apps/{appID}
+// responses. By default both <application> and <applications> are accepted.
+func WithApplicationRoot(root ApplicationRoot) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithApplicationRoot(root))
+	}
+}
+
+// WithWriteFanOut sends registration, heartbeat, status and metadata writes to
+// every configured Eureka node rather than only the first one that answers,
+// for standalone peers without server-side replication.
+func WithWriteFanOut() Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithWriteFanOut())
+	}
+}
+
+// RequestSigner signs outgoing requests after they are built; see
+// WithRequestSigner.
+type RequestSigner = eurekaapi.RequestSigner
+
+// RequestSignerFunc adapts a function to the RequestSigner interface.
+type RequestSignerFunc = eurekaapi.RequestSignerFunc
+
+// WithRequestSigner signs every request sent to Eureka, e.g. with an HMAC
+// header required by a gateway, without needing a custom transport.
+func WithRequestSigner(signer RequestSigner) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithRequestSigner(signer))
+	}
+}
+
+// WithDualStackPorts registers both the plain and the secure port as enabled,
+// for services that listen for HTTP and HTTPS at the same time. The useSSL
+// argument of RegisterInstance then only selects the scheme of derived URLs.
+func WithDualStackPorts() Option {
+	return func(c *Client) {
+		c.dualStackPorts = true
+	}
+}
+
+// WithSecurePort sets the port registered for TLS traffic when it differs from
+// the plain port, e.g. when TLS terminates on a separate listener. Defaults to
+// the port passed to NewClient.
+func WithSecurePort(port int) Option {
+	return func(c *Client) {
+		c.securePort = port
+	}
+}
+
+// WithHistorySize sets how many client actions History retains. Zero disables
+// recording.
+func WithHistorySize(size int) Option {
+	return func(c *Client) {
+		c.historySize = size
+	}
+}
+
+// WithEventBuffer sets how many events the Events channel buffers before
+// dropping new ones. Defaults to 64.
+func WithEventBuffer(size int) Option {
+	return func(c *Client) {
+		c.eventBuffer = max(size, 0)
+	}
+}
+
+// ContextHeader maps a context key to the request header its value is sent in.
+type ContextHeader = eurekaapi.ContextHeader
+
+// WithContextHeaders copies values such as trace or request IDs from the
+// context of each call onto the request sent to Eureka, for end-to-end
+// correlation in gateway logs.
+func WithContextHeaders(headers ...ContextHeader) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithContextHeaders(headers...))
+	}
+}
+
+// WithClock replaces the time source used for heartbeat scheduling, cache
+// refreshes and timestamps. Tests can pass a clock.Manual to simulate time.
+func WithClock(clk clock.Clock) Option {
+	return func(c *Client) {
+		if clk == nil {
+			return
+		}
+		c.clock = clk
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithClock(clk))
+	}
+}
+
+// WithThrottleBackoff sets how often a request answered with 429 or 503 is
+// retried against the same node, and the longest Retry-After it will wait
+// for. Zero retries disables the backoff.
+func WithThrottleBackoff(retries int, maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithThrottleBackoff(retries, maxWait))
+	}
+}
+
+// WithReadFailOver sets the response status classes (5 for 5xx, 4 for 4xx)
+// on which registry reads move on to the next Eureka node. Reads fail over on
+// 5xx by default; calling it without arguments restricts failover to
+// transport errors.
+func WithReadFailOver(classes ...int) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithReadFailOver(classes...))
+	}
+}
+
+// WithHeartbeatHTTPClient replaces the dedicated HTTP client used for
+// heartbeats. By default heartbeats use their own small connection pool with
+// short timeouts, isolated from registry fetches.
+func WithHeartbeatHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithHeartbeatHTTPClient(client))
+	}
+}
+
+// WithInstanceFilter hides instances rejected by any of the filters from the
+// registry cache, the resolver and the typed query methods.
+func WithInstanceFilter(filters ...InstanceFilter) Option {
+	return func(c *Client) {
+		c.filters = append(c.filters, filters...)
+	}
+}
+
+// WithDNSResolver sets the resolver used to look up Eureka hosts, e.g. one
+// that queries an internal DNS server.
+func WithDNSResolver(resolver *net.Resolver) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithDNSResolver(resolver))
+	}
+}
+
+// WithDualStackFallbackDelay sets how long dialing a Eureka host with both A
+// and AAAA records waits for the preferred address family before racing the
+// other one (Happy Eyeballs). A negative delay disables the race.
+func WithDualStackFallbackDelay(delay time.Duration) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithDualStackFallbackDelay(delay))
+	}
+}
+
+// DialFunc dials a connection to a Eureka node, like net.Dialer.DialContext.
+type DialFunc = eurekaapi.DialFunc
+
+// UnixSocketDialer returns a DialFunc connecting to the Unix domain socket at
+// path, for Eureka exposed through a local proxy.
+func UnixSocketDialer(path string) DialFunc {
+	return eurekaapi.UnixSocketDialer(path)
+}
+
+// WithDialContext replaces how connections to Eureka are dialed, so the
+// client can talk over non-TCP transports such as a Unix domain socket.
+func WithDialContext(dial DialFunc) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithDialContext(dial))
+	}
+}
+
+// TransportWrapper decorates the round tripper requests to Eureka are sent
+// with, e.g. to add tracing or metrics.
+type TransportWrapper = eurekaapi.TransportWrapper
+
+// WithJSON asks the given Eureka server URLs, or every one if none is given,
+// for JSON instead of XML, e.g. for a server known to produce malformed XML.
+// Responses are decoded according to their Content-Type either way.
+func WithJSON(eurekaServiceURLs ...string) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithJSON(eurekaServiceURLs...))
+	}
+}
+
+// ErrRetryBudgetExhausted is returned when a request failed and the retry
+// budget allows no further attempt.
+var ErrRetryBudgetExhausted = eurekaapi.ErrRetryBudgetExhausted
+
+// WithRetryBudget caps failover and throttling retries of all requests
+// together at maxRetries per window, so that retries cannot multiply the
+// traffic during an outage of the Eureka cluster.
+func WithRetryBudget(maxRetries int, window time.Duration) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithRetryBudget(maxRetries, window))
+	}
+}
+
+// RetryDecision tells whether a request moves on to the next Eureka node.
+type RetryDecision = eurekaapi.RetryDecision
+
+const (
+	RetryDefault  = eurekaapi.RetryDefault
+	RetryNextNode = eurekaapi.RetryNextNode
+	DoNotRetry    = eurekaapi.DoNotRetry
+)
+
+// RetryClassifier classifies the transport error or response a request to a
+// Eureka node ended with; see WithRetryClassifier.
+type RetryClassifier = eurekaapi.RetryClassifier
+
+// WithRetryClassifier decides whether a request fails over to the next Eureka
+// node, so that site-specific conditions such as a gateway's custom 599
+// status can be retried. Returning RetryDefault keeps the built-in behavior.
+func WithRetryClassifier(classify RetryClassifier) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithRetryClassifier(classify))
+	}
+}
+
+// NodeConfig holds the credentials, headers and TLS configuration used for a
+// single Eureka service URL.
+type NodeConfig = eurekaapi.NodeConfig
+
+// WithSpringCompat enables workarounds for Spring Cloud Netflix Eureka
+// servers: registrations are sent as Jackson-style JSON, with ports as
+// {"$": 8080, "@enabled": "true"} and the data center's @class, and statuses
+// such as "up" or "OutOfService" are normalized when reading the registry.
+func WithSpringCompat() Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithSpringCompat())
+	}
+}
+
+// WithNodeConfig applies cfg to the requests sent to one of the Eureka
+// service URLs, for failover nodes behind gateways with different
+// credentials. NewClient fails if the URL isn't one of the client's.
+func WithNodeConfig(eurekaServiceURL string, cfg NodeConfig) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithNodeConfig(eurekaServiceURL, cfg))
+	}
+}
+
+// WithReadURLs sends registry fetches and other reads to separate Eureka
+// service URLs, such as read-only replicas, while registration, heartbeats
+// and other writes keep going to the URLs passed to NewClient.
+func WithReadURLs(eurekaServiceURLs ...string) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithReadURLs(eurekaServiceURLs...))
+	}
+}
+
+// NodeSelection chooses which Eureka node a registry read is sent to first.
+type NodeSelection = eurekaapi.NodeSelection
+
+const (
+	PrimaryWithFailOver = eurekaapi.PrimaryWithFailOver
+	RoundRobinNodes     = eurekaapi.RoundRobinNodes
+)
+
+// WithReadNodeSelection sets how registry reads pick the Eureka node they are
+// sent to. PrimaryWithFailOver, the default, sends them to the first service
+// URL; RoundRobinNodes spreads them over all of them. Writes always go to the
+// first node that answers.
+func WithReadNodeSelection(selection NodeSelection) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithReadNodeSelection(selection))
+	}
+}
+
+// WithNodeQuarantine stops trying a Eureka node first once it failed
+// failures requests in a row, for duration or until it answers again as a
+// last resort. Disabled by default.
+func WithNodeQuarantine(failures int, duration time.Duration) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithNodeQuarantine(failures, duration))
+	}
+}
+
+// WithTransportWrapper wraps the HTTP transports at construction, before the
+// first request. Unlike WrapTransport it cannot race with requests in flight.
+func WithTransportWrapper(wrap TransportWrapper) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithTransportWrapper(wrap))
+	}
+}
+
+// WithNotFoundTTL sets how long a lookup of an application the registry
+// doesn't have is remembered, so that callers looping on a misconfigured
+// service name don't hit Eureka on every call. Defaults to 5 seconds; zero
+// disables it.
+func WithNotFoundTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithNotFoundTTL(ttl))
+	}
+}
+
+// WithLogger logs every request sent to Eureka at debug level, with the
+// operation, node, method, path, status and duration. Requests are not
+// logged by default. Panics recovered by the crash safety net are logged to
+// logger too, at error level; slog.Default is used without it.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithLogger(logger))
+	}
+}
+
+// ErrResponseTooLarge is returned when a response from Eureka exceeds the
+// size limit of its operation.
+var ErrResponseTooLarge = eurekaapi.ErrResponseTooLarge
+
+// ResponseLimits caps the size of Eureka responses per operation.
+type ResponseLimits = eurekaapi.ResponseLimits
+
+// WithResponseLimits sets the maximum size of Eureka responses, small for
+// heartbeats and status updates and large for registry fetches. Defaults to
+// 1 MiB and 256 MiB.
+func WithResponseLimits(limits ResponseLimits) Option {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, eurekaapi.WithResponseLimits(limits))
+	}
+}
+
+// DecodeError is returned when a Eureka response can't be decoded. It quotes
+// the Content-Type and the start of the body, with credentials redacted.
+type DecodeError = eurekaapi.DecodeError
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+// OutlierDetection configures the temporary ejection of instances whose calls
+// keep failing, independent of their status in Eureka. Zero fields take their
+// defaults.
+type OutlierDetection struct {
+	// Interval is the window over which error rates are computed. Defaults to
+	// 10 seconds.
+	Interval time.Duration
+	// MinRequests is the number of calls within a window before an instance
+	// can be ejected. Defaults to 5.
+	MinRequests int
+	// MaxErrorRate is the fraction of failed calls, between 0 and 1, above
+	// which an instance is ejected. Defaults to 0.5.
+	MaxErrorRate float64
+	// BaseEjectionTime is how long an instance is ejected the first time. It
+	// is multiplied by the number of consecutive ejections. Defaults to 30
+	// seconds.
+	BaseEjectionTime time.Duration
+	// MaxEjectionTime caps the ejection time. Defaults to 5 minutes.
+	MaxEjectionTime time.Duration
+}
+
+func (o OutlierDetection) withDefaults() OutlierDetection {
+	if o.Interval <= 0 {
+		o.Interval = 10 * time.Second
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = 5
+	}
+	if o.MaxErrorRate <= 0 {
+		o.MaxErrorRate = 0.5
+	}
+	if o.BaseEjectionTime <= 0 {
+		o.BaseEjectionTime = 30 * time.Second
+	}
+	if o.MaxEjectionTime < o.BaseEjectionTime {
+		o.MaxEjectionTime = max(5*time.Minute, o.BaseEjectionTime)
+	}
+	return o
+}
+
+// WithOutlierDetection makes the resolver eject instances whose calls, as
+// reported through Resolver.Report, fail too often. After its ejection time
+// an instance is re-admitted gradually: the share of picks it takes part in
+// grows linearly over another ejection time.
+func WithOutlierDetection(cfg OutlierDetection) ResolverOption {
+	return func(r *Resolver) {
+		r.outliers = &outlierDetector{cfg: cfg.withDefaults(), hosts: make(map[string]*hostStats)}
+	}
+}
+
+type outlierDetector struct {
+	cfg   OutlierDetection
+	clock clock.Clock
+
+	mu    sync.Mutex
+	hosts map[string]*hostStats // by instance ID
+}
+
+type hostStats struct {
+	windowStart time.Time
+	requests    int
+	failures    int
+
+	ejections    int
+	ejectedAt    time.Time
+	ejectedUntil time.Time
+}
+
+func (d *outlierDetector) report(instanceID string, err error) {
+	now := d.clock.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, ok := d.hosts[instanceID]
+	if !ok {
+		h = &hostStats{windowStart: now}
+		d.hosts[instanceID] = h
+	}
+	if now.Before(h.ejectedUntil) {
+		// Calls that were in flight when the instance got ejected.
+		return
+	}
+	if now.Sub(h.windowStart) >= d.cfg.Interval {
+		if h.ejections > 0 && now.Sub(h.ejectedUntil) >= h.ejectedUntil.Sub(h.ejectedAt) {
+			// A full window without ejection after re-admittance.
+			h.ejections--
+		}
+		h.windowStart, h.requests, h.failures = now, 0, 0
+	}
+
+	h.requests++
+	if err != nil {
+		h.failures++
+	}
+	if h.requests >= d.cfg.MinRequests && float64(h.failures)/float64(h.requests) > d.cfg.MaxErrorRate {
+		h.ejections++
+		ejection := min(d.cfg.BaseEjectionTime*time.Duration(h.ejections), d.cfg.MaxEjectionTime)
+		h.ejectedAt, h.ejectedUntil = now, now.Add(ejection)
+		h.windowStart, h.requests, h.failures = h.ejectedUntil, 0, 0
+	}
+}
+
+// admit reports whether the instance may be picked now.
+func (d *outlierDetector) admit(instanceID string) bool {
+	now := d.clock.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, ok := d.hosts[instanceID]
+	if !ok || h.ejectedUntil.IsZero() {
+		return true
+	}
+	if now.Before(h.ejectedUntil) {
+		return false
+	}
+	ramp := h.ejectedUntil.Sub(h.ejectedAt)
+	since := now.Sub(h.ejectedUntil)
+	if since >= ramp {
+		return true
+	}
+	return rand.Float64() < float64(since)/float64(ramp)
+}
+
+// filter drops the endpoints that are ejected. If that would leave nothing
+// to route to, every endpoint is returned instead.
+func (d *outlierDetector) filter(endpoints []Endpoint) []Endpoint {
+	admitted := make([]Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if d.admit(ep.InstanceID) {
+			admitted = append(admitted, ep)
+		}
+	}
+	if len(admitted) == 0 {
+		return endpoints
+	}
+	return admitted
+}
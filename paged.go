@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// ErrApplicationNotFound is returned when the registry has no application
+// with the requested name.
+var ErrApplicationNotFound = eurekaapi.ErrApplicationNotFound
+
+// ListApplications fetches the registry one application at a time, so that
+// only a single application is held in memory, instead of one giant /apps
+// response. It visits the given names, or if there are none, the
+// applications known to the cache plus those added according to the registry
+// delta. Applications that disappeared in the meantime are skipped. Iteration
+// stops at the first error, which is yielded.
+func (c *Client) ListApplications(ctx context.Context, names ...string) iter.Seq2[eurekaapi.Application, error] {
+	return func(yield func(eurekaapi.Application, error) bool) {
+		if len(names) == 0 {
+			var err error
+			if names, err = c.applicationNames(ctx); err != nil {
+				yield(eurekaapi.Application{}, err)
+				return
+			}
+		}
+
+		for _, name := range names {
+			app, err := c.eurekaAPIClient.GetApplication(ctx, c.normalizeAppID(name))
+			if errors.Is(err, eurekaapi.ErrApplicationNotFound) {
+				continue
+			}
+			if err != nil {
+				yield(eurekaapi.Application{}, fmt.Errorf("failed to get application %s: %w", name, err))
+				return
+			}
+			app, _ = c.ownApplication(app)
+			if !yield(c.filters.application(app), nil) {
+				return
+			}
+		}
+	}
+}
+
+// applicationNames returns the application names known to the cache and the
+// registry delta.
+func (c *Client) applicationNames(ctx context.Context) ([]string, error) {
+	names := c.cache.ApplicationNames()
+	delta, err := c.eurekaAPIClient.GetDelta(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry delta: %w", err)
+	}
+	for _, app := range delta.Application {
+		if !slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, app.Name) }) {
+			names = append(names, app.Name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestListApplications(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		switch name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]; name {
+		case "delta":
+			w.Write([]byte("<applications><application><name>FOO</name></application><application><name>GONE</name></application></applications>"))
+		case "FOO", "BAR":
+			fmt.Fprintf(w, "<application><name>%s</name></application>", name)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	client := newTestClient(t, f)
+
+	collect := func(names ...string) []string {
+		var got []string
+		for app, err := range client.ListApplications(context.Background(), names...) {
+			if err != nil {
+				t.Fatalf("ListApplications returned error: %v", err)
+			}
+			got = append(got, app.Name)
+		}
+		return got
+	}
+
+	if got := collect(); len(got) != 1 || got[0] != "FOO" {
+		t.Errorf("ListApplications() = %v; want [FOO]", got)
+	}
+	if got := collect("BAR", "FOO"); len(got) != 2 || got[0] != "BAR" || got[1] != "FOO" {
+		t.Errorf("ListApplications(BAR, FOO) = %v; want [BAR FOO]", got)
+	}
+
+	before := f.count(http.MethodGet)
+	for range client.ListApplications(context.Background(), "FOO", "BAR") {
+		break
+	}
+	if got := f.count(http.MethodGet) - before; got != 1 {
+		t.Errorf("breaking after the first application made %d requests; want 1", got)
+	}
+}
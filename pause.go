@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+)
+
+// pauseGate holds background loops while paused. The zero value is not
+// paused.
+type pauseGate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // nil unless paused
+}
+
+// pause reports whether the gate was open before.
+func (g *pauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		return false
+	}
+	g.resumed = make(chan struct{})
+	return true
+}
+
+// resume reports whether the gate was paused before.
+func (g *pauseGate) resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		return false
+	}
+	close(g.resumed)
+	g.resumed = nil
+	return true
+}
+
+func (g *pauseGate) paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed != nil
+}
+
+// await blocks while paused. It reports false once ctx is cancelled.
+func (g *pauseGate) await(ctx context.Context) bool {
+	g.mu.Lock()
+	resumed := g.resumed
+	g.mu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-resumed:
+		return true
+	}
+}
+
+// Pause stops the background heartbeats and registry refreshes until Resume
+// is called, e.g. while the application is in maintenance. The registration
+// and the cached registry are left as they are; explicit calls such as
+// Heartbeat or Cache.Refresh still go through. Note that Eureka evicts the
+// instance if it stays paused for longer than its lease.
+func (c *Client) Pause() {
+	c.heartbeats.pause()
+	c.cache.Pause()
+}
+
+// Resume restarts the background traffic stopped by Pause. A heartbeat and a
+// registry refresh are sent right away.
+func (c *Client) Resume() {
+	c.heartbeats.resume()
+	c.cache.Resume()
+}
+
+// Paused reports whether the client is paused.
+func (c *Client) Paused() bool {
+	return c.heartbeats.paused()
+}
+
+// Pause stops the refreshes of Run until Resume is called. Refresh still
+// fetches the registry on demand.
+func (c *Cache) Pause() {
+	c.gate.pause()
+}
+
+// Resume restarts the refreshes of Run, starting with one right away.
+func (c *Cache) Resume() {
+	c.gate.resume()
+}
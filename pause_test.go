@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestPauseHoldsHeartbeats(t *testing.T) {
+	f := newFakeEureka(t)
+	heartbeats := make(chan struct{}, 10)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		heartbeats <- struct{}{}
+	})
+	client := newTestClient(t, f)
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+
+	client.Pause()
+	if !client.Paused() {
+		t.Fatalf("Paused() = false after Pause")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = client.RunHeartbeat(ctx, time.Hour) }()
+
+	select {
+	case <-heartbeats:
+		t.Fatalf("heartbeat sent while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	client.Resume()
+	select {
+	case <-heartbeats:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no heartbeat sent after Resume")
+	}
+	if client.Paused() {
+		t.Errorf("Paused() = true after Resume")
+	}
+}
+
+func TestPauseHoldsCacheRefreshes(t *testing.T) {
+	refreshes := make(chan struct{}, 10)
+	cache := newCache(func(context.Context) (eurekaapi.Applications, error) {
+		refreshes <- struct{}{}
+		return eurekaapi.Applications{}, nil
+	}, time.Minute, time.Minute)
+	cache.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = cache.Run(ctx) }()
+
+	select {
+	case <-refreshes:
+		t.Fatalf("registry refreshed while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cache.Resume()
+	select {
+	case <-refreshes:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("registry not refreshed after Resume")
+	}
+}
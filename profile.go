@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// MetadataZone is the metadata key holding the zone of an instance, as used
+// by Spring Cloud Netflix.
+const MetadataZone = "zone"
+
+// Profile tunes discovery of a single target application.
+type Profile struct {
+	// RefreshInterval, if set, refreshes the application on its own at this
+	// interval, in addition to the full registry refresh.
+	RefreshInterval time.Duration
+	// Balancer overrides the resolver's balancer for the application.
+	Balancer Balancer
+	// Zone prefers instances whose zone metadata matches. Other instances are
+	// only used when the zone has none.
+	Zone string
+}
+
+// WithProfiles configures discovery per target application, keyed by
+// application name. This allows e.g. a latency-sensitive backend to be
+// refreshed more often than a batch one.
+func WithProfiles(profiles map[string]Profile) Option {
+	return func(c *Client) {
+		if c.profiles == nil {
+			c.profiles = make(map[string]Profile, len(profiles))
+		}
+		for app, p := range profiles {
+			c.profiles[strings.ToUpper(app)] = p
+		}
+	}
+}
+
+// preferZone returns the endpoints in zone, or all endpoints if none is.
+func preferZone(endpoints []Endpoint, zone string) []Endpoint {
+	if zone == "" {
+		return endpoints
+	}
+	local := make([]Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if z, _ := ep.Instance.Metadata.Get(MetadataZone); z == zone {
+			local = append(local, ep)
+		}
+	}
+	if len(local) == 0 {
+		return endpoints
+	}
+	return local
+}
+
+// runAppRefresh refreshes app every interval until ctx is cancelled.
+func (c *Cache) runAppRefresh(ctx context.Context, app string, interval time.Duration) {
+	defer c.recoverPanic()
+	ticker := c.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		if !c.gate.await(ctx) {
+			return
+		}
+		if err := c.RefreshApplication(ctx, app); err != nil {
+			c.failures.Add(1)
+		}
+	}
+}
+
+// RefreshApplication fetches a single application and replaces it in the
+// cache. It is a no-op until the cache has been populated.
+func (c *Cache) RefreshApplication(ctx context.Context, name string) error {
+	if c.fetchApp == nil {
+		return nil
+	}
+	app, err := c.fetchApp(ctx, name)
+	if err != nil {
+		return err
+	}
+	app = c.visible().application(app)
+
+	// Deferred first so that watchers run once the lock is released.
+	defer c.notify(nil)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.populated {
+		return nil
+	}
+	// Readers may hold the previous slice, so replace rather than modify it.
+	apps := make([]eurekaapi.Application, len(c.apps.Application), len(c.apps.Application)+1)
+	copy(apps, c.apps.Application)
+	key := strings.ToUpper(name)
+	if i, ok := c.index[key]; ok {
+		apps[i] = app
+		c.apps.Application = apps
+		return nil
+	}
+	index := make(map[string]int, len(c.index)+1)
+	for k, v := range c.index {
+		index[k] = v
+	}
+	index[key] = len(apps)
+	c.apps.Application = append(apps, app)
+	c.index = index
+	return nil
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestResolverAppliesProfiles(t *testing.T) {
+	inZone := testInstance("foo-1", StatusUp, 8080, 0)
+	inZone.Metadata = eurekaapi.NewMetadata(map[string]string{MetadataZone: "eu-1a"})
+	elsewhere := testInstance("foo-2", StatusUp, 8080, 0)
+	cache := newStaticCache(
+		eurekaapi.Application{Name: "FOO", Instance: []eurekaapi.Instance{inZone, elsewhere}},
+		eurekaapi.Application{Name: "BAR", Instance: []eurekaapi.Instance{elsewhere}},
+	)
+	resolver := newResolver(cache)
+	resolver.profiles = map[string]Profile{
+		"FOO": {Zone: "eu-1a"},
+		"BAR": {Zone: "eu-1a"},
+	}
+
+	for i := 0; i < 3; i++ {
+		ep, err := resolver.Resolve(context.Background(), "foo")
+		if err != nil {
+			t.Fatalf("Resolve returned error: %v", err)
+		}
+		if ep.InstanceID != "foo-1" {
+			t.Errorf("Resolve(foo) = %s; want the in-zone foo-1", ep.InstanceID)
+		}
+	}
+	// Without an instance in the zone, the others are used.
+	if ep, err := resolver.Resolve(context.Background(), "bar"); err != nil || ep.InstanceID != "foo-2" {
+		t.Errorf("Resolve(bar) = %s, %v; want foo-2", ep.InstanceID, err)
+	}
+}
+
+func TestCacheRefreshApplication(t *testing.T) {
+	cache := newStaticCache(
+		eurekaapi.Application{Name: "FOO", Instance: []eurekaapi.Instance{testInstance("foo-1", StatusUp, 8080, 0)}},
+	)
+	cache.fetchApp = func(ctx context.Context, name string) (eurekaapi.Application, error) {
+		return eurekaapi.Application{Name: name, Instance: []eurekaapi.Instance{testInstance(name+"-2", StatusUp, 8080, 0)}}, nil
+	}
+	if err := cache.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	before, _ := cache.Applications()
+
+	for _, name := range []string{"FOO", "BAR"} {
+		if err := cache.RefreshApplication(context.Background(), name); err != nil {
+			t.Fatalf("RefreshApplication(%q) returned error: %v", name, err)
+		}
+		app, ok := cache.Application(name)
+		if !ok || app.Instance[0].InstanceID != name+"-2" {
+			t.Errorf("Application(%q) = %+v, %t; want the refreshed application", name, app, ok)
+		}
+	}
+	if before.Application[0].Instance[0].InstanceID != "foo-1" {
+		t.Errorf("RefreshApplication modified a registry previously handed out")
+	}
+}
+
+func TestWithProfilesSchedulesAppRefresh(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f, WithProfiles(map[string]Profile{"orders": {RefreshInterval: time.Second}, "batch": {}}))
+	if got := client.cache.appIntervals; len(got) != 1 || got["ORDERS"] != time.Second {
+		t.Errorf("app refresh intervals = %v; want ORDERS every second", got)
+	}
+}
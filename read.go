@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// ReadOption configures a single read from the registry cache.
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	maxStaleness time.Duration
+}
+
+// WithMaxStaleness bounds how old the cached registry may be. If it was
+// refreshed longer than d ago, the read refreshes it first and waits for the
+// result.
+func WithMaxStaleness(d time.Duration) ReadOption {
+	return func(o *readOptions) {
+		o.maxStaleness = d
+	}
+}
+
+// GetInstances returns the instances of app from the registry cache,
+// populating the cache first if needed. An app that isn't registered has no
+// instances.
+func (c *Client) GetInstances(ctx context.Context, app string, opts ...ReadOption) ([]eurekaapi.Instance, error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := c.cache.refreshIfStale(ctx, o.maxStaleness); err != nil {
+		return nil, err
+	}
+	application, ok := c.cache.Application(app)
+	if !ok {
+		return nil, nil
+	}
+	return slices.Clone(application.Instance), nil
+}
+
+// refreshIfStale refreshes the cache if it hasn't been populated yet or, for
+// a positive maxStaleness, if it was last refreshed longer than that ago.
+func (c *Cache) refreshIfStale(ctx context.Context, maxStaleness time.Duration) error {
+	c.mu.RLock()
+	populated := c.populated
+	age := c.clock.Since(c.lastRefresh)
+	c.mu.RUnlock()
+
+	if !populated {
+		if err := c.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to populate registry cache: %w", err)
+		}
+		return nil
+	}
+	if maxStaleness > 0 && age > maxStaleness {
+		if err := c.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to refresh stale registry cache: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestGetInstancesMaxStaleness(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`<applications><application><name>FOO</name>` +
+			`<instance><instanceId>foo-1</instanceId></instance>` +
+			`</application></applications>`))
+	})
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	ctx := context.Background()
+
+	insts, err := client.GetInstances(ctx, "foo", WithMaxStaleness(time.Minute))
+	if err != nil {
+		t.Fatalf("GetInstances returned error: %v", err)
+	}
+	if len(insts) != 1 || insts[0].InstanceID != "foo-1" {
+		t.Fatalf("GetInstances(foo) = %v; want [foo-1]", insts)
+	}
+
+	clk.Advance(30 * time.Second)
+	if _, err := client.GetInstances(ctx, "foo", WithMaxStaleness(time.Minute)); err != nil {
+		t.Fatalf("GetInstances returned error: %v", err)
+	}
+	if got := f.count(http.MethodGet); got != 1 {
+		t.Errorf("fresh read fetched the registry; %d fetches, want 1", got)
+	}
+
+	clk.Advance(time.Minute)
+	if _, err := client.GetInstances(ctx, "foo"); err != nil {
+		t.Fatalf("GetInstances returned error: %v", err)
+	}
+	if got := f.count(http.MethodGet); got != 1 {
+		t.Errorf("unbounded read fetched the registry; %d fetches, want 1", got)
+	}
+	if _, err := client.GetInstances(ctx, "foo", WithMaxStaleness(time.Minute)); err != nil {
+		t.Fatalf("GetInstances returned error: %v", err)
+	}
+	if got := f.count(http.MethodGet); got != 2 {
+		t.Errorf("stale read made %d fetches in total; want 2", got)
+	}
+
+	insts, err = client.GetInstances(ctx, "missing")
+	if err != nil || len(insts) != 0 {
+		t.Errorf("GetInstances(missing) = %v, %v; want no instances", insts, err)
+	}
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// pendingMetadataTTL bounds how long a local metadata write overrides what
+// the server returns, in case another writer changed the key meanwhile.
+const pendingMetadataTTL = 2 * time.Minute
+
+// pendingMetadata remembers metadata written by UpdateMetadata until the
+// registry reflects it. Replication between Eureka nodes lags, so without it
+// a query right after an update can return the old values.
+type pendingMetadata struct {
+	mu      sync.Mutex
+	entries map[string]pendingEntry
+}
+
+type pendingEntry struct {
+	value     string
+	writtenAt time.Time
+}
+
+func (p *pendingMetadata) record(kv map[string]string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.entries == nil {
+		p.entries = make(map[string]pendingEntry, len(kv))
+	}
+	for k, v := range kv {
+		p.entries[k] = pendingEntry{value: v, writtenAt: now}
+	}
+}
+
+// reconcile returns the metadata of inst with pending writes applied, and
+// false if nothing had to be applied. Writes the server already reflects are
+// forgotten.
+func (p *pendingMetadata) reconcile(inst eurekaapi.Instance, now time.Time) (*eurekaapi.Metadata, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) == 0 {
+		return nil, false
+	}
+
+	kv := inst.Metadata.Map()
+	changed := false
+	for k, e := range p.entries {
+		if v, ok := kv[k]; (ok && v == e.value) || now.Sub(e.writtenAt) > pendingMetadataTTL {
+			delete(p.entries, k)
+			continue
+		}
+		kv[k] = e.value
+		changed = true
+	}
+	if !changed {
+		return nil, false
+	}
+	return eurekaapi.NewMetadata(kv), true
+}
+
+// ownInstance applies pending metadata writes to inst if it is this
+// instance, and reports whether it changed it.
+func (c *Client) ownInstance(inst eurekaapi.Instance) (eurekaapi.Instance, bool) {
+	if inst.InstanceID != c.instanceID {
+		return inst, false
+	}
+	md, ok := c.pendingMetadata.reconcile(inst, c.clock.Now())
+	if ok {
+		inst.Metadata = md
+	}
+	return inst, ok
+}
+
+// ownApplication applies pending metadata writes to this instance within app.
+// The instances of app may be shared, so they are copied before changing.
+func (c *Client) ownApplication(app eurekaapi.Application) (eurekaapi.Application, bool) {
+	for i, inst := range app.Instance {
+		if inst.InstanceID != c.instanceID {
+			continue
+		}
+		reconciled, ok := c.ownInstance(inst)
+		if ok {
+			app.Instance = slices.Clone(app.Instance)
+			app.Instance[i] = reconciled
+		}
+		return app, ok
+	}
+	return app, false
+}
+
+func (c *Client) ownApplications(apps eurekaapi.Applications) eurekaapi.Applications {
+	for i, app := range apps.Application {
+		if !strings.EqualFold(app.Name, c.appID) {
+			continue
+		}
+		if reconciled, ok := c.ownApplication(app); ok {
+			apps.Application = slices.Clone(apps.Application)
+			apps.Application[i] = reconciled
+		}
+		break
+	}
+	return apps
+}
+
+// fetchApplications fetches the registry for the cache, with pending metadata
+// writes applied.
+func (c *Client) fetchApplications(ctx context.Context) (eurekaapi.Applications, error) {
+	apps, err := c.eurekaAPIClient.GetAllApplications(ctx)
+	if err != nil {
+		return apps, err
+	}
+	return c.ownApplications(apps), nil
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestReadYourMetadataWrites(t *testing.T) {
+	f := newFakeEureka(t)
+	var mu sync.Mutex
+	serverZone := "a"
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "<instance><instanceId>127.0.0.1:test-app:8080</instanceId><metadata><zone>%s</zone></metadata></instance>", serverZone)
+	})
+	setServerZone := func(zone string) {
+		mu.Lock()
+		defer mu.Unlock()
+		serverZone = zone
+	}
+	client := newTestClient(t, f)
+
+	zoneOf := func() string {
+		inst, err := client.GetInstance(context.Background())
+		if err != nil {
+			t.Fatalf("GetInstance returned error: %v", err)
+		}
+		zone, _ := inst.Metadata.Get("zone")
+		return zone
+	}
+
+	if err := client.UpdateMetadata(context.Background(), map[string]string{"zone": "b"}); err != nil {
+		t.Fatalf("UpdateMetadata returned error: %v", err)
+	}
+	// The server hasn't caught up yet.
+	if got := zoneOf(); got != "b" {
+		t.Errorf("zone before replication = %q; want the written %q", got, "b")
+	}
+	setServerZone("b")
+	if got := zoneOf(); got != "b" {
+		t.Errorf("zone after replication = %q; want %q", got, "b")
+	}
+	// Once reflected, the write no longer masks later changes by others.
+	setServerZone("c")
+	if got := zoneOf(); got != "c" {
+		t.Errorf("zone after a later change = %q; want %q", got, "c")
+	}
+}
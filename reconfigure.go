@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// Reconfiguration lists settings Reconfigure changes at runtime. Zero fields
+// are left as they are.
+type Reconfiguration struct {
+	// ServiceURLs replace the Eureka servers requests fail over between. With
+	// server discovery they replace the seed URLs, and servers discovered
+	// from the registry are added again on the next refresh.
+	ServiceURLs []string
+	// NodeConfigs replace all node configs, e.g. to rotate credentials. An
+	// empty, non-nil map removes them. TLS configurations can't be changed.
+	NodeConfigs map[string]NodeConfig
+	// RefreshInterval replaces the interval of the registry cache, which
+	// refreshes next one interval from now.
+	RefreshInterval time.Duration
+	// HeartbeatInterval replaces the interval RunHeartbeat was given, taking
+	// effect one interval from now.
+	HeartbeatInterval time.Duration
+}
+
+// Reconfigure applies cfg without recreating the client, so that the
+// registration and the registry cache are kept. cfg is validated before any
+// of it is applied; requests in flight finish with the settings they started
+// with.
+func (c *Client) Reconfigure(cfg Reconfiguration) error {
+	if cfg.RefreshInterval < 0 {
+		return fmt.Errorf("%w: refresh interval must not be negative, got %s", ErrInvalidConfig, cfg.RefreshInterval)
+	}
+	if cfg.HeartbeatInterval < 0 {
+		return fmt.Errorf("%w: heartbeat interval must not be negative, got %s", ErrInvalidConfig, cfg.HeartbeatInterval)
+	}
+	var urls []string
+	for _, u := range cfg.ServiceURLs {
+		norm, err := eurekaapi.NormalizeBaseURL(u)
+		if err != nil {
+			return fmt.Errorf("%w: invalid service URL %q: %w", ErrInvalidConfig, u, err)
+		}
+		urls = append(urls, norm)
+	}
+
+	if cfg.NodeConfigs != nil {
+		if err := c.eurekaAPIClient.SetNodeConfigs(cfg.NodeConfigs); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+	}
+	if len(urls) > 0 {
+		if err := c.setServiceURLs(urls); err != nil {
+			return err
+		}
+	}
+	if cfg.RefreshInterval > 0 {
+		c.cache.SetInterval(cfg.RefreshInterval)
+	}
+	if cfg.HeartbeatInterval > 0 {
+		c.heartbeatOverride.Store(int64(cfg.HeartbeatInterval))
+		select {
+		case c.heartbeatRescheduled <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// setServiceURLs replaces the Eureka servers with urls, which are normalized.
+func (c *Client) setServiceURLs(urls []string) error {
+	if c.serverDiscovery != nil {
+		c.mu.Lock()
+		c.seedURLs = urls
+		c.servers = urls
+		c.mu.Unlock()
+	}
+	if err := c.eurekaAPIClient.SetBaseURLs(urls); err != nil {
+		return fmt.Errorf("failed to set service URLs: %w", err)
+	}
+	c.events.publish(Event{Type: EventServersChanged, Detail: strings.Join(urls, ",")})
+	return nil
+}
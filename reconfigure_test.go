@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReconfigure(t *testing.T) {
+	old, next := newFakeEureka(t), newFakeEureka(t)
+	var user, pass string
+	next.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ = r.BasicAuth()
+		w.Write([]byte(`<applications></applications>`))
+	})
+	client := newTestClient(t, old)
+
+	err := client.Reconfigure(Reconfiguration{
+		ServiceURLs:     []string{next.URL},
+		NodeConfigs:     map[string]NodeConfig{next.URL: {Username: "user", Password: "rotated"}},
+		RefreshInterval: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Reconfigure returned error: %v", err)
+	}
+	if err := client.Cache().Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if n := old.count(http.MethodGet); n != 0 {
+		t.Errorf("old server got %d requests; want none", n)
+	}
+	if user != "user" || pass != "rotated" {
+		t.Errorf("credentials = %q:%q; want the new ones", user, pass)
+	}
+	if got := client.Cache().Stats().Interval; got != 5*time.Second {
+		t.Errorf("refresh interval = %s; want 5s", got)
+	}
+
+	// Invalid settings are rejected before anything is applied.
+	err = client.Reconfigure(Reconfiguration{ServiceURLs: []string{old.URL}, HeartbeatInterval: -time.Second})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Reconfigure returned %v; want ErrInvalidConfig", err)
+	}
+	if nodes := client.NodeStatus(); len(nodes) != 1 || nodes[0].URL != next.URL+"/eureka/v2" {
+		t.Errorf("servers = %+v; want them unchanged", nodes)
+	}
+}
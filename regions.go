@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// LocalRegion is the key of the client's own region in the result of
+// GetAllApplicationsByRegion.
+const LocalRegion = "local"
+
+const defaultRegionParallelism = 4
+
+// WithRemoteRegions configures the Eureka service URLs of other regions,
+// keyed by region name, for GetAllApplicationsByRegion. The regions are
+// queried with the same options as the client's own.
+func WithRemoteRegions(regions map[string][]string) Option {
+	return func(c *Client) {
+		if c.remoteRegions == nil {
+			c.remoteRegions = make(map[string][]string, len(regions))
+		}
+		for region, urls := range regions {
+			c.remoteRegions[region] = urls
+		}
+	}
+}
+
+// WithRegionParallelism bounds how many regions GetAllApplicationsByRegion
+// queries at the same time. Defaults to 4.
+func WithRegionParallelism(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.regionParallelism = n
+		}
+	}
+}
+
+// newRegionClients creates an API client for each remote region.
+func (c *Client) newRegionClients() error {
+	if len(c.remoteRegions) == 0 {
+		return nil
+	}
+	c.regionClients = make(map[string]eurekaapi.EurekaAPI, len(c.remoteRegions))
+	for region, urls := range c.remoteRegions {
+		if region == LocalRegion {
+			return fmt.Errorf("%w: remote region must not be named %q", ErrInvalidConfig, LocalRegion)
+		}
+		client, err := eurekaapi.NewEurekaAPIClient(urls, c.apiOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to create client for region %s: %w", region, err)
+		}
+		c.regionClients[region] = client
+	}
+	return nil
+}
+
+// GetAllApplicationsByRegion fetches the registry of the client's own region,
+// under LocalRegion, and of each remote region configured with
+// WithRemoteRegions, querying at most the configured number of regions at
+// once. Eureka's GET /apps isn't paged, so each region's registry is fetched
+// whole. Regions that fail are left out of the result and reported in the
+// error, so the caller can still use the others.
+func (c *Client) GetAllApplicationsByRegion(ctx context.Context) (map[string]eurekaapi.Applications, error) {
+	clients := make(map[string]eurekaapi.EurekaAPI, len(c.regionClients)+1)
+	clients[LocalRegion] = c.eurekaAPIClient
+	for region, client := range c.regionClients {
+		clients[region] = client
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]eurekaapi.Applications, len(clients))
+		errs    []error
+		slots   = make(chan struct{}, c.regionParallelism)
+	)
+	for region, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("region %s: %w", region, ctx.Err()))
+				mu.Unlock()
+				return
+			}
+			defer func() { <-slots }()
+
+			apps, err := client.GetAllApplications(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get applications of region %s: %w", region, err))
+				return
+			}
+			if region == LocalRegion {
+				apps = c.ownApplications(apps)
+			}
+			results[region] = c.filters.applications(apps)
+		}()
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetAllApplicationsByRegion(t *testing.T) {
+	registry := func(app string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<applications><application><name>` + app + `</name><instance><instanceId>` + app + `-1</instanceId><status>UP</status></instance></application></applications>`))
+		}
+	}
+	local := newFakeEureka(t)
+	local.handle(http.MethodGet, registry("LOCAL-APP"))
+	east := newFakeEureka(t)
+	east.handle(http.MethodGet, registry("EAST-APP"))
+	broken := newFakeEureka(t)
+	broken.handle(http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	client := newTestClient(t, local, WithRegionParallelism(1), WithRemoteRegions(map[string][]string{
+		"us-east-1": {east.URL},
+		"eu-west-1": {broken.URL},
+	}))
+	byRegion, err := client.GetAllApplicationsByRegion(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "eu-west-1") {
+		t.Errorf("GetAllApplicationsByRegion returned %v; want an error for eu-west-1", err)
+	}
+	if len(byRegion) != 2 {
+		t.Fatalf("GetAllApplicationsByRegion() = %+v; want the local and us-east-1 registries", byRegion)
+	}
+	for region, app := range map[string]string{LocalRegion: "LOCAL-APP", "us-east-1": "EAST-APP"} {
+		apps := byRegion[region].Application
+		if len(apps) != 1 || apps[0].Name != app {
+			t.Errorf("applications of %s = %+v; want %s", region, apps, app)
+		}
+	}
+
+	if _, err := NewClient([]string{local.URL}, "test-app", "127.0.0.1", 8080, WithRemoteRegions(map[string][]string{LocalRegion: {east.URL}})); err == nil {
+		t.Errorf("NewClient with a remote region named %q returned nil error", LocalRegion)
+	}
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+)
+
+// RenewalStats describes the lease renewals the client performs, for
+// comparison with the renewal threshold below which the Eureka server enters
+// self-preservation.
+type RenewalStats struct {
+	// Renewals and Failures count heartbeats since the client was created.
+	Renewals    uint64    `json:"renewals"`
+	Failures    uint64    `json:"failures"`
+	LastRenewal time.Time `json:"lastRenewal,omitzero"`
+	// LastMinute counts the successful renewals of the past minute.
+	LastMinute int `json:"lastMinute"`
+	// ExpectedPerMinute is the renewal rate the heartbeat interval of
+	// RunHeartbeat implies, which is what the server expects from the
+	// instance. It is zero while no heartbeat loop runs.
+	ExpectedPerMinute float64 `json:"expectedPerMinute"`
+}
+
+// renewalTracker counts renewals, keeping the times of those in the past
+// minute.
+type renewalTracker struct {
+	mu       sync.Mutex
+	recent   []time.Time // oldest first
+	renewals uint64
+	failures uint64
+	interval time.Duration
+}
+
+func (t *renewalTracker) record(now time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.failures++
+		return
+	}
+	t.renewals++
+	t.recent = append(t.prune(now), now)
+}
+
+// prune drops the renewals older than a minute.
+func (t *renewalTracker) prune(now time.Time) []time.Time {
+	expired := 0
+	for expired < len(t.recent) && now.Sub(t.recent[expired]) >= time.Minute {
+		expired++
+	}
+	return t.recent[expired:]
+}
+
+// setInterval records the heartbeat interval in use, zero once the heartbeat
+// loop stopped.
+func (t *renewalTracker) setInterval(interval time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interval = interval
+}
+
+// currentInterval returns the heartbeat interval in use, zero if no heartbeat
+// loop runs.
+func (t *renewalTracker) currentInterval() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.interval
+}
+
+func (t *renewalTracker) stats(now time.Time) RenewalStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recent = t.prune(now)
+	stats := RenewalStats{
+		Renewals:   t.renewals,
+		Failures:   t.failures,
+		LastMinute: len(t.recent),
+	}
+	if len(t.recent) > 0 {
+		stats.LastRenewal = t.recent[len(t.recent)-1]
+	}
+	if t.interval > 0 {
+		stats.ExpectedPerMinute = float64(time.Minute) / float64(t.interval)
+	}
+	return stats
+}
+
+// RenewalStats reports the renewals the client performed in the past minute
+// and overall, next to the rate expected from the heartbeat interval.
+func (c *Client) RenewalStats() RenewalStats {
+	return c.renewals.stats(c.clock.Now())
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestRenewalStats(t *testing.T) {
+	f := newFakeEureka(t)
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := client.Renew(ctx); err != nil {
+			t.Fatalf("Renew returned error: %v", err)
+		}
+		clk.Advance(30 * time.Second)
+	}
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	_, _ = client.Renew(ctx)
+
+	stats := client.RenewalStats()
+	if stats.Renewals != 3 || stats.Failures != 1 {
+		t.Errorf("Renewals, Failures = %d, %d; want 3, 1", stats.Renewals, stats.Failures)
+	}
+	if stats.LastMinute != 1 {
+		t.Errorf("LastMinute = %d; want 1", stats.LastMinute)
+	}
+	if want := time.Unix(60, 0); !stats.LastRenewal.Equal(want) {
+		t.Errorf("LastRenewal = %s; want %s", stats.LastRenewal, want)
+	}
+	if stats.ExpectedPerMinute != 0 {
+		t.Errorf("ExpectedPerMinute without a heartbeat loop = %v; want 0", stats.ExpectedPerMinute)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = client.RunHeartbeat(runCtx, 20*time.Second)
+	}()
+	clk.BlockUntil(1)
+	if got := client.RenewalStats().ExpectedPerMinute; got != 3 {
+		t.Errorf("ExpectedPerMinute = %v; want 3", got)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// ErrNoInstances is returned when an application has no instance to route to.
+var ErrNoInstances = errors.New("no UP instances available")
+
+// Endpoint is a routable address of a discovered instance.
+type Endpoint struct {
+	InstanceID string
+	Host       string
+	Port       int
+	Secure     bool
+	Instance   eurekaapi.Instance
+}
+
+// URL returns the base URL of the endpoint.
+func (e Endpoint) URL() *url.URL {
+	scheme := "http"
+	if e.Secure {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: net.JoinHostPort(e.Host, strconv.Itoa(e.Port))}
+}
+
+// Resolver turns application names into endpoints using the registry cache.
+type Resolver struct {
+	cache        *Cache
+	balancer     Balancer
+	preferSecure bool
+	outliers     *outlierDetector
+	profiles     map[string]Profile
+}
+
+// ResolverOption configures a Resolver.
+type ResolverOption func(*Resolver)
+
+// WithBalancer sets the strategy used to pick an endpoint. Defaults to
+// round-robin.
+func WithBalancer(balancer Balancer) ResolverOption {
+	return func(r *Resolver) {
+		r.balancer = balancer
+	}
+}
+
+// PreferSecure routes to the secure port of instances that have both their
+// plain and secure ports enabled.
+func PreferSecure() ResolverOption {
+	return func(r *Resolver) {
+		r.preferSecure = true
+	}
+}
+
+// StickySessions routes requests carrying an affinity key (see
+// WithAffinityKey) to the same instance while it stays UP, and other requests
+// round-robin.
+func StickySessions() ResolverOption {
+	return WithBalancer(NewSticky(nil))
+}
+
+// Resolver returns a resolver backed by the client's registry cache.
+func (c *Client) Resolver(opts ...ResolverOption) *Resolver {
+	r := newResolver(c.cache, opts...)
+	r.profiles = c.profiles
+	return r
+}
+
+func newResolver(cache *Cache, opts ...ResolverOption) *Resolver {
+	r := &Resolver{
+		cache:    cache,
+		balancer: NewRoundRobin(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.outliers != nil {
+		r.outliers.clock = cache.clock
+	}
+	return r
+}
+
+// Resolve picks an endpoint of app. If the cache hasn't been populated yet it
+// is refreshed first.
+func (r *Resolver) Resolve(ctx context.Context, app string) (Endpoint, error) {
+	endpoints, err := r.Endpoints(ctx, app)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	return r.balancerFor(app).Pick(ctx, app, endpoints)
+}
+
+func (r *Resolver) balancerFor(app string) Balancer {
+	if p, ok := r.profiles[strings.ToUpper(app)]; ok && p.Balancer != nil {
+		return p.Balancer
+	}
+	return r.balancer
+}
+
+// Endpoints returns the endpoints of all UP instances of app, minus those
+// ejected by outlier detection. If the app's profile names a zone, only the
+// endpoints in that zone are returned, unless it has none.
+func (r *Resolver) Endpoints(ctx context.Context, app string) ([]Endpoint, error) {
+	if err := r.populate(ctx); err != nil {
+		return nil, err
+	}
+
+	application, ok := r.cache.Application(app)
+	if !ok {
+		return nil, fmt.Errorf("%w: application %s is not registered", ErrNoInstances, app)
+	}
+
+	endpoints := r.upEndpoints(application.Instance, r.endpoint)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("%w: application %s", ErrNoInstances, app)
+	}
+	if r.outliers != nil {
+		endpoints = r.outliers.filter(endpoints)
+	}
+	return preferZone(endpoints, r.profiles[strings.ToUpper(app)].Zone), nil
+}
+
+// populate refreshes the cache if it hasn't been populated yet.
+func (r *Resolver) populate(ctx context.Context) error {
+	if _, err := r.cache.Applications(); errors.Is(err, ErrCacheNotPopulated) {
+		if err := r.cache.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to populate registry cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// upEndpoints returns the endpoints of the UP instances among instances, as
+// built by endpoint.
+func (r *Resolver) upEndpoints(instances []eurekaapi.Instance, endpoint func(eurekaapi.Instance) (Endpoint, bool)) []Endpoint {
+	endpoints := make([]Endpoint, 0, len(instances))
+	for _, inst := range instances {
+		if inst.Status != StatusUp {
+			continue
+		}
+		if ep, ok := endpoint(inst); ok {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	return endpoints
+}
+
+// Report records the outcome of a call to ep, for outlier detection. HTTP and
+// gRPC integrations call it after every request; it is a no-op unless the
+// resolver was created with WithOutlierDetection.
+func (r *Resolver) Report(ep Endpoint, err error) {
+	if r.outliers != nil {
+		r.outliers.report(ep.InstanceID, err)
+	}
+}
+
+func (r *Resolver) endpoint(inst eurekaapi.Instance) (Endpoint, bool) {
+	host := inst.IPAddr
+	if host == "" {
+		host = inst.HostName
+	}
+	ep := Endpoint{InstanceID: inst.InstanceID, Host: host, Instance: inst}
+
+	plain := inst.Port != nil && inst.Port.Enabled
+	secure := inst.SecurePort != nil && inst.SecurePort.Enabled
+	switch {
+	case secure && (r.preferSecure || !plain):
+		ep.Port, ep.Secure = inst.SecurePort.Value, true
+	case plain:
+		ep.Port = inst.Port.Value
+	default:
+		return Endpoint{}, false
+	}
+	return ep, true
+}
@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func newStaticCache(apps ...eurekaapi.Application) *Cache {
+	return newCache(func(ctx context.Context) (eurekaapi.Applications, error) {
+		return eurekaapi.Applications{Application: apps}, nil
+	}, time.Minute, time.Minute)
+}
+
+func testInstance(id, status string, port, securePort int) eurekaapi.Instance {
+	inst := eurekaapi.Instance{InstanceID: id, IPAddr: "10.0.0.1", Status: status}
+	if port != 0 {
+		inst.Port = &eurekaapi.Port{Value: port, Enabled: true}
+	}
+	if securePort != 0 {
+		inst.SecurePort = &eurekaapi.Port{Value: securePort, Enabled: true}
+	}
+	return inst
+}
+
+func TestResolverPrefersSecurePort(t *testing.T) {
+	cache := newStaticCache(eurekaapi.Application{
+		Name:     "FOO",
+		Instance: []eurekaapi.Instance{testInstance("foo-1", StatusUp, 8080, 8443)},
+	})
+
+	ep, err := newResolver(cache).Resolve(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if ep.URL().String() != "http://10.0.0.1:8080" {
+		t.Errorf("Resolve() = %s; want http://10.0.0.1:8080", ep.URL())
+	}
+
+	ep, err = newResolver(cache, PreferSecure()).Resolve(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if ep.URL().String() != "https://10.0.0.1:8443" {
+		t.Errorf("Resolve() = %s; want https://10.0.0.1:8443", ep.URL())
+	}
+}
+
+func TestResolverRoundRobinSkipsDownInstances(t *testing.T) {
+	cache := newStaticCache(eurekaapi.Application{
+		Name: "FOO",
+		Instance: []eurekaapi.Instance{
+			testInstance("foo-1", StatusUp, 8080, 0),
+			testInstance("foo-2", StatusDown, 8080, 0),
+			testInstance("foo-3", StatusUp, 8080, 0),
+		},
+	})
+	resolver := newResolver(cache)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		ep, err := resolver.Resolve(context.Background(), "FOO")
+		if err != nil {
+			t.Fatalf("Resolve returned error: %v", err)
+		}
+		got = append(got, ep.InstanceID)
+	}
+	expected := []string{"foo-1", "foo-3", "foo-1", "foo-3"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("resolved instances = %v; want %v", got, expected)
+		}
+	}
+
+	if _, err := resolver.Resolve(context.Background(), "BAR"); !errors.Is(err, ErrNoInstances) {
+		t.Errorf("Resolve of unknown app returned %v; want ErrNoInstances", err)
+	}
+}
+
+func TestResolverEjectsOutliers(t *testing.T) {
+	cache := newStaticCache(eurekaapi.Application{
+		Name: "FOO",
+		Instance: []eurekaapi.Instance{
+			testInstance("foo-1", StatusUp, 8080, 0),
+			testInstance("foo-2", StatusUp, 8080, 0),
+		},
+	})
+	clk := clock.NewManual(time.Unix(0, 0))
+	cache.clock = clk
+	resolver := newResolver(cache, WithOutlierDetection(OutlierDetection{BaseEjectionTime: 10 * time.Second}))
+
+	bad := Endpoint{InstanceID: "foo-1"}
+	for i := 0; i < 5; i++ {
+		resolver.Report(bad, errors.New("connection refused"))
+	}
+
+	idsOf := func() []string {
+		endpoints, err := resolver.Endpoints(context.Background(), "FOO")
+		if err != nil {
+			t.Fatalf("Endpoints returned error: %v", err)
+		}
+		var ids []string
+		for _, ep := range endpoints {
+			ids = append(ids, ep.InstanceID)
+		}
+		return ids
+	}
+
+	if ids := idsOf(); len(ids) != 1 || ids[0] != "foo-2" {
+		t.Errorf("endpoints while ejected = %v; want [foo-2]", ids)
+	}
+	// Re-admittance starts at zero share once the ejection time is over.
+	clk.Advance(10 * time.Second)
+	if ids := idsOf(); len(ids) != 1 {
+		t.Errorf("endpoints at start of re-admittance = %v; want [foo-2]", ids)
+	}
+	clk.Advance(10 * time.Second)
+	if ids := idsOf(); len(ids) != 2 {
+		t.Errorf("endpoints after re-admittance = %v; want both instances", ids)
+	}
+
+	// The last instance standing is never ejected.
+	for _, id := range []string{"foo-1", "foo-2"} {
+		for i := 0; i < 5; i++ {
+			resolver.Report(Endpoint{InstanceID: id}, errors.New("timeout"))
+		}
+	}
+	if ids := idsOf(); len(ids) != 2 {
+		t.Errorf("endpoints with every instance ejected = %v; want both instances", ids)
+	}
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// InstanceRetry configures retries in Transport. Zero fields take their
+// defaults.
+type InstanceRetry struct {
+	// MaxAttempts bounds the attempts per request, including the first.
+	// Defaults to 3.
+	MaxAttempts int
+	// Backoff is the wait before each retry. Defaults to 50 milliseconds.
+	Backoff time.Duration
+}
+
+// DefaultInstanceRetry is the retry policy of clients returned by
+// HTTPClientFor.
+var DefaultInstanceRetry = InstanceRetry{MaxAttempts: 3, Backoff: 50 * time.Millisecond}
+
+// WithInstanceRetry retries idempotent requests that failed to connect or
+// were answered with a 5xx status, preferring another instance than the one
+// that failed. Requests with a body are only retried if it can be replayed,
+// i.e. their GetBody is set.
+func WithInstanceRetry(cfg InstanceRetry) TransportOption {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultInstanceRetry.MaxAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultInstanceRetry.Backoff
+	}
+	return func(t *Transport) {
+		t.retry = &cfg
+	}
+}
+
+// isRetryable reports whether req may be sent more than once.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// sendWithRetry sends req to app, retrying on another instance if possible.
+// The outcome of the last attempt is returned.
+func (t *Transport) sendWithRetry(req *http.Request, app string) (*http.Response, error) {
+	var exclude string
+	for attempt := 1; ; attempt++ {
+		ep, err := t.pick(req, app, exclude)
+		if err != nil && exclude != "" && errors.Is(err, ErrNoInstances) {
+			// The failed instance is the only one; try it again.
+			ep, err = t.pick(req, app, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		out := req
+		if attempt > 1 && req.GetBody != nil {
+			out = req.Clone(req.Context())
+			if out.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.send(out, app, ep)
+		if attempt >= t.retry.MaxAttempts || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := t.resolver.cache.clock.NewTimer(t.retry.Backoff)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C():
+		}
+		exclude = ep.InstanceID
+	}
+}
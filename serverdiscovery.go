@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// ServerDiscovery configures how the client learns about Eureka servers from
+// the registry. Zero fields take their defaults.
+type ServerDiscovery struct {
+	// App is the application the Eureka servers register under. Defaults to
+	// "EUREKA".
+	App string
+	// ContextPath is where the Eureka API is served on the discovered
+	// servers. Defaults to "/eureka".
+	ContextPath string
+}
+
+// WithServerDiscovery keeps the list of Eureka servers up to date from the
+// registry: every fetched registry replaces the servers requests fail over
+// between with the configured service URLs followed by the UP instances of
+// the Eureka application, so that the list follows the cluster as it scales.
+// The configured URLs are always kept, to find the cluster again should all
+// discovered servers go away. Changes are published as EventServersChanged.
+func WithServerDiscovery(discovery ServerDiscovery) Option {
+	if discovery.App == "" {
+		discovery.App = "EUREKA"
+	}
+	if discovery.ContextPath == "" {
+		discovery.ContextPath = "/eureka"
+	}
+	return func(c *Client) {
+		c.serverDiscovery = &discovery
+	}
+}
+
+// updateServers sets the base URLs to the seed URLs followed by the Eureka
+// servers listed in raw.
+func (c *Client) updateServers(raw eurekaapi.Applications) {
+	c.mu.Lock()
+	seeds := c.seedURLs
+	c.mu.Unlock()
+
+	var discovered []string
+	for _, app := range raw.Application {
+		if !strings.EqualFold(app.Name, c.serverDiscovery.App) {
+			continue
+		}
+		for _, inst := range app.Instance {
+			if inst.Status != StatusUp {
+				continue
+			}
+			if u, ok := serverURL(inst, c.serverDiscovery.ContextPath); ok && !slices.Contains(seeds, u) {
+				discovered = append(discovered, u)
+			}
+		}
+	}
+	slices.Sort(discovered)
+	servers := append(slices.Clip(seeds), slices.Compact(discovered)...)
+
+	c.mu.Lock()
+	unchanged := slices.Equal(c.servers, servers)
+	c.servers = servers
+	c.mu.Unlock()
+	if unchanged {
+		return
+	}
+	if err := c.eurekaAPIClient.SetBaseURLs(servers); err != nil {
+		c.events.publish(Event{Type: EventWarning, Detail: "failed to update Eureka servers", Err: err})
+		return
+	}
+	c.events.publish(Event{Type: EventServersChanged, Detail: strings.Join(servers, ",")})
+}
+
+// serverURL returns the service URL of the Eureka server inst, normalized
+// like the configured ones.
+func serverURL(inst eurekaapi.Instance, contextPath string) (string, bool) {
+	host := inst.IPAddr
+	if host == "" {
+		host = inst.HostName
+	}
+	scheme, port := "http", inst.Port
+	if port == nil || !port.Enabled {
+		scheme, port = "https", inst.SecurePort
+	}
+	if host == "" || port == nil || !port.Enabled {
+		return "", false
+	}
+	u, err := eurekaapi.NormalizeBaseURL(scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port.Value)) + "/" + strings.Trim(contextPath, "/"))
+	return u, err == nil
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestServerDiscoveryUpdatesServerList(t *testing.T) {
+	f := newFakeEureka(t)
+	peers := []string{`<instance><instanceId>peer-1</instanceId><ipAddr>10.0.0.1</ipAddr><status>UP</status>` +
+		`<port enabled="true">8761</port></instance>`}
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<applications><application><name>EUREKA</name>`)
+		for _, p := range peers {
+			fmt.Fprint(w, p)
+		}
+		fmt.Fprint(w, `</application></applications>`)
+	})
+	client := newTestClient(t, f, WithServerDiscovery(ServerDiscovery{}))
+	events := client.Events()
+
+	urls := func() []string {
+		var urls []string
+		for _, n := range client.NodeStatus() {
+			urls = append(urls, n.URL)
+		}
+		return urls
+	}
+	if err := client.Cache().Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	seed := f.URL + "/eureka/v2"
+	if got := urls(); len(got) != 2 || got[0] != seed || got[1] != "http://10.0.0.1:8761/eureka/v2" {
+		t.Fatalf("servers = %v; want the seed and the discovered peer", got)
+	}
+	if e := <-events; e.Type != EventServersChanged || e.Detail != seed+",http://10.0.0.1:8761/eureka/v2" {
+		t.Errorf("event = %+v; want EventServersChanged with the new list", e)
+	}
+
+	// The cluster scales in; the seed is kept.
+	peers = nil
+	if err := client.Cache().Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if got := urls(); len(got) != 1 || got[0] != seed {
+		t.Errorf("servers = %v; want only the seed", got)
+	}
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Metadata keys UnregisterWithReason sets before the instance is removed.
+const (
+	MetadataShutdownReason = "shutdownReason"
+	MetadataShutdownTime   = "shutdownTime"
+)
+
+// ShutdownReason says why an instance was unregistered, for post-mortem
+// tooling reading the registry history. Any value may be used; these are the
+// common ones.
+type ShutdownReason string
+
+const (
+	ShutdownReasonDeploy  ShutdownReason = "deploy"
+	ShutdownReasonScaleIn ShutdownReason = "scale-in"
+	ShutdownReasonCrash   ShutdownReason = "crash"
+)
+
+// UnregisterWithReason unregisters the instance like UnregisterInstance, after
+// recording reason and the time in its metadata. Failing to record them is
+// published as an EventWarning and doesn't keep the instance registered. An
+// empty reason records nothing.
+func (c *Client) UnregisterWithReason(ctx context.Context, reason ShutdownReason) error {
+	if reason != "" {
+		kv := map[string]string{
+			MetadataShutdownReason: string(reason),
+			MetadataShutdownTime:   c.serverNow().UTC().Format(time.RFC3339),
+		}
+		if err := c.UpdateMetadata(ctx, kv); err != nil {
+			c.events.publish(Event{
+				Type:   EventWarning,
+				Detail: fmt.Sprintf("failed to record shutdown reason %s", reason),
+				Err:    err,
+			})
+		}
+	}
+	return c.UnregisterInstance(ctx)
+}
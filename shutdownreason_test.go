@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnregisterWithReason(t *testing.T) {
+	f := newFakeEureka(t)
+	var recorded url.Values
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/metadata") {
+			recorded = r.URL.Query()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, f)
+
+	if err := client.UnregisterWithReason(context.Background(), ShutdownReasonScaleIn); err != nil {
+		t.Fatalf("UnregisterWithReason returned error: %v", err)
+	}
+	if got := recorded.Get(MetadataShutdownReason); got != "scale-in" {
+		t.Errorf("shutdownReason = %q; want scale-in", got)
+	}
+	if _, err := time.Parse(time.RFC3339, recorded.Get(MetadataShutdownTime)); err != nil {
+		t.Errorf("shutdownTime = %q; want an RFC 3339 time", recorded.Get(MetadataShutdownTime))
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+}
+
+func TestUnregisterWithReasonUnregistersIfRecordingFails(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t, f)
+	events := client.Events()
+
+	if err := client.UnregisterWithReason(context.Background(), ShutdownReasonDeploy); err != nil {
+		t.Fatalf("UnregisterWithReason returned error: %v", err)
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+	for {
+		select {
+		case e := <-events:
+			if e.Type == EventWarning {
+				return
+			}
+		default:
+			t.Fatal("no EventWarning published for the failed metadata update")
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package sidecar registers a local, non-Go process with Eureka and mirrors
+// the result of its health endpoint into the instance status, in the spirit
+// of Netflix Prana.
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	eureka "github.com/cassis163/eureka-go-client"
+	"github.com/cassis163/eureka-go-client/clock"
+	"github.com/cassis163/eureka-go-client/health"
+)
+
+const (
+	defaultTTL               = 30
+	defaultHeartbeatInterval = 10 * time.Second
+	defaultHealthInterval    = 10 * time.Second
+	defaultHealthTimeout     = 5 * time.Second
+	shutdownTimeout          = 5 * time.Second
+)
+
+// Config describes the process to register.
+type Config struct {
+	EurekaURLs []string
+	AppID      string
+	Host       string
+	IP         net.IP
+	Port       int
+	UseSSL     bool
+	// HealthURL is polled to derive the instance status when no Checker is
+	// set. A 2xx response means UP, anything else DOWN.
+	HealthURL string
+	// Checker overrides the HTTP check against HealthURL, e.g. with a TCP or
+	// exec check.
+	Checker health.Checker
+	// HealthyThreshold and UnhealthyThreshold are the number of consecutive
+	// check results needed to flip the status. Both default to one.
+	HealthyThreshold   int
+	UnhealthyThreshold int
+
+	TTL               uint
+	HeartbeatInterval time.Duration
+	HealthInterval    time.Duration
+	HealthTimeout     time.Duration
+	// Clock schedules the health checks and is handed to the Eureka client.
+	// Defaults to the real clock.
+	Clock clock.Clock
+	// Logger receives the sidecar's registration and status changes and is
+	// handed to the Eureka client, see eureka.WithLogger. Nothing is logged
+	// without one.
+	Logger *slog.Logger
+}
+
+type Sidecar struct {
+	cfg     Config
+	client  eureka.ClientAPI
+	checker health.Checker
+}
+
+// New creates a sidecar for cfg. opts are passed through to the Eureka client.
+func New(cfg Config, opts ...eureka.Option) (*Sidecar, error) {
+	if cfg.HealthURL == "" && cfg.Checker == nil {
+		return nil, errors.New("health URL or checker is required")
+	}
+	if cfg.IP == nil {
+		return nil, errors.New("IP address is required")
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = defaultTTL
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = defaultHealthInterval
+	}
+	if cfg.HealthTimeout <= 0 {
+		cfg.HealthTimeout = defaultHealthTimeout
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real()
+	} else {
+		opts = append([]eureka.Option{eureka.WithClock(cfg.Clock)}, opts...)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.DiscardHandler)
+	} else {
+		opts = append([]eureka.Option{eureka.WithLogger(cfg.Logger)}, opts...)
+	}
+
+	client, err := eureka.NewClient(cfg.EurekaURLs, cfg.AppID, cfg.Host, cfg.Port, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Eureka client: %w", err)
+	}
+	checker := cfg.Checker
+	if checker == nil {
+		checker = &health.HTTPChecker{URL: cfg.HealthURL, Timeout: cfg.HealthTimeout}
+	}
+	return &Sidecar{
+		cfg:     cfg,
+		client:  client,
+		checker: checker,
+	}, nil
+}
+
+// Client returns the underlying Eureka client.
+func (s *Sidecar) Client() eureka.ClientAPI {
+	return s.client
+}
+
+// Run registers the process, keeps its lease alive and mirrors its health
+// into Eureka until ctx is cancelled, then unregisters it.
+func (s *Sidecar) Run(ctx context.Context) error {
+	if _, err := s.client.RegisterInstance(ctx, s.cfg.IP, s.cfg.TTL, s.cfg.UseSSL); err != nil {
+		return err
+	}
+	s.cfg.Logger.InfoContext(ctx, "sidecar registered instance", slog.String("instance", s.client.InstanceID()))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = s.client.RunHeartbeat(ctx, s.cfg.HeartbeatInterval)
+	}()
+
+	s.mirrorHealth(ctx)
+	wg.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.client.UnregisterInstance(shutdownCtx)
+}
+
+func (s *Sidecar) mirrorHealth(ctx context.Context) {
+	ticker := s.cfg.Clock.NewTicker(s.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	// RegisterInstance registers the instance as UP.
+	threshold := health.NewThreshold(true, s.cfg.HealthyThreshold, s.cfg.UnhealthyThreshold)
+	current := eureka.StatusUp
+	for {
+		err := s.checker.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		healthy, _ := threshold.Observe(err)
+		status := eureka.StatusDown
+		if healthy {
+			status = eureka.StatusUp
+		}
+		if status != current {
+			if err := s.client.SetStatus(ctx, status); err != nil {
+				s.cfg.Logger.ErrorContext(ctx, "sidecar failed to mirror status",
+					slog.String("status", string(status)),
+					slog.Any("error", err),
+				)
+			} else {
+				s.cfg.Logger.InfoContext(ctx, "sidecar status changed",
+					slog.String("from", string(current)),
+					slog.String("to", string(status)),
+				)
+				current = status
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
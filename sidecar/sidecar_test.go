@@ -0,0 +1,69 @@
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSidecarMirrorsHealthIntoStatus(t *testing.T) {
+	var mu sync.Mutex
+	var statuses []string
+	eurekaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/status"):
+			mu.Lock()
+			statuses = append(statuses, r.URL.Query().Get("value"))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer eurekaServer.Close()
+
+	healthServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer healthServer.Close()
+
+	var logs bytes.Buffer
+	s, err := New(Config{
+		EurekaURLs:        []string{eurekaServer.URL},
+		AppID:             "legacy-app",
+		Host:              "127.0.0.1",
+		IP:                net.ParseIP("127.0.0.1"),
+		Port:              9000,
+		HealthURL:         healthServer.URL,
+		HeartbeatInterval: time.Hour,
+		HealthInterval:    10 * time.Millisecond,
+		Logger:            slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(statuses) != 1 || statuses[0] != "DOWN" {
+		t.Errorf("mirrored statuses = %v; want [DOWN]", statuses)
+	}
+	if !strings.Contains(logs.String(), `msg="sidecar status changed" from=UP to=DOWN`) {
+		t.Errorf("logs = %q; want the status change", logs.String())
+	}
+}
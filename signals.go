@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals drains and unregisters the instance when the process receives
+// one of signals, SIGINT or SIGTERM if none are given. The instance is put
+// into lame-duck mode for the duration set with WithShutdownDrain, or
+// unregistered right away without one.
+//
+// HandleSignals returns immediately. The returned channel receives an error
+// if the drain fails and is closed once it is done, after which the process
+// can shut down. Heartbeat loops the application started keep running until
+// their context is cancelled, but no longer re-register the instance once it
+// is unregistered. Once a signal arrived, further ones get their default
+// behavior again, so a second Ctrl-C terminates the process.
+func (c *Client) HandleSignals(signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer c.safetyNet()
+		<-sigCh
+		signal.Stop(sigCh)
+		if err := c.shutdown(context.Background()); err != nil {
+			errCh <- err
+		}
+	}()
+	return errCh
+}
+
+// shutdown drains the instance if configured to, and unregisters it.
+func (c *Client) shutdown(ctx context.Context) error {
+	if c.shutdownDrain > 0 {
+		return c.LameDuck(ctx, c.shutdownDrain)
+	}
+	shutdownCtx, cancel := context.WithTimeout(ctx, defaultShutdownTimeout)
+	defer cancel()
+	return c.UnregisterInstance(shutdownCtx)
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestHandleSignalsUnregisters(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+
+	errCh := client.HandleSignals(os.Interrupt)
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess returned error: %v", err)
+	}
+	if err := proc.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot signal the test process: %v", err)
+	}
+	select {
+	case err, ok := <-errCh:
+		if ok {
+			t.Fatalf("HandleSignals reported error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HandleSignals did not finish")
+	}
+	if got := f.count(http.MethodDelete); got != 1 {
+		t.Errorf("server received %d unregistrations; want 1", got)
+	}
+}
+
+func TestShutdownWithRunningHeartbeats(t *testing.T) {
+	f := newFakeEureka(t)
+	var deleted atomic.Bool
+	notFound := make(chan struct{}, 100)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		if deleted.Load() {
+			w.WriteHeader(http.StatusNotFound)
+			notFound <- struct{}{}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	f.handle(http.MethodDelete, func(w http.ResponseWriter, r *http.Request) {
+		deleted.Store(true)
+		w.WriteHeader(http.StatusOK)
+	})
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = client.RunHeartbeat(ctx, 10*time.Second) }()
+	clk.BlockUntil(1)
+
+	if err := client.shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown returned error: %v", err)
+	}
+
+	// The loop only sends its second heartbeat once the 404 of the first was
+	// handled.
+	for got := 0; got < 2; {
+		clk.Advance(10 * time.Second)
+		select {
+		case <-notFound:
+			got++
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if got := f.count(http.MethodPost); got != 1 {
+		t.Errorf("server received %d registrations; want the instance to stay unregistered", got)
+	}
+}
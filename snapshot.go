@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// SnapshotFormat is the encoding of a cache snapshot. XML is offered in place
+// of YAML: it is Eureka's other wire format, and the standard library encodes
+// it, so snapshots don't pull in a YAML dependency.
+type SnapshotFormat int
+
+const (
+	// SnapshotJSON encodes the registry like Eureka's JSON API does.
+	SnapshotJSON SnapshotFormat = iota
+	// SnapshotXML encodes the registry like Eureka's XML API does.
+	SnapshotXML
+)
+
+func (f SnapshotFormat) String() string {
+	switch f {
+	case SnapshotJSON:
+		return "json"
+	case SnapshotXML:
+		return "xml"
+	default:
+		return fmt.Sprintf("SnapshotFormat(%d)", int(f))
+	}
+}
+
+// snapshotJSON is the JSON document root, matching Eureka's GET /apps.
+type snapshotJSON struct {
+	Applications eurekaapi.Applications `json:"applications"`
+}
+
+// Snapshot writes the cached registry to w, e.g. to attach the discovered
+// topology to a bug report. It can be read back with LoadSnapshot.
+func (c *Cache) Snapshot(w io.Writer, format SnapshotFormat) error {
+	apps, err := c.Applications()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case SnapshotJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(snapshotJSON{Applications: apps})
+	case SnapshotXML:
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		err = enc.Encode(apps)
+	default:
+		return fmt.Errorf("unsupported snapshot format %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s snapshot: %w", format, err)
+	}
+	return nil
+}
+
+// LoadSnapshot replaces the cached registry with one written by Snapshot, so
+// a recorded topology can be replayed in tests. The client's instance filters
+// apply as if the registry had been fetched. A running refresh loop will
+// overwrite it on its next refresh.
+func (c *Cache) LoadSnapshot(r io.Reader, format SnapshotFormat) error {
+	var apps eurekaapi.Applications
+	var err error
+	switch format {
+	case SnapshotJSON:
+		var doc snapshotJSON
+		err = json.NewDecoder(r).Decode(&doc)
+		apps = doc.Applications
+	case SnapshotXML:
+		err = xml.NewDecoder(r).Decode(&apps)
+	default:
+		return fmt.Errorf("unsupported snapshot format %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s snapshot: %w", format, err)
+	}
+
+	c.store(apps, c.clock.Now())
+	c.notify(nil)
+	return nil
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	inst := testInstance("foo-1", StatusUp, 8080, 0)
+	inst.Metadata = eurekaapi.NewMetadata(map[string]string{MetadataZone: "eu-1"})
+	source := newStaticCache(eurekaapi.Application{Name: "FOO", Instance: []eurekaapi.Instance{inst}})
+	if err := source.Refresh(t.Context()); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+
+	for _, format := range []SnapshotFormat{SnapshotJSON, SnapshotXML} {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := source.Snapshot(&buf, format); err != nil {
+				t.Fatalf("Snapshot returned error: %v", err)
+			}
+
+			replay := newStaticCache()
+			if err := replay.LoadSnapshot(&buf, format); err != nil {
+				t.Fatalf("LoadSnapshot returned error: %v", err)
+			}
+			app, ok := replay.Application("foo")
+			if !ok || len(app.Instance) != 1 {
+				t.Fatalf("Application(foo) = %+v, %t; want the snapshotted instance", app, ok)
+			}
+			got := app.Instance[0]
+			zone, _ := got.Metadata.Get(MetadataZone)
+			if got.InstanceID != "foo-1" || got.Port == nil || got.Port.Value != 8080 || zone != "eu-1" {
+				t.Errorf("replayed instance = %+v; want foo-1 on port 8080 in zone eu-1", got)
+			}
+		})
+	}
+}
+
+func TestSnapshotBeforeRefresh(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newStaticCache().Snapshot(&buf, SnapshotJSON); !errors.Is(err, ErrCacheNotPopulated) {
+		t.Errorf("Snapshot before refresh = %v; want ErrCacheNotPopulated", err)
+	}
+}
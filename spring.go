@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"maps"
+	"strconv"
+)
+
+// Metadata keys Spring Cloud Netflix and Spring Boot Admin read from Eureka
+// instances.
+const (
+	MetadataManagementPort = "management.port"
+	MetadataJMXPort        = "jmx.port"
+	MetadataInstanceID     = "instanceId"
+)
+
+// registrationMetadata returns the metadata to register with: the Spring Cloud
+// defaults and any detected container metadata, overridden by anything the
+// user configured explicitly.
+func (c *Client) registrationMetadata() map[string]string {
+	managementPort := c.managementPort
+	if managementPort == 0 {
+		managementPort = c.port
+	}
+
+	kv := map[string]string{
+		MetadataManagementPort: strconv.Itoa(managementPort),
+		MetadataInstanceID:     c.instanceID,
+	}
+	if c.jmxPort != 0 {
+		kv[MetadataJMXPort] = strconv.Itoa(c.jmxPort)
+	}
+	maps.Copy(kv, c.containerMetadata)
+	maps.Copy(kv, c.metadata)
+	return kv
+}
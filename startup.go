@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// StartupRetryPolicy controls how a failed initial registration is retried.
+type StartupRetryPolicy struct {
+	// InitialBackoff is the wait before the first retry, one second if
+	// unset. It doubles with every failed attempt, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxAttempts bounds the number of registration attempts, including the
+	// first one. Zero retries until the context is cancelled.
+	MaxAttempts int
+	// OnRetry, if set, is called after each failed attempt, numbered from 1.
+	OnRetry func(attempt int, err error)
+}
+
+// DefaultStartupRetryPolicy retries registration indefinitely, backing off
+// from one second to 30 seconds.
+var DefaultStartupRetryPolicy = StartupRetryPolicy{
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// WithStartupRetry keeps retrying a registration that failed, e.g. because
+// Eureka was down when the process started, instead of failing
+// RegisterInstance. Run waits for the registration before sending
+// heartbeats; other callers can wait on Ready.
+func WithStartupRetry(policy StartupRetryPolicy) Option {
+	return func(c *Client) {
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = DefaultStartupRetryPolicy.InitialBackoff
+		}
+		c.startupRetry = &policy
+	}
+}
+
+// Ready returns a channel that is closed once the instance has been
+// registered for the first time.
+func (c *Client) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Registered returns a channel that is closed once the registration has been
+// confirmed by a successful heartbeat. Unlike Ready, it guarantees that the
+// server knows the instance and renews its lease, so components that must
+// only start once the instance is discoverable can wait on it.
+func (c *Client) Registered() <-chan struct{} {
+	return c.registered
+}
+
+// retryRegistration retries registering instance after a first attempt
+// failed with err, until it succeeds, ctx is done or the attempts are used up.
+func (c *Client) retryRegistration(ctx context.Context, instance *eurekaapi.Instance, err error) error {
+	policy := c.startupRetry
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err)
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		timer := c.clock.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+		if err = c.register(ctx, instance); err == nil {
+			return nil
+		}
+		backoff = min(2*backoff, max(policy.MaxBackoff, policy.InitialBackoff))
+	}
+}
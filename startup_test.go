@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+)
+
+func TestStartupRetryRegistersInBackground(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPost, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	var mu sync.Mutex
+	var attempts []int
+	policy := StartupRetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		OnRetry: func(attempt int, _ error) {
+			mu.Lock()
+			attempts = append(attempts, attempt)
+			mu.Unlock()
+		},
+	}
+	clk := clock.NewManual(time.Unix(0, 0))
+	client := newTestClient(t, f, WithClock(clk), WithStartupRetry(policy))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	clk.BlockUntil(1)
+	select {
+	case <-client.Ready():
+		t.Fatal("client ready before registration succeeded")
+	default:
+	}
+
+	f.handle(http.MethodPost, nil)
+	clk.Advance(2 * time.Second)
+	select {
+	case <-client.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("client not ready after registration succeeded")
+	}
+	if got := f.count(http.MethodPost); got != 3 {
+		t.Errorf("server received %d registrations; want 3", got)
+	}
+	if got := client.State(); got != StateRegistered {
+		t.Errorf("State() = %s; want REGISTERED", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("OnRetry saw attempts %v; want [1 2]", attempts)
+	}
+}
+
+func TestRunGivesUpAfterMaxAttempts(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPost, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t, f, WithStartupRetry(StartupRetryPolicy{
+		InitialBackoff: time.Millisecond,
+		MaxAttempts:    3,
+	}))
+
+	errCh := client.Run(context.Background(), RunOptions{IP: testIP})
+	if err := <-errCh; err == nil {
+		t.Fatal("Run reported no error")
+	}
+	if got := f.count(http.MethodPost); got != 3 {
+		t.Errorf("server received %d registrations; want 3", got)
+	}
+	if got := f.count(http.MethodPut); got != 0 {
+		t.Errorf("server received %d heartbeats; want 0", got)
+	}
+}
+
+func TestRegisteredAfterFirstHeartbeat(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodPut, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client := newTestClient(t, f)
+	ctx := context.Background()
+
+	if _, err := client.RegisterInstance(ctx, testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if err := client.Heartbeat(ctx); err == nil {
+		t.Fatal("Heartbeat for an unknown instance returned nil")
+	}
+	select {
+	case <-client.Registered():
+		t.Fatal("client registered before a heartbeat succeeded")
+	default:
+	}
+
+	f.handle(http.MethodPut, nil)
+	if err := client.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+	select {
+	case <-client.Registered():
+	default:
+		t.Fatal("client not registered after a heartbeat succeeded")
+	}
+	// Later heartbeats must not close the channel again.
+	if err := client.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat returned error: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package pkg
+
+import eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+
+// Instance statuses understood by Eureka.
+const (
+	StatusUp           = eurekaapi.UP
+	StatusDown         = eurekaapi.DOWN
+	StatusStarting     = eurekaapi.STARTING
+	StatusOutOfService = eurekaapi.OUT_OF_SERVICE
+	StatusUnknown      = eurekaapi.UNKNOWN
+)
+
+// ActionType tells how an instance in a registry delta changed.
+type ActionType = eurekaapi.ActionType
+
+// Action types of instances in a registry delta.
+const (
+	ActionTypeAdded    = eurekaapi.ADDED
+	ActionTypeModified = eurekaapi.MODIFIED
+	ActionTypeDeleted  = eurekaapi.DELETED
+)
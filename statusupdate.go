@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// SetStatusWithMetadata changes the status and metadata of the instance
+// together, e.g. to publish a new version and mark the instance UP. Eureka
+// has no single call for both, so they are applied in the order that keeps
+// consumers from routing by a half-applied change: the metadata first when
+// the instance becomes UP, the status first otherwise. If the second step
+// fails, the first one is rolled back to the state read from the server
+// beforehand; a failed rollback is joined to the returned error.
+func (c *Client) SetStatusWithMetadata(ctx context.Context, status string, kv map[string]string) error {
+	before, err := c.eurekaAPIClient.GetInstance(ctx, c.appID, c.instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to read instance %s before updating it: %w", c.instanceID, err)
+	}
+
+	setStatus := func() error { return c.SetStatus(ctx, status) }
+	updateMetadata := func() error { return c.UpdateMetadata(ctx, kv) }
+	restoreStatus := func() error { return c.restoreStatus(ctx, before) }
+	restoreMetadata := func() error { return c.UpdateMetadata(ctx, previousMetadata(before, kv)) }
+
+	first, second, rollback := setStatus, updateMetadata, restoreStatus
+	if status == StatusUp {
+		first, second, rollback = updateMetadata, setStatus, restoreMetadata
+	}
+	if err := first(); err != nil {
+		return err
+	}
+	if err := second(); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back: %w", rbErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreStatus brings the status of the instance back to what it was in
+// before: the overridden status if there was one, or else no override.
+func (c *Client) restoreStatus(ctx context.Context, before eurekaapi.Instance) error {
+	if before.OverriddenStatus != "" && before.OverriddenStatus != StatusUnknown {
+		return c.SetStatus(ctx, before.OverriddenStatus)
+	}
+	return c.ClearStatusOverride(ctx, before.Status)
+}
+
+// previousMetadata returns the values the keys of kv had in before. Keys that
+// were missing are cleared, as Eureka can't remove them.
+func previousMetadata(before eurekaapi.Instance, kv map[string]string) map[string]string {
+	prev := make(map[string]string, len(kv))
+	for k := range kv {
+		prev[k], _ = before.Metadata.Get(k)
+	}
+	return prev
+}
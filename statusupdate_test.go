@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetStatusWithMetadataRollsBack(t *testing.T) {
+	f := newFakeEureka(t)
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<instance><instanceId>x</instanceId><status>STARTING</status>` +
+			`<metadata><version>1</version></metadata></instance>`))
+	})
+	var mu sync.Mutex
+	var puts []string
+	f.handle(http.MethodPut, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		puts = append(puts, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]+"?"+r.URL.RawQuery)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/status") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := newTestClient(t, f)
+
+	err := client.SetStatusWithMetadata(context.Background(), StatusUp, map[string]string{"version": "2", "color": "blue"})
+	if err == nil {
+		t.Fatal("SetStatusWithMetadata returned nil although the status update failed")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"metadata?color=blue&version=2",
+		"status?value=UP",
+		"metadata?color=&version=1",
+	}
+	if len(puts) != len(want) {
+		t.Fatalf("server received %v; want %v", puts, want)
+	}
+	for i := range want {
+		if puts[i] != want[i] {
+			t.Errorf("update %d = %q; want %q", i, puts[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+type affinityKey struct{}
+
+// WithAffinityKey returns a context carrying the key, such as a user or
+// session ID, that sticky balancers route on.
+func WithAffinityKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, affinityKey{}, key)
+}
+
+// AffinityKeyFrom returns the affinity key carried by ctx, if any.
+func AffinityKeyFrom(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(affinityKey{}).(string)
+	return key, ok && key != ""
+}
+
+// Sticky routes every affinity key to the same endpoint for as long as that
+// endpoint stays UP. It uses rendezvous hashing, so an instance leaving only
+// moves the keys that were routed to it. Requests without an affinity key are
+// handed to the fallback balancer.
+type Sticky struct {
+	fallback Balancer
+}
+
+// NewSticky returns a sticky balancer that uses fallback for requests without
+// an affinity key. A nil fallback defaults to round-robin.
+func NewSticky(fallback Balancer) *Sticky {
+	if fallback == nil {
+		fallback = NewRoundRobin()
+	}
+	return &Sticky{fallback: fallback}
+}
+
+func (s *Sticky) Pick(ctx context.Context, app string, endpoints []Endpoint) (Endpoint, error) {
+	if len(endpoints) == 0 {
+		return Endpoint{}, ErrNoInstances
+	}
+	key, ok := AffinityKeyFrom(ctx)
+	if !ok {
+		return s.fallback.Pick(ctx, app, endpoints)
+	}
+
+	best, bestScore := 0, uint64(0)
+	for i, ep := range endpoints {
+		if score := rendezvousScore(key, ep.InstanceID); i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return endpoints[best], nil
+}
+
+func rendezvousScore(key, instanceID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(instanceID))
+	return mix64(h.Sum64())
+}
+
+// mix64 is the splitmix64 finalizer. FNV alone distributes similar inputs,
+// such as sequential instance IDs, poorly.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
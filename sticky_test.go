@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestStickySessions(t *testing.T) {
+	app := eurekaapi.Application{Name: "FOO"}
+	for i := 0; i < 5; i++ {
+		app.Instance = append(app.Instance, testInstance(fmt.Sprintf("foo-%d", i), StatusUp, 8080, 0))
+	}
+	resolver := newResolver(newStaticCache(app), StickySessions())
+
+	picks := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		ctx := WithAffinityKey(context.Background(), key)
+		first, err := resolver.Resolve(ctx, "foo")
+		if err != nil {
+			t.Fatalf("Resolve returned error: %v", err)
+		}
+		second, _ := resolver.Resolve(ctx, "foo")
+		if first.InstanceID != second.InstanceID {
+			t.Errorf("key %s routed to %s and then %s", key, first.InstanceID, second.InstanceID)
+		}
+		picks[key] = first.InstanceID
+	}
+
+	// Taking one instance down only moves the keys that were routed to it.
+	app.Instance[2].Status = StatusDown
+	resolver = newResolver(newStaticCache(app), StickySessions())
+	for key, before := range picks {
+		after, err := resolver.Resolve(WithAffinityKey(context.Background(), key), "foo")
+		if err != nil {
+			t.Fatalf("Resolve returned error: %v", err)
+		}
+		if before != "foo-2" && after.InstanceID != before {
+			t.Errorf("key %s moved from %s to %s", key, before, after.InstanceID)
+		}
+		if after.InstanceID == "foo-2" {
+			t.Errorf("key %s routed to DOWN instance", key)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// ErrCircuitOpen is returned by Transport when the circuit of every instance
+// of the target application is open.
+var ErrCircuitOpen = errors.New("circuit open for every instance")
+
+// Transport is an http.RoundTripper that routes requests addressed to an
+// application name, such as http://inventory/items, to a discovered instance
+// of that application. URLs with the vip or svip scheme are routed by VIP
+// address instead, see SchemeVIP. Call outcomes are reported to the resolver
+// for outlier detection.
+type Transport struct {
+	resolver *Resolver
+	next     http.RoundTripper
+	breakers *breakerSet
+	hedging  *hedger
+	retry    *InstanceRetry
+	metrics  func(RequestMetrics)
+	// app, if set, receives every request regardless of its host.
+	app string
+}
+
+// TransportOption configures a Transport.
+type TransportOption func(*Transport)
+
+// WithBaseTransport sets the round tripper that sends the rewritten requests.
+// Defaults to http.DefaultTransport.
+func WithBaseTransport(next http.RoundTripper) TransportOption {
+	return func(t *Transport) {
+		t.next = next
+	}
+}
+
+// NewTransport returns a discovery-aware round tripper using resolver.
+func NewTransport(resolver *Resolver, opts ...TransportOption) *Transport {
+	t := &Transport{resolver: resolver, next: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.breakers != nil {
+		t.breakers.clock = resolver.cache.clock
+	}
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	app := req.URL.Hostname()
+	if t.app != "" {
+		app = t.app
+	}
+	if t.hedging != nil && isHedgeable(req) {
+		return t.hedge(req, app)
+	}
+	if t.retry != nil && isRetryable(req) {
+		return t.sendWithRetry(req, app)
+	}
+	ep, err := t.pick(req, app, "")
+	if err != nil {
+		return nil, err
+	}
+	return t.send(req, app, ep)
+}
+
+// send sends req to ep and records the outcome.
+func (t *Transport) send(req *http.Request, app string, ep Endpoint) (*http.Response, error) {
+	start := t.resolver.cache.clock.Now()
+	resp, err := t.next.RoundTrip(rewrite(req, ep))
+	elapsed := t.resolver.cache.clock.Since(start)
+	if err == nil && t.hedging != nil {
+		t.hedging.observe(app, elapsed)
+	}
+	if t.metrics != nil {
+		t.observe(req, app, ep, resp, err, elapsed)
+	}
+
+	if err != nil && req.Context().Err() != nil {
+		// Cancelled by the caller or as the losing hedge; says nothing
+		// about the instance, so a probe leaves its circuit to the next one.
+		if t.breakers != nil {
+			t.breakers.release(ep.InstanceID)
+		}
+		return nil, err
+	}
+
+	failure := err
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		failure = fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	t.resolver.Report(ep, failure)
+	if t.breakers != nil {
+		t.breakers.record(ep.InstanceID, failure == nil)
+	}
+	return resp, err
+}
+
+// pick chooses the endpoint of app to send req to, skipping the instance with
+// ID exclude.
+func (t *Transport) pick(req *http.Request, app, exclude string) (Endpoint, error) {
+	endpoints, err := t.endpoints(req, app)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if exclude != "" {
+		endpoints = slices.DeleteFunc(slices.Clone(endpoints), func(ep Endpoint) bool { return ep.InstanceID == exclude })
+		if len(endpoints) == 0 {
+			return Endpoint{}, fmt.Errorf("%w: application %s", ErrNoInstances, app)
+		}
+	}
+	if t.breakers != nil {
+		endpoints = t.breakers.allowed(endpoints)
+		if len(endpoints) == 0 {
+			return Endpoint{}, fmt.Errorf("%w: application %s", ErrCircuitOpen, app)
+		}
+	}
+	ep, err := t.resolver.balancerFor(app).Pick(req.Context(), app, endpoints)
+	if err != nil && t.breakers != nil {
+		// No request goes out, so a probe admitted above must not hold its
+		// circuit half-open.
+		for _, ep := range endpoints {
+			t.breakers.release(ep.InstanceID)
+		}
+	}
+	return ep, err
+}
+
+// rewrite returns a copy of req addressed to ep.
+func rewrite(req *http.Request, ep Endpoint) *http.Request {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = "http"
+	if ep.Secure {
+		out.URL.Scheme = "https"
+	}
+	out.URL.Host = net.JoinHostPort(ep.Host, strconv.Itoa(ep.Port))
+	out.Host = ""
+	return out
+}
@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cassis163/eureka-go-client/clock"
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// backendInstance returns an UP instance pointing at server.
+func backendInstance(t *testing.T, id string, server *httptest.Server) eurekaapi.Instance {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portStr)
+	return eurekaapi.Instance{
+		InstanceID: id,
+		IPAddr:     host,
+		Status:     StatusUp,
+		Port:       &eurekaapi.Port{Value: port, Enabled: true},
+	}
+}
+
+func TestTransportRoutesToInstance(t *testing.T) {
+	var gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer backend.Close()
+
+	cache := newStaticCache(eurekaapi.Application{Name: "INVENTORY", Instance: []eurekaapi.Instance{backendInstance(t, "inv-1", backend)}})
+	client := &http.Client{Transport: NewTransport(newResolver(cache))}
+
+	resp, err := client.Get("http://inventory/items")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot || gotPath != "/items" {
+		t.Errorf("got status %d for path %q; want 418 for /items", resp.StatusCode, gotPath)
+	}
+}
+
+func TestTransportCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer backend.Close()
+
+	cache := newStaticCache(eurekaapi.Application{Name: "INVENTORY", Instance: []eurekaapi.Instance{backendInstance(t, "inv-1", backend)}})
+	clk := clock.NewManual(time.Unix(0, 0))
+	cache.clock = clk
+	transport := NewTransport(newResolver(cache), WithCircuitBreaker(CircuitBreaker{FailureThreshold: 2, OpenTimeout: time.Minute}))
+	client := &http.Client{Transport: transport}
+
+	get := func() error {
+		resp, err := client.Get("http://inventory/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	healthy.Store(false)
+	for i := 0; i < 2; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Get with open circuit returned %v; want ErrCircuitOpen", err)
+	}
+
+	// A failed half-open probe keeps the circuit open.
+	clk.Advance(time.Minute)
+	if err := get(); err != nil {
+		t.Fatalf("probe returned error: %v", err)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Get after failed probe returned %v; want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it.
+	healthy.Store(true)
+	clk.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("Get after successful probe returned error: %v", err)
+		}
+	}
+}
+
+// flakyBalancer fails every pick while fail is set.
+type flakyBalancer struct {
+	fail atomic.Bool
+}
+
+func (b *flakyBalancer) Pick(ctx context.Context, app string, endpoints []Endpoint) (Endpoint, error) {
+	if b.fail.Load() {
+		return Endpoint{}, errors.New("no pick")
+	}
+	return endpoints[0], nil
+}
+
+func TestTransportCircuitBreakerReleasesUnsentProbe(t *testing.T) {
+	var healthy atomic.Bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer backend.Close()
+
+	cache := newStaticCache(eurekaapi.Application{Name: "INVENTORY", Instance: []eurekaapi.Instance{backendInstance(t, "inv-1", backend)}})
+	clk := clock.NewManual(time.Unix(0, 0))
+	cache.clock = clk
+	balancer := &flakyBalancer{}
+	transport := NewTransport(newResolver(cache, WithBalancer(balancer)), WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Minute}))
+	client := &http.Client{Transport: transport}
+	get := func() error {
+		resp, err := client.Get("http://inventory/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	clk.Advance(time.Minute)
+	// The probe is admitted but never sent.
+	balancer.fail.Store(true)
+	if err := get(); err == nil {
+		t.Fatal("Get with a failing balancer succeeded")
+	}
+
+	balancer.fail.Store(false)
+	healthy.Store(true)
+	if err := get(); err != nil {
+		t.Fatalf("Get after the unsent probe returned %v; want the instance probed again", err)
+	}
+}
+
+func TestTransportCircuitBreakerRetriesCancelledProbe(t *testing.T) {
+	var healthy atomic.Bool
+	hang := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hang" {
+			close(hang)
+			<-r.Context().Done()
+			return
+		}
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer backend.Close()
+
+	cache := newStaticCache(eurekaapi.Application{Name: "INVENTORY", Instance: []eurekaapi.Instance{backendInstance(t, "inv-1", backend)}})
+	clk := clock.NewManual(time.Unix(0, 0))
+	cache.clock = clk
+	transport := NewTransport(newResolver(cache), WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Minute}))
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get("http://inventory/")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	// The caller gives up on the probe before it is answered.
+	clk.Advance(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-hang
+		cancel()
+	}()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://inventory/hang", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled probe returned %v; want context.Canceled", err)
+	}
+
+	healthy.Store(true)
+	resp, err = client.Get("http://inventory/")
+	if err != nil {
+		t.Fatalf("Get after the cancelled probe returned %v; want the instance probed again", err)
+	}
+	resp.Body.Close()
+}
+
+func TestTransportHedgesSlowRequests(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer fast.Close()
+
+	cache := newStaticCache(eurekaapi.Application{Name: "INVENTORY", Instance: []eurekaapi.Instance{
+		backendInstance(t, "slow", slow),
+		backendInstance(t, "fast", fast),
+	}})
+	transport := NewTransport(newResolver(cache), WithHedging(Hedging{MaxDelay: 20 * time.Millisecond}))
+	client := &http.Client{Transport: transport}
+
+	start := time.Now()
+	resp, err := client.Get("http://inventory/")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d; want the fast instance's %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hedged request took %v; want it to finish with the fast instance", elapsed)
+	}
+}
+
+func TestHedgingDelayPercentile(t *testing.T) {
+	h := &hedger{cfg: Hedging{Percentile: 0.9, MinDelay: time.Millisecond, MaxDelay: time.Second}, latencies: make(map[string]*latencyWindow)}
+	if got := h.delay("FOO"); got != time.Second {
+		t.Errorf("delay without samples = %v; want MaxDelay", got)
+	}
+	for i := 1; i <= 20; i++ {
+		h.observe("FOO", time.Duration(i)*time.Millisecond)
+	}
+	if got := h.delay("FOO"); got != 18*time.Millisecond {
+		t.Errorf("delay = %v; want 18ms", got)
+	}
+}
+
+func TestTransportRoutesByVIP(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer secure.Close()
+
+	plainInst := backendInstance(t, "plain", plain)
+	plainInst.VipAddress = "orders.internal, inventory.internal"
+	secureInst := backendInstance(t, "secure", secure)
+	secureInst.SecurePort = &eurekaapi.Port{Value: secureInst.Port.Value, Enabled: true}
+	secureInst.Port = nil
+	secureInst.SecureVipAddress = "inventory.internal"
+	cache := newStaticCache(
+		eurekaapi.Application{Name: "INVENTORY-V1", Instance: []eurekaapi.Instance{plainInst}},
+		eurekaapi.Application{Name: "INVENTORY-V2", Instance: []eurekaapi.Instance{secureInst}},
+	)
+	client := &http.Client{Transport: NewTransport(newResolver(cache), WithBaseTransport(secure.Client().Transport))}
+
+	for url, want := range map[string]int{
+		"vip://INVENTORY.internal/items":  http.StatusTeapot,
+		"svip://inventory.internal/items": http.StatusAccepted,
+	} {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("Get(%s) returned error: %v", url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Get(%s) got status %d; want %d", url, resp.StatusCode, want)
+		}
+	}
+	if _, err := client.Get("vip://unknown.internal/"); !errors.Is(err, ErrNoInstances) {
+		t.Errorf("Get of an unknown VIP returned %v; want ErrNoInstances", err)
+	}
+}
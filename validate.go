@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// ErrInvalidConfig is wrapped by the errors returned from Validate.
+var ErrInvalidConfig = errors.New("invalid Eureka client configuration")
+
+// ErrInvalidInstance is returned by RegisterInstance when the assembled
+// registration would be rejected by the server or be unusable for consumers.
+var ErrInvalidInstance = eurekaapi.ErrInvalidInstance
+
+// Validate reports every problem with the configuration at once, so they can
+// be fixed at startup rather than surfacing as failed requests at runtime.
+func (cfg Config) Validate() error {
+	var problems []error
+	if len(cfg.EurekaServiceURLs) == 0 {
+		problems = append(problems, errors.New("no Eureka service URLs: provide at least one, e.g. http://localhost:8761/eureka"))
+	}
+	seen := make(map[string]string, len(cfg.EurekaServiceURLs))
+	for _, u := range cfg.EurekaServiceURLs {
+		norm, err := eurekaapi.NormalizeBaseURL(u)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid Eureka service URL %q: %w", u, err))
+			continue
+		}
+		if prev, ok := seen[norm]; ok {
+			problems = append(problems, fmt.Errorf("duplicate Eureka service URL %q (same as %q): failover would retry the same node", u, prev))
+			continue
+		}
+		seen[norm] = u
+	}
+	if cfg.AppID == "" {
+		problems = append(problems, errors.New("empty AppID: set it to the name consumers discover the service by"))
+	}
+	if cfg.Host == "" {
+		problems = append(problems, errors.New("empty Host: set it to the hostname or IP other services reach this instance on"))
+	}
+	if err := validateAddress(cfg.Host, cfg.IP); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		problems = append(problems, fmt.Errorf("port %d out of range: set it to the port the service listens on", cfg.Port))
+	}
+	if err := cfg.Run.validate(); err != nil {
+		problems = append(problems, err)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(problems...))
+}
+
+// validateAddress checks that the host name and IP address an instance
+// registers with can describe the same instance. A nil ip is not checked.
+func validateAddress(host string, ip net.IP) error {
+	if ip == nil {
+		return nil
+	}
+	if ip.To4() == nil {
+		return fmt.Errorf("IP address %s is not IPv4: Eureka registers instances by their IPv4 address", ip)
+	}
+	if hostIP := net.ParseIP(host); hostIP != nil && !hostIP.Equal(ip) {
+		return fmt.Errorf("host %s is an IP address other than the registered IP %s: consumers resolving by host name would reach a different address", host, ip)
+	}
+	return nil
+}
+
+// Validate checks the options for mistakes such as a TTL shorter than the
+// heartbeat interval, which would let the lease expire between heartbeats.
+func (opts RunOptions) Validate() error {
+	if err := opts.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return nil
+}
+
+func (opts RunOptions) validate() error {
+	ttl := time.Duration(opts.TTL) * time.Second
+	if opts.TTL == 0 {
+		ttl = defaultTTL * time.Second
+	}
+	interval := opts.HeartbeatInterval
+	if interval <= 0 {
+		// Derived from the TTL, which always leaves room for heartbeats.
+		return nil
+	}
+	if ttl < interval {
+		return fmt.Errorf("TTL of %s is shorter than the heartbeat interval of %s: the lease would expire between heartbeats; use a TTL of about three intervals", ttl, interval)
+	}
+	return nil
+}
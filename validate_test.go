@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{
+		EurekaServiceURLs: []string{"http://eureka-1:8761/eureka", "http://eureka-2:8761/eureka"},
+		AppID:             "orders",
+		Host:              "10.0.0.1",
+		Port:              8080,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		problem string
+	}{
+		{"valid", func(cfg *Config) {}, ""},
+		{"no URLs", func(cfg *Config) { cfg.EurekaServiceURLs = nil }, "no Eureka service URLs"},
+		{"duplicate URLs", func(cfg *Config) {
+			cfg.EurekaServiceURLs = []string{"http://eureka-1:8761/eureka", "http://eureka-1:8761/eureka/v2/"}
+		}, "duplicate Eureka service URL"},
+		{"relative URL", func(cfg *Config) { cfg.EurekaServiceURLs = []string{"eureka-1"} }, "invalid Eureka service URL"},
+		{"empty AppID", func(cfg *Config) { cfg.AppID = "" }, "empty AppID"},
+		{"IP other than host", func(cfg *Config) { cfg.IP = net.ParseIP("10.0.0.2") }, "other than the registered IP"},
+		{"IPv6 address", func(cfg *Config) { cfg.Host, cfg.IP = "orders-1", net.ParseIP("fd00::1") }, "not IPv4"},
+		{"host name and IP", func(cfg *Config) { cfg.Host, cfg.IP = "orders-1.internal", net.ParseIP("10.0.0.2") }, ""},
+		{"port 0", func(cfg *Config) { cfg.Port = 0 }, "port 0 out of range"},
+		{"TTL shorter than heartbeat", func(cfg *Config) {
+			cfg.Run = RunOptions{TTL: 10, HeartbeatInterval: 30 * time.Second}
+		}, "shorter than the heartbeat interval"},
+	}
+
+	for _, test := range tests {
+		cfg := valid
+		test.modify(&cfg)
+		err := cfg.Validate()
+		if test.problem == "" {
+			if err != nil {
+				t.Errorf("Validate() for %s returned %v; want nil", test.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("Validate() for %s = %v; want ErrInvalidConfig mentioning %q", test.name, err, test.problem)
+		}
+	}
+}
+
+func TestRegisterInstanceValidatesPayload(t *testing.T) {
+	f := newFakeEureka(t)
+	client := newTestClient(t, f)
+
+	_, err := client.RegisterInstance(context.Background(), nil, 30, false)
+	if !errors.Is(err, ErrInvalidInstance) || !strings.Contains(err.Error(), "ipAddr") {
+		t.Errorf("RegisterInstance without an IP returned %v; want ErrInvalidInstance mentioning ipAddr", err)
+	}
+	if got := f.count(http.MethodPost); got != 0 {
+		t.Errorf("server received %d registrations; want 0", got)
+	}
+
+	inst := eurekaapi.Instance{
+		HostName:       "orders-1",
+		App:            "ORDERS",
+		IPAddr:         "10.0.0.1",
+		Status:         StatusUp,
+		Port:           &eurekaapi.Port{Value: 70000, Enabled: true},
+		DataCenterInfo: eurekaapi.DataCenter{Name: eurekaapi.DefaultDataCenter},
+	}
+	err = inst.Validate("PAYMENTS")
+	for _, problem := range []string{"doesn't match the application", "port 70000 out of range"} {
+		if !strings.Contains(fmt.Sprint(err), problem) {
+			t.Errorf("Validate() = %v; want it to mention %q", err, problem)
+		}
+	}
+	inst.Port.Value = 8080
+	if err := inst.Validate("orders"); err != nil {
+		t.Errorf("Validate() of a valid instance returned %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const registrationPollInterval = 500 * time.Millisecond
+
+// ErrNotDiscoverable is returned when a registered instance doesn't become
+// visible through the registry in time, e.g. because replication between
+// Eureka nodes lags.
+var ErrNotDiscoverable = errors.New("instance registered but not discoverable")
+
+// WithRegistrationVerification makes RegisterInstance wait up to timeout for
+// the instance to be returned by the registry before it returns.
+func WithRegistrationVerification(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.verifyTimeout = timeout
+	}
+}
+
+// VerifyRegistration polls the registry until it returns this instance or
+// ctx is done, in which case the error wraps ErrNotDiscoverable.
+func (c *Client) VerifyRegistration(ctx context.Context) error {
+	timer := c.clock.NewTimer(0)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return fmt.Errorf("%w: %s: %w", ErrNotDiscoverable, c.instanceID, lastErr)
+		case <-timer.C():
+		}
+
+		_, lastErr = c.eurekaAPIClient.GetInstance(ctx, c.appID, c.instanceID)
+		if lastErr == nil {
+			return nil
+		}
+		timer.Reset(registrationPollInterval)
+	}
+}
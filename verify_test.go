@@ -0,0 +1,40 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistrationVerification(t *testing.T) {
+	f := newFakeEureka(t)
+	var lookups atomic.Int32
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		// Simulate replication lag: the instance shows up on the second poll.
+		if lookups.Add(1) < 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("<instance><instanceId>x</instanceId></instance>"))
+	})
+
+	client := newTestClient(t, f, WithRegistrationVerification(5*time.Second))
+	if _, err := client.RegisterInstance(context.Background(), testIP, 30, false); err != nil {
+		t.Fatalf("RegisterInstance returned error: %v", err)
+	}
+	if got := lookups.Load(); got != 2 {
+		t.Errorf("instance looked up %d times; want 2", got)
+	}
+
+	f.handle(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.VerifyRegistration(ctx); !errors.Is(err, ErrNotDiscoverable) {
+		t.Errorf("VerifyRegistration returned %v; want ErrNotDiscoverable", err)
+	}
+}
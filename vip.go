@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	eurekaapi "github.com/cassis163/eureka-go-client/internal/eureka-api"
+)
+
+// URL schemes Transport routes by VIP address rather than application name,
+// e.g. vip://inventory.internal/items. Requests to svip URLs are sent over
+// HTTPS to the secure port.
+const (
+	SchemeVIP       = "vip"
+	SchemeSecureVIP = "svip"
+)
+
+// InstancesByVIP returns the cached instances whose VIP address, or secure
+// VIP address if secure is set, includes vip. Instances may list several
+// comma-separated addresses, which are matched case-insensitively.
+func (c *Cache) InstancesByVIP(vip string, secure bool) []eurekaapi.Instance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var instances []eurekaapi.Instance
+	for _, app := range c.apps.Application {
+		for _, inst := range app.Instance {
+			addresses := inst.VipAddress
+			if secure {
+				addresses = inst.SecureVipAddress
+			}
+			if hasVIP(addresses, vip) {
+				instances = append(instances, inst)
+			}
+		}
+	}
+	return instances
+}
+
+func hasVIP(addresses, vip string) bool {
+	for _, a := range strings.Split(addresses, ",") {
+		if strings.EqualFold(strings.TrimSpace(a), vip) {
+			return true
+		}
+	}
+	return false
+}
+
+// EndpointsByVIP returns the endpoints of all UP instances serving vip, minus
+// those ejected by outlier detection. With secure set it looks up the secure
+// VIP address and only returns secure ports.
+func (r *Resolver) EndpointsByVIP(ctx context.Context, vip string, secure bool) ([]Endpoint, error) {
+	if err := r.populate(ctx); err != nil {
+		return nil, err
+	}
+
+	endpoint := r.endpoint
+	if secure {
+		endpoint = r.secureEndpoint
+	}
+	endpoints := r.upEndpoints(r.cache.InstancesByVIP(vip, secure), endpoint)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("%w: VIP %s", ErrNoInstances, vip)
+	}
+	if r.outliers != nil {
+		endpoints = r.outliers.filter(endpoints)
+	}
+	return endpoints, nil
+}
+
+func (r *Resolver) secureEndpoint(inst eurekaapi.Instance) (Endpoint, bool) {
+	if inst.SecurePort == nil || !inst.SecurePort.Enabled {
+		return Endpoint{}, false
+	}
+	ep, _ := r.endpoint(inst)
+	ep.Port, ep.Secure = inst.SecurePort.Value, true
+	return ep, true
+}
+
+// endpoints returns the candidate endpoints for req, which is addressed to
+// name: an application, or a VIP address if the URL has a VIP scheme.
+func (t *Transport) endpoints(req *http.Request, name string) ([]Endpoint, error) {
+	if t.app == "" {
+		switch req.URL.Scheme {
+		case SchemeVIP:
+			return t.resolver.EndpointsByVIP(req.Context(), name, false)
+		case SchemeSecureVIP:
+			return t.resolver.EndpointsByVIP(req.Context(), name, true)
+		}
+	}
+	return t.resolver.Endpoints(req.Context(), name)
+}